@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Value of the --algorithm flags which picks an algorithm based on the size of the problem.
+const autoAlgorithm = "auto"
+
+// Value of compute's --algorithm flag which estimates the probability from simulated runs instead, see
+// computeMonteCarlo.
+const monteCarloAlgorithm = "monte-carlo"
+
+// Returns the algorithm selected by an --algorithm flag. With "auto", memoized is used for tiny problems, where it only
+// visits a handful of states, and dp otherwise. Recursive is only used when explicitly requested.
+func selectAlgorithm(name string, size uint8, days uint) (pondersolve.Algorithm, error) {
+	if name == "" || name == autoAlgorithm {
+		algorithm := pondersolve.DP
+		if size <= 3 || days <= 2 {
+			algorithm = pondersolve.Memoized
+		}
+		log.Printf("using the %s algorithm", algorithm)
+		return algorithm, nil
+	}
+	for _, algorithm := range pondersolve.Algorithms {
+		if string(algorithm) == name {
+			return algorithm, nil
+		}
+	}
+	names := []string{autoAlgorithm}
+	for _, algorithm := range pondersolve.Algorithms {
+		names = append(names, string(algorithm))
+	}
+	return "", fmt.Errorf("unknown algorithm %q, expecting one of: %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Structural properties of a graph.
+type graphAnalysis struct {
+	Vertices       int     `json:"vertices"`
+	Edges          int     `json:"edges"`
+	DegreeSequence []int   `json:"degree_sequence"` // largest degree first
+	Connected      bool    `json:"connected"`
+	Components     [][]int `json:"components"`
+	Diameter       int     `json:"diameter"`       // -1 when the graph isn't connected
+	Eccentricities []int   `json:"eccentricities"` // by vertex, -1 when some vertices can't be reached
+	Tree           bool    `json:"tree"`
+	Bipartite      bool    `json:"bipartite"`
+}
+
+// Describes the structure of a graph.
+func analyze() {
+	g, err := pondersolve.Decode(pondersolve.Format(args.Analyze.Format), args.Analyze.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+
+	a := graphAnalysis{
+		Vertices:       int(g.Size()),
+		Edges:          g.EdgeCount(),
+		DegreeSequence: []int{},
+		Connected:      g.Connected(),
+		Components:     [][]int{},
+		Diameter:       g.Diameter(),
+		Eccentricities: g.Eccentricities(),
+		Tree:           g.IsTree(),
+		Bipartite:      g.Bipartite(),
+	}
+	for v := uint8(0); v < g.Size(); v++ {
+		a.DegreeSequence = append(a.DegreeSequence, g.Degree(v))
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(a.DegreeSequence)))
+	for _, component := range g.Components() {
+		vertices := []int{}
+		for _, v := range component {
+			vertices = append(vertices, int(v))
+		}
+		a.Components = append(a.Components, vertices)
+	}
+
+	if args.Analyze.JSON {
+		b, err := json.MarshalIndent(a, "", "  ")
+		if err != nil {
+			log.Panic(err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+	fmt.Printf("vertices: %d\n", a.Vertices)
+	fmt.Printf("edges: %d\n", a.Edges)
+	fmt.Printf("degree sequence: %v\n", a.DegreeSequence)
+	fmt.Printf("connected: %t\n", a.Connected)
+	fmt.Printf("components: %d %v\n", len(a.Components), a.Components)
+	if a.Diameter >= 0 {
+		fmt.Printf("diameter: %d\n", a.Diameter)
+	} else {
+		fmt.Println("diameter: infinite")
+	}
+	fmt.Printf("eccentricities: %v\n", a.Eccentricities)
+	fmt.Printf("tree: %t\n", a.Tree)
+	fmt.Printf("bipartite: %t\n", a.Bipartite)
+}
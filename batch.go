@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Result of computing a line of --graphs-file.
+type batchRow struct {
+	Line          int       `json:"line"`
+	Matrix        string    `json:"matrix"`
+	Edges         int       `json:"edges,omitempty"`
+	Probabilities []float64 `json:"probabilities,omitempty"` // by initial vertex, only vertex 0 without --all-vertices
+	Error         string    `json:"error,omitempty"`
+}
+
+// Computes every graph of --graphs-file, which has the same format as solve's databases. Malformed lines are reported
+// in their row, without stopping the batch.
+func computeBatch() {
+	ctx, stop := interruptibleContext()
+	defer stop()
+	if args.Compute.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.Compute.MaxDuration)
+		defer cancel()
+	}
+	source := openFileSource(args.Compute.GraphsFile, func(lineNumber int, offset int64, line string) {})
+	defer source.Close()
+
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(args.Compute.Model)
+	cache := args.Compute.open(model)
+	if cache != nil {
+		defer cache.Close()
+	}
+
+	// selected once per graph size, rather than logging the same choice for every line
+	algorithms := make(map[uint8]pondersolve.Algorithm)
+	var rows []batchRow
+	for {
+		number, matrix, ok := source.Next()
+		if !ok {
+			break
+		}
+		row := batchRow{Line: number, Matrix: matrix}
+		g, err := pondersolve.ParseGraph(matrix)
+		if err == nil {
+			err = checkUndirected(&g, args.Compute.Directed)
+		}
+		if err != nil {
+			row.Error = err.Error()
+			rows = append(rows, row)
+			continue
+		}
+		algorithm, ok := algorithms[g.Size()]
+		if !ok {
+			if algorithm, err = selectAlgorithm(args.Compute.Algorithm, g.Size(), args.Compute.Days); err != nil {
+				log.Print(err)
+				exit(exitInvalidInput)
+			}
+			algorithms[g.Size()] = algorithm
+		}
+		row.Edges = g.EdgeCount()
+		row.Probabilities, err = cache.compute(ctx, g, args.Compute.Days, args.Compute.Rate, !args.Compute.AllVertices, pondersolve.WithAlgorithm(algorithm),
+			pondersolve.WithModel(model))
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			log.Printf("computation took longer than %s", args.Compute.MaxDuration)
+			exit(exitInterrupted)
+		case errors.Is(err, context.Canceled):
+			log.Print("computation interrupted")
+			exit(exitInterrupted)
+		case err != nil:
+			row.Error = err.Error()
+		}
+		rows = append(rows, row)
+	}
+
+	switch {
+	case args.Compute.JSON:
+		b, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			log.Panic(err)
+		}
+		fmt.Println(string(b))
+	case args.Compute.CSVOut != "":
+		writeBatchCSV(rows)
+	default:
+		fmt.Printf("probability of all vertices infected after %d days:\n", args.Compute.Days)
+		fmt.Printf("%-6s %-6s %s\n", "line", "edges", "probability")
+		for _, row := range rows {
+			if row.Error != "" {
+				fmt.Printf("%-6d %-6s %s\n", row.Line, "-", row.Error)
+				continue
+			}
+			var probabilities []string
+			for _, p := range row.Probabilities {
+				probabilities = append(probabilities, fmt.Sprintf("%g%%", p*100.0))
+			}
+			fmt.Printf("%-6d %-6d %s\n", row.Line, row.Edges, strings.Join(probabilities, " "))
+		}
+	}
+	if args.Compute.CacheStats {
+		cache.printStats()
+	}
+}
+
+// Writes one line per row, with a column per initial vertex.
+func writeBatchCSV(rows []batchRow) {
+	file, err := os.Create(args.Compute.CSVOut)
+	if err != nil {
+		fatalf("invalid --csv-out: %s", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	columns := 1
+	for _, row := range rows {
+		if len(row.Probabilities) > columns {
+			columns = len(row.Probabilities)
+		}
+	}
+	header := []string{"line", "edges"}
+	for i := 0; i < columns; i++ {
+		header = append(header, fmt.Sprintf("vertex%d", i))
+	}
+	fmt.Fprintln(w, strings.Join(append(header, "error"), ","))
+	for _, row := range rows {
+		fields := []string{fmt.Sprint(row.Line), fmt.Sprint(row.Edges)}
+		for i := 0; i < columns; i++ {
+			if i < len(row.Probabilities) {
+				fields = append(fields, fmt.Sprint(row.Probabilities[i]))
+			} else {
+				fields = append(fields, "")
+			}
+		}
+		// keeps the error in a single column
+		fields = append(fields, strings.Replace(row.Error, ",", ";", -1))
+		fmt.Fprintln(w, strings.Join(fields, ","))
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("%d graphs written to %s\n", len(rows), args.Compute.CSVOut)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Result of --before, see pondersolve.Graph.InfectionOrder.
+type infectionOrder struct {
+	a, b                             uint8
+	aFirst, sameDay, bFirst, neither float64
+}
+
+// Parses --before: two distinct vertices separated by a comma.
+func parseVertexPair(text string, size uint8) (a, b uint8, err error) {
+	fields := strings.Split(text, ",")
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("invalid vertices %q, expecting two vertices separated by a comma", text)
+	}
+	var vertices [2]uint8
+	for i, field := range fields {
+		v, err := strconv.ParseUint(strings.TrimSpace(field), 10, 8)
+		if err != nil || v >= uint64(size) {
+			return 0, 0, fmt.Errorf("invalid vertices %q, expecting vertices between 0 and %d", text, size-1)
+		}
+		vertices[i] = uint8(v)
+	}
+	if vertices[0] == vertices[1] {
+		return 0, 0, fmt.Errorf("invalid vertices %q, expecting two different vertices", text)
+	}
+	return vertices[0], vertices[1], nil
+}
+
+func (o infectionOrder) print() {
+	fmt.Printf("infection order within %d days, starting from vertex %d:\n", args.Compute.Days, args.Compute.InitialVertex)
+	fmt.Printf("vertex %d before vertex %d: %g%%\n", o.a, o.b, o.aFirst*100.0)
+	fmt.Printf("same day: %g%%\n", o.sameDay*100.0)
+	fmt.Printf("vertex %d before vertex %d: %g%%\n", o.b, o.a, o.bFirst*100.0)
+	fmt.Printf("neither infected: %g%%\n", o.neither*100.0)
+}
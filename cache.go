@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// First line of cache files, bumped whenever the format or the meaning of the entries changes.
+const cacheHeader = "ponderthis-cache v1"
+
+// Result cache flags, shared by compute and solve.
+type cacheFlags struct {
+	Cache      string `type:"path" help:"file caching the probabilities across runs, keyed by canonical graph, days and rate"`
+	CacheStats bool   `help:"print the number of cache hits and misses at the end of the run"`
+}
+
+func (c cacheFlags) check() error {
+	if c.CacheStats && c.Cache == "" {
+		return fmt.Errorf("--cache-stats requires --cache")
+	}
+	return nil
+}
+
+// Opens the cache for the probabilities of the given model, nil when --cache isn't set.
+func (c cacheFlags) open(model pondersolve.TransmissionModel) *resultCache {
+	if c.Cache == "" {
+		return nil
+	}
+	return openResultCache(c.Cache, cacheModel(model))
+}
+
+// Append-only file of probabilities, one entry per line:
+//
+//	<canonical matrix> <days> <rate> <model> <probability for each initial vertex of the canonical graph>
+//
+// Isomorphic graphs share an entry, the probabilities are relabeled on the way in and out. When an entry appears
+// several times, the last one wins. Entries for other models are loaded, but never returned.
+type resultCache struct {
+	path    string
+	model   string   // infection model of the entries returned and written, see cacheModel
+	file    *os.File // nil when the cache is read-only, e.g. written by another version
+	entries map[string][]float64
+	hits    int
+	misses  int
+}
+
+// Loads a cache file, creating it if needed. Corrupted entries and unknown versions fall back to recomputing, with a
+// warning.
+func openResultCache(path, model string) *resultCache {
+	c := &resultCache{path: path, model: model, entries: make(map[string][]float64)}
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		fatalf("invalid --cache: %s", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fatalf("invalid --cache: %s", err)
+	}
+	if len(content) == 0 {
+		if _, err := fmt.Fprintln(file, cacheHeader); err != nil {
+			log.Panic(err)
+		}
+		c.file = file
+		return c
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if lines[0] != cacheHeader {
+		log.Printf("warning: %s isn't a %q file, ignoring it and recomputing every graph", path, cacheHeader)
+		file.Close()
+		return c
+	}
+	// an interrupted write leaves a partial last line, which is dropped along with the other corrupted entries
+	corrupted := 0
+	for _, line := range lines[1 : len(lines)-1] {
+		if !c.load(line) {
+			corrupted++
+		}
+	}
+	if last := lines[len(lines)-1]; last != "" {
+		corrupted++
+		if _, err := io.WriteString(file, "\n"); err != nil {
+			log.Panic(err)
+		}
+	}
+	if corrupted > 0 {
+		log.Printf("warning: %s: skipped %d corrupted entries, these graphs will be recomputed", path, corrupted)
+	}
+	c.file = file
+	return c
+}
+
+// Parses an entry, returns false if it's corrupted.
+func (c *resultCache) load(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 5 {
+		return false
+	}
+	g, err := pondersolve.ParseMatrix(fields[0])
+	if err != nil || !g.IsCanonical() || len(fields)-4 != int(g.Size()) {
+		return false
+	}
+	days, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return false
+	}
+	rate, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return false
+	}
+	var r []float64
+	for _, field := range fields[4:] {
+		p, err := strconv.ParseFloat(field, 64)
+		if err != nil || !(p >= 0 && p <= 1) || math.IsNaN(p) {
+			return false
+		}
+		r = append(r, p)
+	}
+	c.entries[cacheKey(fields[0], uint(days), rate, fields[3])] = r
+	return true
+}
+
+func cacheKey(matrix string, days uint, rate float64, model string) string {
+	return fmt.Sprintf("%s %d %s %s", matrix, days, strconv.FormatFloat(rate, 'g', -1, 64), model)
+}
+
+// Get implements pondersolve.Cache.
+func (c *resultCache) Get(g pondersolve.Graph, days uint, rate float64) ([]float64, bool) {
+	canonical, perm := g.CanonicalPermutation()
+	cached, ok := c.entries[cacheKey(canonical.Matrix(), days, rate, c.model)]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	r := make([]float64, g.Size())
+	for i := range r {
+		r[i] = cached[perm[i]]
+	}
+	return r, true
+}
+
+// Put implements pondersolve.Cache. Entries are written right away, so that they survive an interrupted run.
+func (c *resultCache) Put(g pondersolve.Graph, days uint, rate float64, r []float64) {
+	canonical, perm := g.CanonicalPermutation()
+	key := cacheKey(canonical.Matrix(), days, rate, c.model)
+	cached := make([]float64, g.Size())
+	for i := range r {
+		cached[perm[i]] = r[i]
+	}
+	c.entries[key] = cached
+	if c.file == nil {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(key)
+	for _, p := range cached {
+		b.WriteString(" ")
+		b.WriteString(strconv.FormatFloat(p, 'g', -1, 64))
+	}
+	b.WriteString("\n")
+	// a single write per entry, so that concurrent runs don't interleave partial lines
+	if _, err := io.WriteString(c.file, b.String()); err != nil {
+		log.Panic(err)
+	}
+}
+
+// Computes the probability for each initial vertex, only vertex 0 with firstResultOnly. Without a cache, c is nil.
+// Every vertex is computed on a cache miss, so that the entry is complete.
+func (c *resultCache) compute(ctx context.Context, g pondersolve.Graph, days uint, rate float64, firstResultOnly bool, opts ...pondersolve.Option) ([]float64, error) {
+	if c == nil {
+		if firstResultOnly {
+			opts = append(opts, pondersolve.FirstResultOnly())
+		}
+		return g.Compute(ctx, days, rate, opts...)
+	}
+	r, ok := c.Get(g, days, rate)
+	if !ok {
+		var err error
+		if r, err = g.Compute(ctx, days, rate, opts...); err != nil {
+			return nil, err
+		}
+		c.Put(g, days, rate, r)
+	}
+	if firstResultOnly {
+		r = r[:1]
+	}
+	return r, nil
+}
+
+// Logs the hit and miss counts, keeping them out of the JSON and CSV outputs.
+func (c *resultCache) printStats() {
+	log.Printf("cache: %d hits, %d misses, %d entries in %s\n", c.hits, c.misses, len(c.entries), c.path)
+}
+
+func (c *resultCache) Close() error {
+	if c.file == nil {
+		return nil
+	}
+	return c.file.Close()
+}
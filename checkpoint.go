@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Written to --checkpoint while solve runs, so that --resume can continue from the last graph processed.
+type checkpoint struct {
+	Options string      `json:"options"` // flags which change the results, see checkpointOptions
+	Offset  int64       `json:"offset"`  // bytes of --graphs processed
+	Line    int         `json:"line"`    // last line of --graphs processed
+	Summary solveResult `json:"summary"`
+}
+
+// Describes the flags which must be the same for a run to be resumed. --algorithm, --order and the output flags can
+// change.
+func checkpointOptions() string {
+	s := &args.Solve
+	return fmt.Sprintf("graphs=%s model=%s target=%v tolerance=%g top=%d rate=%g days=%d days-min=%d days-max=%d constraint=%q interval=%t near-miss=%g shard=%d/%d sample=%d seed=%d vertices=%d-%d edges=%d-%d",
+		s.Graphs, s.Model, s.Target, s.Tolerance, s.Top, s.Rate, s.Days, s.DaysMin, s.DaysMax, s.Constraint, s.Interval, s.NearMiss,
+		s.Shard, s.NumShards, s.Sample, s.Seed, s.MinVertices, s.MaxVertices, s.MinEdges, s.MaxEdges)
+}
+
+// Reads --checkpoint, checking that it was written with the same flags.
+func readCheckpoint(path string) (checkpoint, error) {
+	var c checkpoint
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Options != checkpointOptions() {
+		return c, fmt.Errorf("%s was written with other flags: %s", path, c.Options)
+	}
+	return c, nil
+}
+
+// Writes --checkpoint to a temporary file first, so that an interruption never leaves a truncated checkpoint.
+func writeCheckpoint(path string, c checkpoint) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Saves the position after the last graph processed along with the solver's summary.
+func saveCheckpoint(summary pondersolve.Summary, offset int64, line int, minDays, maxDays uint, algorithm pondersolve.Algorithm) {
+	c := checkpoint{
+		Options: checkpointOptions(),
+		Offset:  offset,
+		Line:    line,
+		Summary: newSolveResult(summary, minDays, maxDays, algorithm),
+	}
+	if err := writeCheckpoint(args.Solve.Checkpoint, c); err != nil {
+		log.Panic(err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Sets the flags which are part of checkpointOptions, along with the algorithm and the order.
+func setCheckpointFlags() {
+	s := &args.Solve
+	s.Graphs, s.Target, s.Tolerance, s.Top = "graphs.txt", []float64{0.7}, 0.01, 1
+	s.Rate, s.Days, s.DaysMin, s.DaysMax = 0.1, 29, 0, 0
+	s.Shard, s.NumShards, s.MinVertices = 0, 1, 0
+	s.Algorithm, s.Order = "auto", "file"
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	database := openFileSource(writeTestDatabase(t, dir, 100), func(int, int64, string) {})
+	defer database.Close()
+	summary, err := pondersolve.Solve(context.Background(), database, pondersolve.SolveOptions{
+		Targets:   []float64{0.3, 0.7},
+		Tolerance: 0.05,
+		Top:       5,
+		MinDays:   3,
+		MaxDays:   6,
+		Rate:      0.2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary.Elapsed = 1500 * time.Millisecond
+	summary.Interrupted = true
+
+	setCheckpointFlags()
+	args.Solve.Target, args.Solve.Tolerance, args.Solve.Top = []float64{0.3, 0.7}, 0.05, 5
+	args.Solve.Rate, args.Solve.Days, args.Solve.DaysMin, args.Solve.DaysMax = 0.2, 0, 3, 6
+	args.Solve.Checkpoint = filepath.Join(dir, "checkpoint.json")
+	saveCheckpoint(summary, 1234, 100, 3, 6, pondersolve.DP)
+	c, err := readCheckpoint(args.Solve.Checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Offset != 1234 || c.Line != 100 {
+		t.Errorf("got offset %d line %d, want 1234 and 100", c.Offset, c.Line)
+	}
+	if got := c.Summary.summary(); !reflect.DeepEqual(got, summary) {
+		t.Errorf("got summary %+v, want %+v", got, summary)
+	}
+	if _, err := os.Stat(args.Solve.Checkpoint + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary checkpoint left behind: %v", err)
+	}
+}
+
+func TestReadCheckpointErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	setCheckpointFlags()
+	path := filepath.Join(dir, "checkpoint.json")
+	if err := writeCheckpoint(path, checkpoint{Options: checkpointOptions()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCheckpoint(path); err != nil {
+		t.Fatalf("same flags: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func()
+	}{
+		{"graphs", func() { args.Solve.Graphs = "other.txt" }},
+		{"target", func() { args.Solve.Target = []float64{0.7, 0.8} }},
+		{"tolerance", func() { args.Solve.Tolerance = 0.02 }},
+		{"rate", func() { args.Solve.Rate = 0.2 }},
+		{"days", func() { args.Solve.Days = 30 }},
+		{"shard", func() { args.Solve.Shard, args.Solve.NumShards = 1, 2 }},
+		{"vertices", func() { args.Solve.MinVertices = 5 }},
+	}
+	for _, tt := range tests {
+		setCheckpointFlags()
+		tt.change()
+		if _, err := readCheckpoint(path); err == nil {
+			t.Errorf("%s changed: got no error", tt.name)
+		}
+	}
+	// the algorithm and the order can change
+	setCheckpointFlags()
+	args.Solve.Algorithm, args.Solve.Order = "recursive", "heuristic"
+	if _, err := readCheckpoint(path); err != nil {
+		t.Errorf("algorithm and order changed: %v", err)
+	}
+
+	if _, err := readCheckpoint(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("missing checkpoint: got error %v, want a not exist error", err)
+	}
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := ioutil.WriteFile(corrupt, []byte(`{"options": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCheckpoint(corrupt); err == nil {
+		t.Error("corrupt checkpoint: got no error")
+	}
+}
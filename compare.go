@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Computes the probabilities of a graph with every algorithm, for every initial vertex, and reports the largest
+// difference between two algorithms. Algorithms which take longer than --timeout (typically recursive, for large day
+// counts) are skipped.
+func compare() {
+	g, err := pondersolve.ParseMatrix(args.Compare.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+
+	var algorithms []pondersolve.Algorithm
+	var results [][]float64
+	for _, algorithm := range pondersolve.Algorithms {
+		algorithmCtx, cancel := context.WithTimeout(ctx, args.Compare.Timeout)
+		r, err := g.Compute(algorithmCtx, args.Compare.Days, args.Compare.Rate, pondersolve.WithAlgorithm(algorithm))
+		cancel()
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			fmt.Printf("%s skipped: took longer than %s\n", algorithm, args.Compare.Timeout)
+			continue
+		case errors.Is(err, context.Canceled):
+			log.Print("computation interrupted")
+			exit(exitInterrupted)
+		case err != nil:
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		algorithms = append(algorithms, algorithm)
+		results = append(results, r)
+	}
+
+	var header strings.Builder
+	fmt.Fprintf(&header, "%-8s", "vertex")
+	for _, algorithm := range algorithms {
+		fmt.Fprintf(&header, " %-22s", algorithm)
+	}
+	fmt.Println(strings.TrimRight(header.String(), " "))
+	for i := uint8(0); i < g.Size(); i++ {
+		var row strings.Builder
+		fmt.Fprintf(&row, "%-8d", i)
+		for _, r := range results {
+			fmt.Fprintf(&row, " %-22g", r[i])
+		}
+		fmt.Println(strings.TrimRight(row.String(), " "))
+	}
+
+	divergence := 0.0
+	for a := range results {
+		for b := a + 1; b < len(results); b++ {
+			for i := range results[a] {
+				divergence = math.Max(divergence, math.Abs(results[a][i]-results[b][i]))
+			}
+		}
+	}
+	fmt.Printf("max pairwise difference: %g\n", divergence)
+	if len(results) < 2 {
+		fmt.Println("fewer than 2 algorithms completed, nothing to compare")
+	}
+	if divergence > args.Compare.MaxDivergence {
+		exit(exitDiverged)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Parses solve's --constraint flags, e.g. "days=20,target=0.50". The tolerance defaults to --tolerance.
+func parseConstraints(specs []string, tolerance float64) ([]pondersolve.Constraint, error) {
+	var r []pondersolve.Constraint
+	for _, spec := range specs {
+		c := pondersolve.Constraint{Tolerance: tolerance}
+		var hasDays, hasTarget bool
+		for _, field := range strings.Split(spec, ",") {
+			parts := strings.SplitN(strings.TrimSpace(field), "=", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid constraint %q, expecting days=D,target=T[,tolerance=E]", spec)
+			}
+			switch parts[0] {
+			case "days":
+				days, err := strconv.ParseUint(parts[1], 10, 32)
+				if err != nil || days == 0 {
+					return nil, fmt.Errorf("invalid constraint %q: days must be a positive integer", spec)
+				}
+				c.Days, hasDays = uint(days), true
+			case "target", "tolerance":
+				v, err := strconv.ParseFloat(parts[1], 64)
+				if err != nil || math.IsNaN(v) {
+					return nil, fmt.Errorf("invalid constraint %q: %s %q isn't a number", spec, parts[0], parts[1])
+				}
+				if parts[0] == "target" {
+					if err := checkTarget(v); err != nil {
+						return nil, fmt.Errorf("invalid constraint %q: %s", spec, err)
+					}
+					c.Target, hasTarget = v, true
+				} else {
+					if err := checkTolerance(v); err != nil {
+						return nil, fmt.Errorf("invalid constraint %q: %s", spec, err)
+					}
+					c.Tolerance = v
+				}
+			default:
+				return nil, fmt.Errorf("invalid constraint %q: unknown field %q", spec, parts[0])
+			}
+		}
+		if !hasDays || !hasTarget {
+			return nil, fmt.Errorf("invalid constraint %q: days and target are required", spec)
+		}
+		r = append(r, c)
+	}
+	return r, nil
+}
+
+// Describes the value of a solution for each constraint, e.g. "day 20: 0.5001 (target 0.5), day 30: 0.6998 (target 0.7)".
+func formatConstraintValues(constraints []pondersolve.Constraint, values []float64) string {
+	parts := make([]string, len(constraints))
+	for k, c := range constraints {
+		parts[k] = fmt.Sprintf("day %d: %g (target %g)", c.Days, values[k], c.Target)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Formats the values of a solution for each constraint as a comma separated list.
+func formatValues(values []float64) string {
+	parts := make([]string, len(values))
+	for k, v := range values {
+		parts[k] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
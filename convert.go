@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Converts a list of graphs from one format to another, one graph per line. Lines which can't be converted are
+// reported and skipped.
+func convert() {
+	in, err := os.Open(args.Convert.In)
+	if err != nil {
+		fatalf("%s", err)
+	}
+	defer in.Close()
+	out, err := os.Create(args.Convert.Out)
+	if err != nil {
+		fatalf("invalid --out: %s", err)
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+
+	// validated by validateArgs
+	var perm []uint8
+	if args.Convert.Permute != "" {
+		perm, _ = parsePermutation(args.Convert.Permute)
+	}
+
+	converted, failed := 0, 0
+	lineNumber := 0
+	fileScanner := bufio.NewScanner(in)
+	for fileScanner.Scan() {
+		lineNumber++
+		g, err := pondersolve.Decode(pondersolve.Format(args.Convert.From), fileScanner.Text())
+		if err == nil {
+			err = checkUndirected(&g, args.Convert.Directed)
+		}
+		if err == nil && args.Convert.Complement {
+			g = g.Complement()
+		}
+		if err == nil && perm != nil {
+			g, err = g.Permute(perm)
+		}
+		if err == nil && args.Convert.Canonicalize {
+			g = g.Canonical()
+		}
+		var line string
+		if err == nil {
+			line, err = g.Encode(pondersolve.Format(args.Convert.To))
+		}
+		if err != nil {
+			log.Printf("line %d: %s, skipping", lineNumber, err)
+			failed++
+			continue
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			log.Panic(err)
+		}
+		converted++
+	}
+	if err := fileScanner.Err(); err != nil {
+		log.Panic(err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("%d graphs written to %s\n", converted, args.Convert.Out)
+	if failed > 0 {
+		fmt.Printf("%d lines skipped\n", failed)
+	}
+}
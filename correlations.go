@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+)
+
+// Returns the Pearson correlations matching a covariance matrix returned by InfectionCovariances. A vertex whose
+// infection doesn't vary has no correlation with anything, it's reported as 0.
+func correlations(covariances [][]float64) [][]float64 {
+	r := make([][]float64, len(covariances))
+	for i := range covariances {
+		r[i] = make([]float64, len(covariances))
+		for j := range covariances {
+			if covariances[i][i] > 0 && covariances[j][j] > 0 {
+				r[i][j] = covariances[i][j] / math.Sqrt(covariances[i][i]*covariances[j][j])
+			}
+		}
+	}
+	return r
+}
+
+// Prints the covariance and correlation matrices of the infection of every pair of vertices, as tables or as CSV in
+// args.Compute.CSVOut.
+func printCorrelations(covariances [][]float64) {
+	matrices := []struct {
+		name   string
+		values [][]float64
+	}{
+		{"covariance", covariances},
+		{"correlation", correlations(covariances)},
+	}
+	if args.Compute.CSVOut == "" {
+		for _, m := range matrices {
+			fmt.Printf("%s of the infection of each pair of vertices after %d days, starting from vertex %d:\n", m.name,
+				args.Compute.Days, args.Compute.InitialVertex)
+			var header strings.Builder
+			fmt.Fprintf(&header, "%-6s", "vertex")
+			for j := range m.values {
+				fmt.Fprintf(&header, " %10d", j)
+			}
+			fmt.Println(header.String())
+			for i, row := range m.values {
+				var line strings.Builder
+				fmt.Fprintf(&line, "%-6d", i)
+				for _, value := range row {
+					fmt.Fprintf(&line, " %10.6f", value)
+				}
+				fmt.Println(line.String())
+			}
+		}
+		return
+	}
+
+	file, err := os.Create(args.Compute.CSVOut)
+	if err != nil {
+		fatalf("invalid --csv-out: %s", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	header := []string{"matrix", "vertex"}
+	for j := range covariances {
+		header = append(header, fmt.Sprint(j))
+	}
+	fmt.Fprintln(w, strings.Join(header, ","))
+	for _, m := range matrices {
+		for i, row := range m.values {
+			fields := []string{m.name, fmt.Sprint(i)}
+			for _, value := range row {
+				fields = append(fields, fmt.Sprint(value))
+			}
+			fmt.Fprintln(w, strings.Join(fields, ","))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("covariance and correlation matrices written to %s\n", args.Compute.CSVOut)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+func TestCorrelations(t *testing.T) {
+	tests := []struct {
+		matrix  string
+		days    uint
+		rate    float64
+		initial uint8
+	}{
+		{"0100,1010,0101,0010", 3, 0.5, 1},
+		{"0110,1000,1000,0000", 5, 0.4, 0},
+		{testPuzzleMatrix, 10, 0.1, 0},
+	}
+	for _, tt := range tests {
+		g, err := pondersolve.ParseMatrix(tt.matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		distribution, err := g.StateDistribution(context.Background(), tt.days, tt.rate, tt.initial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		covariances := pondersolve.InfectionCovariances(distribution, g.Size())
+		r := correlations(covariances)
+		for i := range r {
+			for j := range r {
+				if r[i][j] != r[j][i] || math.Abs(r[i][j]) > 1+1e-12 || math.IsNaN(r[i][j]) {
+					t.Errorf("%s from vertex %d: correlation %d-%d is %g, %d-%d is %g", tt.matrix, tt.initial, i, j, r[i][j], j, i, r[j][i])
+				}
+			}
+			// vertices which are always or never infected don't vary, they have no correlation with anything
+			want := 1.0
+			if covariances[i][i] == 0 {
+				want = 0
+			}
+			if math.Abs(r[i][i]-want) > 1e-12 {
+				t.Errorf("%s from vertex %d: correlation of vertex %d with itself is %g, want %g", tt.matrix, tt.initial, i, r[i][i], want)
+			}
+		}
+	}
+}
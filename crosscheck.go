@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Slack for the range and monotonicity checks, which compare floating point results.
+const crosscheckEpsilon = 1e-12
+
+// Computes random graphs with every algorithm and checks that the algorithms agree, and that the probabilities are in
+// [0, 1], don't decrease with the number of days and don't decrease when an edge is added. Recursive is skipped above
+// --recursive-max-days, and algorithms which take longer than --timeout are skipped for that graph.
+func crosscheck() {
+	opts := &args.Crosscheck
+	if opts.MaxSize < 1 || opts.MaxSize > pondersolve.MaxSize {
+		log.Printf("invalid maximum size %d, expecting 1 to %d", opts.MaxSize, pondersolve.MaxSize)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+	rng := rand.New(rand.NewSource(opts.Seed))
+	// dp first, the other algorithms are compared to it
+	algorithms := []pondersolve.Algorithm{pondersolve.DP}
+	for _, algorithm := range pondersolve.Algorithms {
+		if algorithm != pondersolve.DP {
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+
+	violations, skipped := 0, 0
+	for iteration := 1; iteration <= opts.Iterations; iteration++ {
+		n := uint8(1 + rng.Intn(int(opts.MaxSize)))
+		g := pondersolve.RandomGraph(rng, n, rng.Float64())
+		rate := rng.Float64()
+		days := uint(rng.Intn(int(opts.MaxDays) + 1))
+		reproduce := fmt.Sprintf("compute --graph %s --days %d --rate %v", g.Matrix(), days, rate)
+		violation := func(format string, a ...interface{}) {
+			violations++
+			fmt.Printf("iteration %d: %s\n  %s\n", iteration, fmt.Sprintf(format, a...), reproduce)
+			if !opts.KeepGoing {
+				exit(exitDiverged)
+			}
+		}
+
+		// r[d][i] for every algorithm which completed in time, dp first
+		var results [][][]float64
+		var completed []pondersolve.Algorithm
+		for _, algorithm := range algorithms {
+			if algorithm == pondersolve.Recursive && days > opts.RecursiveMaxDays {
+				skipped++
+				continue
+			}
+			algorithmCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+			r, err := g.ComputeDays(algorithmCtx, 0, days, rate, pondersolve.WithAlgorithm(algorithm))
+			cancel()
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				skipped++
+				continue
+			case errors.Is(err, context.Canceled):
+				log.Print("crosscheck interrupted")
+				exit(exitInterrupted)
+			case err != nil:
+				log.Panic(err)
+			}
+			results = append(results, r)
+			completed = append(completed, algorithm)
+		}
+		if len(results) == 0 {
+			continue
+		}
+
+		for a := 1; a < len(results); a++ {
+			for d := range results[a] {
+				for i := range results[a][d] {
+					if diff := math.Abs(results[a][d][i] - results[0][d][i]); diff > opts.MaxDivergence {
+						violation("%s and %s differ by %g after %d days, initial vertex %d", completed[0], completed[a], diff, d, i)
+					}
+				}
+			}
+		}
+		dp := results[0]
+		for d := range dp {
+			for i, v := range dp[d] {
+				if !(v >= -crosscheckEpsilon && v <= 1+crosscheckEpsilon) {
+					violation("probability %g out of [0, 1] after %d days, initial vertex %d", v, d, i)
+				}
+				if d > 0 && v < dp[d-1][i]-crosscheckEpsilon {
+					violation("probability decreases from %g to %g on day %d, initial vertex %d", dp[d-1][i], v, d, i)
+				}
+			}
+		}
+
+		// adding a random missing edge can't lower the probability
+		var missing [][2]uint8
+		for i := uint8(0); i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if !g.HasEdge(i, j) {
+					missing = append(missing, [2]uint8{i, j})
+				}
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		edge := missing[rng.Intn(len(missing))]
+		supergraph := g
+		if err := supergraph.AddEdge(edge[0], edge[1]); err != nil {
+			log.Panic(err)
+		}
+		r, err := supergraph.Compute(ctx, days, rate)
+		if err != nil {
+			log.Print(err)
+			exit(exitInterrupted)
+		}
+		for i, v := range r {
+			if v < dp[days][i]-crosscheckEpsilon {
+				violation("adding edge %d-%d lowers the probability from %g to %g, initial vertex %d", edge[0], edge[1], dp[days][i], v, i)
+			}
+		}
+	}
+
+	fmt.Printf("%d iterations, %d violations, %d algorithm runs skipped (recursive above %d days or longer than %s)\n", opts.Iterations,
+		violations, skipped, opts.RecursiveMaxDays, opts.Timeout)
+	if violations > 0 {
+		exit(exitDiverged)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Looks for the smallest sets of edges whose removal brings the probability of infecting every vertex, starting from
+// vertex 0, below --below. Removing the edges which connect a vertex to the rest of the graph always works, so among
+// the smallest sets, the ones which keep the graph connected are preferred.
+func optimizeCuts() {
+	opts := &args.OptimizeCuts
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	if opts.MaxRemovals < 1 {
+		log.Printf("invalid maximum number of removals %d, expecting at least 1", opts.MaxRemovals)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+	evaluations := 0
+	compute := func(g pondersolve.Graph) float64 {
+		evaluations++
+		r, err := g.Compute(ctx, opts.Days, opts.Rate, pondersolve.FirstResultOnly())
+		switch {
+		case errors.Is(err, context.Canceled):
+			log.Print("computation interrupted")
+			exit(exitInterrupted)
+		case err != nil:
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		return r[0]
+	}
+
+	var edges [][2]uint8
+	for i := uint8(0); i < g.Size(); i++ {
+		for j := i + 1; j < g.Size(); j++ {
+			if g.HasEdge(i, j) {
+				edges = append(edges, [2]uint8{i, j})
+			}
+		}
+	}
+	before := compute(g)
+	fmt.Printf("probability of all vertices infected after %d days: %g%%\n", opts.Days, before*100.0)
+	if before < opts.Below {
+		fmt.Printf("already below %g, no edges to remove\n", opts.Below)
+		return
+	}
+
+	type cut struct {
+		removed   [][2]uint8
+		value     float64
+		connected bool
+	}
+	for k := 1; k <= opts.MaxRemovals && k <= len(edges); k++ {
+		var found []cut
+		for pattern := uint64(1)<<uint(k) - 1; pattern < 1<<uint(len(edges)); pattern = nextCombination(pattern) {
+			c := cut{}
+			cutGraph := g
+			for e, edge := range edges {
+				if pattern&(1<<uint(e)) != 0 {
+					c.removed = append(c.removed, edge)
+					if err := cutGraph.RemoveEdge(edge[0], edge[1]); err != nil {
+						log.Panic(err)
+					}
+				}
+			}
+			c.value = compute(cutGraph)
+			c.connected = cutGraph.Connected()
+			if c.value < opts.Below {
+				found = append(found, c)
+			}
+		}
+		if len(found) == 0 {
+			fmt.Printf("no cut of %d edges brings the probability below %g\n", k, opts.Below)
+			continue
+		}
+
+		// keeping the graph connected first, then the lowest probability
+		best := found[0]
+		for _, c := range found[1:] {
+			if c.connected && !best.connected || c.connected == best.connected && c.value < best.value {
+				best = c
+			}
+		}
+		var removed []string
+		for _, edge := range best.removed {
+			removed = append(removed, fmt.Sprintf("%d-%d", edge[0], edge[1]))
+		}
+		fmt.Printf("%d cuts of %d edges bring the probability below %g\n", len(found), k, opts.Below)
+		fmt.Printf("removed edges: %s\n", strings.Join(removed, " "))
+		fmt.Printf("probability after removal: %g%% (before: %g%%)\n", best.value*100.0, before*100.0)
+		if best.connected {
+			fmt.Println("the graph stays connected")
+		} else {
+			fmt.Println("the graph is disconnected, as with every other cut of this size which works")
+		}
+		fmt.Printf("%d candidates evaluated\n", evaluations)
+		return
+	}
+	fmt.Printf("%d candidates evaluated\n", evaluations)
+	exit(exitOutsideTolerance)
+}
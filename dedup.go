@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Copies a graphs file, skipping the graphs which are isomorphic to a graph of an earlier line. The first graph of each
+// isomorphism class is written as is, or as its canonical form with --canonicalize. Lines which can't be parsed are
+// reported and skipped.
+func dedup() {
+	in, err := os.Open(args.Dedup.In)
+	if err != nil {
+		fatalf("%s", err)
+	}
+	defer in.Close()
+	out, err := os.Create(args.Dedup.Out)
+	if err != nil {
+		fatalf("invalid --out: %s", err)
+	}
+	defer out.Close()
+	w := bufio.NewWriter(out)
+
+	// canonical forms of the graphs written so far, like solve's --dedupe-isomorphic
+	seen := make(map[pondersolve.Graph]struct{})
+	written, isomorphic, failed := 0, 0, 0
+	lineNumber := 0
+	fileScanner := bufio.NewScanner(in)
+	for fileScanner.Scan() {
+		lineNumber++
+		line := fileScanner.Text()
+		g, err := pondersolve.ParseGraph(line)
+		if err == nil {
+			err = checkUndirected(&g, args.Dedup.Directed)
+		}
+		if err != nil {
+			log.Printf("line %d: %s, skipping", lineNumber, err)
+			failed++
+			continue
+		}
+		key := g.Canonical()
+		if _, ok := seen[key]; ok {
+			isomorphic++
+			continue
+		}
+		seen[key] = struct{}{}
+		if args.Dedup.Canonicalize {
+			line = key.Matrix()
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			log.Panic(err)
+		}
+		written++
+	}
+	if err := fileScanner.Err(); err != nil {
+		log.Panic(err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("%d graphs written to %s, %d isomorphic graphs skipped\n", written, args.Dedup.Out, isomorphic)
+	if failed > 0 {
+		fmt.Printf("%d lines skipped\n", failed)
+	}
+}
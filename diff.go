@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Compares two graphs: whether they are identical or isomorphic, the edges to add and remove to turn a into b, and
+// their probabilities. The exit status tells identical, isomorphic and different graphs apart.
+func diff() {
+	opts := &args.Diff
+	a, err := pondersolve.ParseMatrix(opts.A)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	b, err := pondersolve.ParseMatrix(opts.B)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+
+	exitCode := 0
+	switch {
+	case a == b:
+		fmt.Println("identical")
+	case pondersolve.Isomorphic(a, b):
+		fmt.Println("isomorphic")
+		exitCode = exitIsomorphic
+	default:
+		fmt.Println("different")
+		exitCode = exitDifferent
+	}
+
+	if a.Size() != b.Size() {
+		fmt.Printf("a has %d vertices, b has %d: no edge list\n", a.Size(), b.Size())
+	} else {
+		aligned := a
+		if opts.Align {
+			// vertex i of a and vertex j of b have the same canonical label when perm[i] = j
+			_, canonicalA := a.CanonicalPermutation()
+			_, canonicalB := b.CanonicalPermutation()
+			fromCanonicalB := make([]uint8, b.Size())
+			for j, label := range canonicalB {
+				fromCanonicalB[label] = uint8(j)
+			}
+			perm := make([]uint8, a.Size())
+			var mapping []string
+			for i, label := range canonicalA {
+				perm[i] = fromCanonicalB[label]
+				mapping = append(mapping, fmt.Sprintf("%d->%d", i, perm[i]))
+			}
+			if aligned, err = a.Permute(perm); err != nil {
+				log.Panic(err)
+			}
+			fmt.Printf("vertex mapping from a to b: %s\n", strings.Join(mapping, " "))
+		}
+		var added, removed []string
+		for i := uint8(0); i < a.Size(); i++ {
+			for j := i + 1; j < a.Size(); j++ {
+				switch {
+				case b.HasEdge(i, j) && !aligned.HasEdge(i, j):
+					added = append(added, fmt.Sprintf("%d-%d", i, j))
+				case !b.HasEdge(i, j) && aligned.HasEdge(i, j):
+					removed = append(removed, fmt.Sprintf("%d-%d", i, j))
+				}
+			}
+		}
+		fmt.Printf("edges to add: %s\n", edgeList(added))
+		fmt.Printf("edges to remove: %s\n", edgeList(removed))
+	}
+
+	ctx, stop := interruptibleContext()
+	defer stop()
+	var probabilities [2]float64
+	for k, g := range []pondersolve.Graph{a, b} {
+		r, err := g.Compute(ctx, opts.Days, opts.Rate, pondersolve.WithAlgorithm(pondersolve.DP))
+		switch {
+		case errors.Is(err, context.Canceled):
+			log.Print("computation interrupted")
+			exit(exitInterrupted)
+		case err != nil:
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		probabilities[k] = r[opts.InitialVertex]
+	}
+	fmt.Printf("probability of all vertices infected after %d days, starting from vertex %d:\n", opts.Days, opts.InitialVertex)
+	fmt.Printf("a: %g%%\n", probabilities[0]*100.0)
+	fmt.Printf("b: %g%%\n", probabilities[1]*100.0)
+	fmt.Printf("difference (b - a): %g%%\n", (probabilities[1]-probabilities[0])*100.0)
+	exit(exitCode)
+}
+
+func edgeList(edges []string) string {
+	if len(edges) == 0 {
+		return "none"
+	}
+	return strings.Join(edges, " ")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Writes the best solution for the first target to --dot, as a Graphviz graph with the initially infected vertex
+// filled in. The solution is pivoted, so the infected vertex is vertex 0.
+func writeSolutionDOT(summary pondersolve.Summary) {
+	best := summary.Results[0].Best
+	if len(best) == 0 {
+		log.Printf("no solution, %s not written", args.Solve.DOT)
+		return
+	}
+	s := best[0]
+	label := fmt.Sprintf("probability %g after %d days at rate %g", s.Value, s.Days, args.Solve.Rate)
+	if err := writeDOT(args.Solve.DOT, s.Graph, 0, label); err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	if !args.Solve.JSON {
+		fmt.Printf("best solution written to %s\n", args.Solve.DOT)
+	}
+}
+
+// Writes g to path as a Graphviz graph, with vertex infected filled in red. Graphs with an edge in only one direction
+// are written as a digraph.
+func writeDOT(path string, g pondersolve.Graph, infected uint8, label string) error {
+	directed := false
+	for i := uint8(0); i < g.Size(); i++ {
+		for j := i + 1; j < g.Size(); j++ {
+			directed = directed || g.HasEdge(i, j) != g.HasEdge(j, i)
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+
+	kind, arrow := "graph", "--"
+	if directed {
+		kind, arrow = "digraph", "->"
+	}
+	fmt.Fprintf(w, "%s solution {\n", kind)
+	fmt.Fprintf(w, "  label=%q;\n", label)
+	for v := uint8(0); v < g.Size(); v++ {
+		if v == infected {
+			fmt.Fprintf(w, "  %d [style=filled, fillcolor=red, fontcolor=white];\n", v)
+		} else {
+			fmt.Fprintf(w, "  %d;\n", v)
+		}
+	}
+	for i := uint8(0); i < g.Size(); i++ {
+		for j := uint8(0); j < g.Size(); j++ {
+			// undirected edges are only written once
+			if g.HasEdge(i, j) && (directed || i <= j) {
+				fmt.Fprintf(w, "  %d %s %d;\n", i, arrow, j)
+			}
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return w.Flush()
+}
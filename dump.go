@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Writes the distribution of states on each day from 0 to days in dir, one file per day named after the day, e.g.
+// "day-07.txt". Each line holds a state and its probability, states which can't happen are left out.
+func dumpDistributions(ctx context.Context, g pondersolve.Graph, days uint, rate float64, initial uint8, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	// zero-padded, so that the files sort by day
+	width := len(strconv.FormatUint(uint64(days), 10))
+	write := func(day uint, distribution []float64) error {
+		file, err := os.Create(filepath.Join(dir, fmt.Sprintf("day-%0*d.txt", width, day)))
+		if err != nil {
+			return err
+		}
+		w := bufio.NewWriter(file)
+		for state, p := range distribution {
+			if p != 0 {
+				fmt.Fprintf(w, "%s %s\n", formatState(state, g.Size()), strconv.FormatFloat(p, 'g', -1, 64))
+			}
+		}
+		if err := w.Flush(); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	initialDistribution := make([]float64, 1<<g.Size())
+	initialDistribution[1<<initial] = 1.0
+	if err := write(0, initialDistribution); err != nil {
+		return err
+	}
+	return g.Evolve(ctx, 1<<initial, rate, days, write)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Precision of the maximum likelihood rate found by the golden-section search.
+const estimateRateTolerance = 1e-9
+
+// Step used to estimate the curvature of the log-likelihood around its maximum.
+const curvatureStep = 1e-4
+
+// Finds the rate which maximizes the likelihood of independent observed trajectories, see likelihood.
+func estimateRate() {
+	opts := &args.EstimateRate
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	trajectories, err := readTrajectories(g, opts.Trajectories)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	logLikelihood := func(rate float64) float64 {
+		r := 0.0
+		for _, states := range trajectories {
+			for day := 1; day < len(states); day++ {
+				p, err := g.TransitionProbability(rate, states[day-1], states[day])
+				if err != nil {
+					log.Panic(err)
+				}
+				r += math.Log(p)
+			}
+		}
+		return r
+	}
+
+	// the grid excludes 0 and 1, where most trajectories are impossible
+	rates := make([]float64, opts.Grid)
+	curve := make([]float64, opts.Grid)
+	best := 0
+	for k := range rates {
+		rates[k] = float64(k+1) / float64(opts.Grid+1)
+		curve[k] = logLikelihood(rates[k])
+		if curve[k] > curve[best] {
+			best = k
+		}
+	}
+	if curve[0] == curve[len(curve)-1] && curve[best] == curve[0] {
+		log.Print("the trajectories don't depend on the rate: no vertex was ever exposed to an infected neighbor")
+		exit(exitInvalidInput)
+	}
+
+	// the maximum is between the neighbors of the best grid point, the bracket extends to 0 or 1 at the edges
+	low, high := 0.0, 1.0
+	if best > 0 {
+		low = rates[best-1]
+	}
+	if best < len(rates)-1 {
+		high = rates[best+1]
+	}
+	mle := goldenSectionMax(logLikelihood, low, high, estimateRateTolerance)
+	fmt.Printf("trajectories: %d\n", len(trajectories))
+	fmt.Printf("maximum likelihood rate: %g\n", mle)
+	fmt.Printf("log-likelihood: %g\n", logLikelihood(mle))
+
+	// the observed information is the curvature of the log-likelihood, which is too flat or undefined at the edges
+	h := curvatureStep
+	if mle-h <= 0 || mle+h >= 1 {
+		fmt.Println("95% confidence interval: unavailable, the maximum is at the edge of [0, 1]")
+	} else {
+		curvature := (logLikelihood(mle+h) - 2*logLikelihood(mle) + logLikelihood(mle-h)) / (h * h)
+		if curvature < 0 {
+			se := 1 / math.Sqrt(-curvature)
+			fmt.Printf("95%% confidence interval: [%g, %g] (standard error %g)\n", math.Max(mle-1.96*se, 0), math.Min(mle+1.96*se, 1), se)
+		} else {
+			fmt.Println("95% confidence interval: unavailable, the log-likelihood isn't curved around the maximum")
+		}
+	}
+	printLikelihoodCurve(rates, curve)
+}
+
+// Reads one trajectory per line, refusing empty trajectories and transitions which are impossible at every rate.
+func readTrajectories(g pondersolve.Graph, path string) ([][]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var r [][]int
+	lineNumber := 0
+	fileScanner := bufio.NewScanner(file)
+	for fileScanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(fileScanner.Text())
+		if line == "" {
+			return nil, fmt.Errorf("line %d: empty trajectory", lineNumber)
+		}
+		states, err := parseTrajectory(line, g.Size())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+		}
+		if len(states) < 2 {
+			return nil, fmt.Errorf("line %d: a trajectory needs at least two days", lineNumber)
+		}
+		// steps which are impossible at a rate of 0.5 are impossible at every rate strictly between 0 and 1
+		for day := 1; day < len(states); day++ {
+			if p, _ := g.TransitionProbability(0.5, states[day-1], states[day]); p == 0 {
+				return nil, fmt.Errorf("line %d, day %d: impossible transition, %s", lineNumber, day, impossibleStep(g, 0.5, states[day-1], states[day]))
+			}
+		}
+		r = append(r, states)
+	}
+	if err := fileScanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(r) == 0 {
+		return nil, fmt.Errorf("%s doesn't contain any trajectory", path)
+	}
+	return r, nil
+}
+
+// Returns the x in [low, high] maximizing f, assuming f is unimodal in the interval.
+func goldenSectionMax(f func(float64) float64, low, high, tolerance float64) float64 {
+	ratio := (math.Sqrt(5) - 1) / 2
+	x1, x2 := high-ratio*(high-low), low+ratio*(high-low)
+	f1, f2 := f(x1), f(x2)
+	for high-low > tolerance {
+		if f1 < f2 {
+			low, x1, f1 = x1, x2, f2
+			x2 = low + ratio*(high-low)
+			f2 = f(x2)
+		} else {
+			high, x2, f2 = x2, x1, f1
+			x1 = high - ratio*(high-low)
+			f1 = f(x1)
+		}
+	}
+	return (low + high) / 2
+}
+
+// Prints the log-likelihood at each rate of the grid, as a table or as CSV in args.EstimateRate.CSVOut.
+func printLikelihoodCurve(rates, curve []float64) {
+	if args.EstimateRate.CSVOut == "" {
+		fmt.Printf("%-10s %s\n", "rate", "log-likelihood")
+		for k, rate := range rates {
+			fmt.Printf("%-10g %g\n", rate, curve[k])
+		}
+		return
+	}
+
+	file, err := os.Create(args.EstimateRate.CSVOut)
+	if err != nil {
+		fatalf("invalid --csv-out: %s", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, "rate,log_likelihood")
+	for k, rate := range rates {
+		fmt.Fprintf(w, "%g,%g\n", rate, curve[k])
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("%d rates written to %s\n", len(rates), args.EstimateRate.CSVOut)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"time"
+)
+
+// Weight of the latest measurement in the moving averages. A small weight smooths out spikes, e.g. GC pauses.
+const etaSmoothing = 0.05
+
+// Estimates the time left to process a database of graphs. Graphs with more vertices take longer to process, so the
+// average processing time is tracked separately for each vertex count. Graphs outside of [minSize, maxSize] are filtered
+// out before being processed and are ignored.
+type etaEstimator struct {
+	minSize   int
+	maxSize   int
+	remaining [10]int     // number of graphs left to process, indexed by vertex count (9 for larger graphs)
+	average   [10]float64 // moving average of the processing time in nanoseconds, by vertex count
+	overall   float64     // moving average across all vertex counts, used until a given vertex count is measured
+}
+
+func sizeBucket(size int) int {
+	if size > 9 {
+		return 9
+	}
+	return size
+}
+
+func (e *etaEstimator) ignored(size int) bool {
+	return size < e.minSize || size > e.maxSize
+}
+
+// Records a graph with a given number of vertices which is left to process.
+func (e *etaEstimator) add(size int) {
+	if e.ignored(size) {
+		return
+	}
+	e.remaining[sizeBucket(size)]++
+}
+
+// Records count graphs with a given number of vertices which are left to process.
+func (e *etaEstimator) addMany(size int, count int) {
+	if e.ignored(size) {
+		return
+	}
+	e.remaining[sizeBucket(size)] += count
+}
+
+// Processed records the time it took to process a graph.
+func (e *etaEstimator) Processed(size int, d time.Duration) {
+	if e.ignored(size) {
+		return
+	}
+	b := sizeBucket(size)
+	e.remaining[b]--
+	e.average[b] = smooth(e.average[b], float64(d))
+	e.overall = smooth(e.overall, float64(d))
+}
+
+// Skipped records a graph which was skipped without being processed.
+func (e *etaEstimator) Skipped(size int) {
+	if e.ignored(size) {
+		return
+	}
+	e.remaining[sizeBucket(size)]--
+}
+
+func smooth(average, value float64) float64 {
+	if average == 0 {
+		return value
+	}
+	return average + etaSmoothing*(value-average)
+}
+
+// ETA returns the estimated time to process the remaining graphs.
+func (e *etaEstimator) ETA() time.Duration {
+	total := 0.0
+	for b, remaining := range e.remaining {
+		average := e.average[b]
+		if average == 0 {
+			average = e.overall
+		}
+		total += float64(remaining) * average
+	}
+	return time.Duration(total).Round(time.Millisecond)
+}
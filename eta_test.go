@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Runs f, returning what it printed to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	out, err := ioutil.TempFile("", "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+	stdout := os.Stdout
+	os.Stdout = out
+	defer func() { os.Stdout = stdout }()
+	f()
+	b, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestSolveETAGraph6(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rng := rand.New(rand.NewSource(1))
+	var lines []string
+	for i := 0; i < 100; i++ {
+		g := pondersolve.RandomGraph(rng, uint8(3+rng.Intn(6)), 0.5)
+		format := pondersolve.FormatGraph6
+		if i%3 == 0 {
+			format = pondersolve.FormatSparse6
+		}
+		line, err := g.Encode(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	path := filepath.Join(dir, "graphs.g6")
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, flags := range [][]string{nil, {"--min-vertices", "2"}, {"--min-vertices", "5", "--max-vertices", "7"}, {"--sample", "40"}} {
+		parseArgs(t, append([]string{"solve", "--graphs", path, "--days", "4", "--tolerance", "0.01"}, flags...)...)
+		out := captureStdout(t, solve)
+		etas := regexp.MustCompile(`eta: (\S+)`).FindAllStringSubmatch(out, -1)
+		if len(etas) == 0 {
+			t.Fatalf("%q: no progress printed:\n%s", flags, out)
+		}
+		for _, m := range etas {
+			eta, err := time.ParseDuration(m[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if eta < 0 {
+				t.Fatalf("%q: negative eta: %s", flags, m[0])
+			}
+		}
+		if last := etas[len(etas)-1][1]; last != "0s" {
+			t.Errorf("%q: eta %s after the last graph, want 0s", flags, last)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Maximum difference between 1 and the sum of a row of the transition matrix.
+const rowSumTolerance = 1e-12
+
+// Non-zero entry of the transition matrix, for --sparse.
+type transitionEntry struct {
+	From        int     `json:"from"`
+	To          int     `json:"to"`
+	Probability float64 `json:"probability"`
+}
+
+// Sparse transition matrix, for --sparse.
+type sparseTransitions struct {
+	Vertices int               `json:"vertices"`
+	Rate     float64           `json:"rate"`
+	States   int               `json:"states"`
+	Entries  []transitionEntry `json:"entries"`
+}
+
+// Writes the one-day transition matrix of a graph: row = current state, column = next state, with bit i of a state set
+// when vertex i is infected.
+func exportTransitions() {
+	opts := &args.ExportTransitions
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	m, err := g.TransitionMatrix(opts.Rate)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+
+	// a malformed matrix is a bug, better to stop than to hand it over
+	for from, row := range m {
+		sum := 0.0
+		for _, p := range row {
+			sum += p
+		}
+		if math.Abs(sum-1) > rowSumTolerance {
+			log.Panicf("row %d of the transition matrix sums to %g", from, sum)
+		}
+	}
+	last := len(m) - 1
+	for to, p := range m[last] {
+		if (to == last && p != 1) || (to != last && p != 0) {
+			log.Panicf("the all-infected state isn't absorbing: it moves to state %d with probability %g", to, p)
+		}
+	}
+
+	file, err := os.Create(opts.Out)
+	if err != nil {
+		fatalf("invalid --out: %s", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	if opts.Sparse {
+		t := sparseTransitions{Vertices: int(g.Size()), Rate: opts.Rate, States: len(m), Entries: []transitionEntry{}}
+		for from, row := range m {
+			for to, p := range row {
+				if p != 0 {
+					t.Entries = append(t.Entries, transitionEntry{From: from, To: to, Probability: p})
+				}
+			}
+		}
+		b, err := json.MarshalIndent(t, "", "  ")
+		if err != nil {
+			log.Panic(err)
+		}
+		w.Write(b)
+		fmt.Fprintln(w)
+	} else {
+		for _, row := range m {
+			for to, p := range row {
+				if to > 0 {
+					w.WriteString(",")
+				}
+				w.WriteString(strconv.FormatFloat(p, 'g', -1, 64))
+			}
+			w.WriteString("\n")
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("%dx%d transition matrix written to %s\n", len(m), len(m), opts.Out)
+}
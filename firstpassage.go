@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Returns the probability that every vertex gets infected on exactly each day, given cumulative[d], the probability
+// that every vertex is infected within d days, along with the mean day when every vertex is infected within the last
+// day and the probability that some vertices are still healthy after it. The all-infected state is absorbing, so the
+// probability for day d is cumulative[d] - cumulative[d-1]. The probabilities and the residual sum to 1.
+func firstPassage(cumulative []float64) (probabilities []float64, mean, residual float64) {
+	probabilities = make([]float64, len(cumulative))
+	for d := range cumulative {
+		previous := 0.0
+		if d > 0 {
+			previous = cumulative[d-1]
+		}
+		// the cumulative probabilities can decrease by a rounding error
+		probabilities[d] = math.Max(cumulative[d]-previous, 0)
+		mean += float64(d) * probabilities[d]
+	}
+	finished := cumulative[len(cumulative)-1]
+	if finished > 0 {
+		mean /= finished
+	}
+	return probabilities, mean, 1 - finished
+}
+
+// Prints the distribution returned by firstPassage.
+func printFirstPassage(cumulative []float64) {
+	probabilities, mean, residual := firstPassage(cumulative)
+	fmt.Printf("day on which every vertex is infected, starting from vertex %d:\n", args.Compute.InitialVertex)
+	fmt.Printf("%-5s %-24s %s\n", "day", "probability", "cumulative")
+	for d, p := range probabilities {
+		if d == 0 && cumulative[0] == 0 {
+			// only a graph with a single vertex is fully infected on day 0
+			continue
+		}
+		fmt.Printf("%-5d %-24g %g\n", d, p, cumulative[d])
+	}
+	if cumulative[len(cumulative)-1] > 0 {
+		fmt.Printf("mean day, when every vertex is infected within %d days: %g\n", len(cumulative)-1, mean)
+	}
+	fmt.Printf("probability that some vertices are still healthy after %d days: %g\n", len(cumulative)-1, residual)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+func TestFirstPassage(t *testing.T) {
+	tests := []struct {
+		matrix string
+		days   uint
+		rate   float64
+	}{
+		{"0", 5, 0.1},
+		{"01,10", 0, 0.5},
+		{"01,10", 10, 0.5},
+		{"010,101,010", 20, 0.3},
+		{"000,000,000", 5, 0.5}, // never fully infected
+		{testPuzzleMatrix, 30, 0.1},
+		{testPuzzleMatrix, 100, 0.9},
+		{testPuzzleMatrix, 30, 1},
+	}
+	for _, tt := range tests {
+		g, err := pondersolve.ParseMatrix(tt.matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := g.ComputeDays(context.Background(), 0, tt.days, tt.rate, pondersolve.FirstResultOnly())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cumulative []float64
+		for _, values := range r {
+			cumulative = append(cumulative, values[0])
+		}
+		probabilities, mean, residual := firstPassage(cumulative)
+		if len(probabilities) != int(tt.days)+1 {
+			t.Fatalf("%s, %d days: got %d probabilities", tt.matrix, tt.days, len(probabilities))
+		}
+		sum := residual
+		for d, p := range probabilities {
+			if p < 0 {
+				t.Errorf("%s, %d days, rate %g: probability %g on day %d", tt.matrix, tt.days, tt.rate, p, d)
+			}
+			sum += p
+		}
+		if residual < 0 || residual > 1 || math.Abs(sum-1) > 1e-12 {
+			t.Errorf("%s, %d days, rate %g: the probabilities and the residual %g sum to %g", tt.matrix, tt.days, tt.rate, residual, sum)
+		}
+		if mean < 0 || mean > float64(tt.days) {
+			t.Errorf("%s, %d days, rate %g: mean day %g", tt.matrix, tt.days, tt.rate, mean)
+		}
+	}
+}
+
+func TestFirstPassageAtRateOne(t *testing.T) {
+	// the path 0-1-2-3 is fully infected on day 3 exactly
+	probabilities, mean, residual := firstPassage([]float64{0, 0, 0, 1, 1})
+	if want := []float64{0, 0, 0, 1, 0}; !reflect.DeepEqual(probabilities, want) || mean != 3 || residual != 0 {
+		t.Errorf("got %v, mean %g, residual %g, want %v, mean 3, residual 0", probabilities, mean, residual, want)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Writes a database of graphs with a given number of vertices, in the format used by solve.
+func generate() {
+	file, err := os.Create(args.Generate.Out)
+	if err != nil {
+		fatalf("invalid --out: %s", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+
+	enumerated, connected, canonical, written := 0, 0, 0, 0
+	n := args.Generate.N
+	slots := uint(n) * uint(n-1) / 2
+	limit := uint64(1) << slots
+	first, next := uint64(0), func(pattern uint64) uint64 { return pattern + 1 }
+	if args.Generate.Edges >= 0 {
+		// only enumerate the bit patterns with exactly the requested number of edges
+		first, next = uint64(1)<<uint(args.Generate.Edges)-1, nextCombination
+	}
+	for pattern := first; pattern < limit; pattern = next(pattern) {
+		g := pondersolve.FromUpperTriangle(n, pattern)
+		enumerated++
+		if !g.Connected() {
+			if args.Generate.ConnectedOnly {
+				continue
+			}
+		} else {
+			connected++
+		}
+		if args.Generate.Canonical {
+			if !g.IsCanonical() {
+				continue
+			}
+			canonical++
+		}
+		if _, err := fmt.Fprintln(w, g.Matrix()); err != nil {
+			log.Panic(err)
+		}
+		written++
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("enumerated: %d, connected: %d", enumerated, connected)
+	if args.Generate.Canonical {
+		fmt.Printf(", canonical: %d", canonical)
+	}
+	fmt.Printf("\n%d graphs written to %s\n", written, args.Generate.Out)
+}
+
+// Returns the next larger integer with the same number of bits set (Gosper's hack).
+func nextCombination(pattern uint64) uint64 {
+	if pattern == 0 {
+		return math.MaxUint64
+	}
+	c := pattern & -pattern
+	r := pattern + c
+	return (((r ^ pattern) >> 2) / c) | r
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Runs generate with the given flags, returning the lines written.
+func generateLines(t *testing.T, n uint8, edges int, connectedOnly, canonical bool) []string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "generate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	args.Generate.N = n
+	args.Generate.Edges = edges
+	args.Generate.ConnectedOnly = connectedOnly
+	args.Generate.Canonical = canonical
+	args.Generate.Out = filepath.Join(dir, "graphs.txt")
+	generate()
+
+	f, err := os.Open(args.Generate.Out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return lines
+}
+
+func TestGenerateConnectedCanonical(t *testing.T) {
+	// number of connected graphs on n unlabeled vertices, OEIS A001349
+	tests := []struct {
+		n    uint8
+		want int
+	}{
+		{1, 1},
+		{2, 1},
+		{3, 2},
+		{4, 6},
+		{5, 21},
+		{6, 112},
+		{7, 853},
+	}
+	for _, tt := range tests {
+		if tt.n == 7 && testing.Short() {
+			continue
+		}
+		if got := len(generateLines(t, tt.n, -1, true, true)); got != tt.want {
+			t.Errorf("generate --n %d --canonical wrote %d graphs, want %d", tt.n, got, tt.want)
+		}
+	}
+}
+
+// Returns n choose k.
+func binomial(n, k int) int {
+	r := 1
+	for i := 1; i <= k; i++ {
+		r = r * (n - k + i) / i
+	}
+	return r
+}
+
+func TestGenerateEdges(t *testing.T) {
+	tests := []struct {
+		n     uint8
+		edges int
+	}{
+		{1, 0},
+		{4, 0},
+		{4, 3},
+		{4, 6},
+		{5, 4},
+		{6, 7},
+		{7, 9},
+	}
+	for _, tt := range tests {
+		lines := generateLines(t, tt.n, tt.edges, false, false)
+		slots := int(tt.n) * int(tt.n-1) / 2
+		if want := binomial(slots, tt.edges); len(lines) != want {
+			t.Errorf("generate --n %d --edges %d wrote %d graphs, want C(%d, %d) = %d", tt.n, tt.edges, len(lines), slots, tt.edges, want)
+		}
+		// the lines are read by solve
+		for _, line := range lines {
+			g, err := pondersolve.ParseGraph(line)
+			if err != nil {
+				t.Fatalf("generate --n %d --edges %d wrote %q: %s", tt.n, tt.edges, line, err)
+			}
+			if g.Size() != tt.n || g.EdgeCount() != tt.edges {
+				t.Fatalf("generate --n %d --edges %d wrote %q, which has %d vertices and %d edges", tt.n, tt.edges, line, g.Size(), g.EdgeCount())
+			}
+		}
+	}
+}
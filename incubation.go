@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Computes the SEI model with --incubation instead of compute's probability: whether every vertex got infected, and
+// whether every vertex is already infectious, after --days.
+func computeIncubation(ctx context.Context, g pondersolve.Graph) {
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(args.Compute.Model)
+	infected, infectious, err := g.ComputeIncubation(ctx, args.Compute.Days, args.Compute.Rate, args.Compute.Incubation, args.Compute.InitialVertex,
+		pondersolve.WithModel(model))
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("computation took longer than %s", args.Compute.MaxDuration)
+		exit(exitInterrupted)
+	case errors.Is(err, context.Canceled):
+		log.Print("computation interrupted")
+		exit(exitInterrupted)
+	case err != nil:
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	fmt.Printf("SEI model with an incubation of %d days, starting from vertex %d:\n", args.Compute.Incubation, args.Compute.InitialVertex)
+	fmt.Printf("probability of all vertices infected after %d days: %g%%\n", args.Compute.Days, infected*100.0)
+	fmt.Printf("probability of all vertices infectious after %d days: %g%%\n", args.Compute.Days, infectious*100.0)
+	if args.Compute.Target >= 0 {
+		checkComputeTarget(infected)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Quantiles below this distance from their level are considered reached, the marginals can fall short of a level by a
+// rounding error.
+const quantileEpsilon = 1e-12
+
+// Row format of --infection-times, shared by the header so that the columns line up.
+const infectionTimesFormat = "%-7v %-7v %-16v %-24v %v\n"
+
+// When a vertex gets infected, see infectionTimes. The quantiles are -1 when they aren't reached within --days, and
+// mean is only meaningful when infected is positive.
+type infectionTime struct {
+	median, p90    int
+	mean, infected float64
+}
+
+// Returns, for each vertex, quantiles of the day on which it gets infected, the mean of that day if it gets infected
+// and the probability that it does within the horizon. The probability that vertex j is infected by day d is the sum
+// of the distribution over the states containing j, and it increases with d since infected vertices stay infected: the
+// probability that j gets infected on day d is the difference between days d and d-1.
+func infectionTimes(distributions [][]float64, size uint8) []infectionTime {
+	times := make([]infectionTime, size)
+	for v := uint8(0); v < size; v++ {
+		median, p90 := -1, -1
+		mean, previous := 0.0, 0.0
+		for d, distribution := range distributions {
+			marginal := 0.0
+			for state, p := range distribution {
+				if state&(1<<v) != 0 {
+					marginal += p
+				}
+			}
+			// the marginals of the initial vertex wobble around 1 by rounding errors
+			if p := marginal - previous; p > quantileEpsilon {
+				mean += float64(d) * p
+			}
+			previous = marginal
+			if median < 0 && marginal >= 0.5-quantileEpsilon {
+				median = d
+			}
+			if p90 < 0 && marginal >= 0.9-quantileEpsilon {
+				p90 = d
+			}
+		}
+		if previous > 0 {
+			mean /= previous
+		}
+		times[v] = infectionTime{median: median, p90: p90, mean: mean, infected: previous}
+	}
+	return times
+}
+
+// Prints, for each vertex, when it gets infected within --days, see infectionTimes.
+func printInfectionTimes(distributions [][]float64, size uint8) {
+	days := len(distributions) - 1
+	fmt.Printf("day on which each vertex gets infected, starting from vertex %d:\n", args.Compute.InitialVertex)
+	fmt.Printf(infectionTimesFormat, "vertex", "median", "90th percentile", "mean if infected", "never infected")
+	for v, infection := range infectionTimes(distributions, size) {
+		meanText := "-"
+		if infection.infected > 0 {
+			meanText = fmt.Sprintf("%g", infection.mean)
+		}
+		fmt.Printf(infectionTimesFormat, v, formatQuantile(infection.median, days), formatQuantile(infection.p90, days), meanText,
+			fmt.Sprintf("%g%%", math.Max(1-infection.infected, 0)*100.0))
+	}
+}
+
+// Formats a quantile day, -1 when the level isn't reached within the horizon.
+func formatQuantile(day, days int) string {
+	if day < 0 {
+		return fmt.Sprintf(">%d", days)
+	}
+	return fmt.Sprint(day)
+}
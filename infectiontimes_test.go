@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+func TestInfectionTimes(t *testing.T) {
+	tests := []struct {
+		days        uint
+		rate        float64
+		median, p90 int
+	}{
+		// 1-0.7^t reaches 0.5 on day 2 and 0.9 on day 7
+		{10, 0.3, 2, 7},
+		{5, 0.3, 2, -1},
+		// 1-0.5^t reaches 0.5 exactly on day 1
+		{10, 0.5, 1, 4},
+		{10, 1, 1, 1},
+		{10, 0, -1, -1},
+	}
+	g, err := pondersolve.ParseMatrix("01,10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		distributions, err := g.StateDistributions(context.Background(), tt.days, tt.rate, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		times := infectionTimes(distributions, 2)
+		if got := times[0]; got.median != 0 || got.p90 != 0 || got.mean != 0 || math.Abs(got.infected-1) > 1e-12 {
+			t.Errorf("%d days at rate %g: initial vertex got %+v", tt.days, tt.rate, got)
+		}
+
+		// In K2, vertex 1 gets infected on day d with the geometric probability rate * (1-rate)^(d-1).
+		infected, mean := 0.0, 0.0
+		for d := 1; d <= int(tt.days); d++ {
+			p := tt.rate * math.Pow(1-tt.rate, float64(d-1))
+			infected += p
+			mean += float64(d) * p
+		}
+		if infected > 0 {
+			mean /= infected
+		}
+		got := times[1]
+		if got.median != tt.median || got.p90 != tt.p90 || math.Abs(got.mean-mean) > 1e-12 || math.Abs(got.infected-infected) > 1e-12 {
+			t.Errorf("%d days at rate %g: got %+v, want median %d, 90th percentile %d, mean %g, infected %g", tt.days, tt.rate, got,
+				tt.median, tt.p90, mean, infected)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Maximum distance between 1 and the sum of the weights of --initial-dist.
+const initialDistTolerance = 1e-6
+
+// Parses --initial-dist: the probability of each vertex to be the initially infected one, or "uniform".
+func parseInitialDistribution(text string, size uint8) ([]float64, error) {
+	weights := make([]float64, size)
+	if text == "uniform" {
+		for i := range weights {
+			weights[i] = 1 / float64(size)
+		}
+		return weights, nil
+	}
+	fields := strings.Split(text, ",")
+	if len(fields) != int(size) {
+		return nil, fmt.Errorf("invalid initial distribution %q, expecting %d weights or \"uniform\"", text, size)
+	}
+	sum := 0.0
+	for i, field := range fields {
+		w, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || !(w >= 0) || math.IsInf(w, 1) {
+			return nil, fmt.Errorf("invalid initial distribution %q, weight %q isn't a non-negative number", text, field)
+		}
+		weights[i] = w
+		sum += w
+	}
+	if math.Abs(sum-1) > initialDistTolerance {
+		return nil, fmt.Errorf("invalid initial distribution %q, the weights add up to %g instead of 1", text, sum)
+	}
+	return weights, nil
+}
+
+// Prints the probability averaged over the initial distribution, followed by each vertex's contribution. Returns the
+// averaged probability.
+func printInitialDistribution(weights, r []float64) float64 {
+	mixed := 0.0
+	for i, w := range weights {
+		mixed += w * r[i]
+	}
+	fmt.Printf("probability of all vertices infected after %d days, with the initial distribution: %g%%\n", args.Compute.Days, mixed*100.0)
+	for i, w := range weights {
+		fmt.Printf("vertex %d: weight %g, probability %g%%, contribution %g%%\n", i, w, r[i]*100.0, w*r[i]*100.0)
+	}
+	return mixed
+}
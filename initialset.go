@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Computes the probability when every vertex of --initial is infected on day 0, with any algorithm.
+func computeFrom(ctx context.Context, g pondersolve.Graph, initial []uint8) {
+	algorithm, err := selectAlgorithm(args.Compute.Algorithm, g.Size(), args.Compute.Days)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	threads := args.Compute.Threads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(args.Compute.Model)
+	p, err := g.ComputeFrom(ctx, args.Compute.Days, args.Compute.Rate, initial, pondersolve.WithAlgorithm(algorithm),
+		pondersolve.WithThreads(threads), pondersolve.WithModel(model))
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("computation took longer than %s", args.Compute.MaxDuration)
+		exit(exitInterrupted)
+	case errors.Is(err, context.Canceled):
+		log.Print("computation interrupted")
+		exit(exitInterrupted)
+	case err != nil:
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	var vertices []string
+	for _, v := range initial {
+		vertices = append(vertices, fmt.Sprint(v))
+	}
+	fmt.Printf("probability of all vertices infected after %d days, starting from vertices %s: %g%%\n", args.Compute.Days,
+		strings.Join(vertices, ","), p*100.0)
+	if args.Compute.Target >= 0 {
+		checkComputeTarget(p)
+	}
+}
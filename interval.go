@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Prints an interval containing the exact probability, and whether it's within tolerance of --target when checking
+// the target.
+func printInterval(interval pondersolve.Interval, checkTarget bool) {
+	fmt.Printf("guaranteed interval starting from vertex 0: [%g, %g], width %g\n", interval.Lo, interval.Hi, interval.Width())
+	if !checkTarget {
+		return
+	}
+	switch interval.Classify(args.Compute.Target, args.Compute.Tolerance) {
+	case pondersolve.Within:
+		fmt.Println("the exact probability is within tolerance of the target")
+	case pondersolve.Outside:
+		fmt.Println("the exact probability is outside the tolerance of the target")
+	case pondersolve.Undecided:
+		fmt.Println("undecided: the interval straddles the tolerance, the exact probability may or may not be within it")
+	}
+}
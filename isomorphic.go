@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Checks whether two graphs are the same up to a relabeling of their vertices.
+func isomorphic() {
+	a, err := pondersolve.ParseMatrix(args.Isomorphic.A)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	b, err := pondersolve.ParseMatrix(args.Isomorphic.B)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+
+	perm, ok := pondersolve.Isomorphism(a, b)
+	if !ok {
+		fmt.Println("not isomorphic")
+		exit(exitNotIsomorphic)
+	}
+	var mapping []string
+	for i, v := range perm {
+		mapping = append(mapping, fmt.Sprintf("%d->%d", i, v))
+	}
+	fmt.Println("isomorphic")
+	fmt.Printf("vertex mapping: %s\n", strings.Join(mapping, " "))
+}
+
+// Prints the canonical form of a graph.
+func canonicalize() {
+	g, err := pondersolve.ParseMatrix(args.Canonicalize.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	c := g.Canonical()
+	fmt.Println(c.Matrix())
+	fmt.Printf("key: %#016x\n", g.CanonicalKey())
+	fmt.Println(c)
+}
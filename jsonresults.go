@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Output of compute --json for a single graph.
+type computeResult struct {
+	Graph           pondersolve.Graph `json:"graph"` // after --restrict, --complement and --permute
+	Days            uint              `json:"days"`
+	Rate            float64           `json:"rate"`
+	Model           string            `json:"model"`
+	Algorithm       string            `json:"algorithm"`
+	Probabilities   []float64         `json:"probabilities"` // by initial vertex
+	Target          *float64          `json:"target,omitempty"`
+	Delta           *float64          `json:"delta,omitempty"` // probability of --initial-vertex minus the target
+	WithinTolerance *bool             `json:"within_tolerance,omitempty"`
+	ElapsedSeconds  float64           `json:"elapsed_seconds"`
+}
+
+// Prints compute's results as JSON, exiting with exitOutsideTolerance like checkComputeTarget when --target is set and
+// the probability of --initial-vertex isn't within tolerance.
+func printComputeJSON(g pondersolve.Graph, algorithm pondersolve.Algorithm, r []float64, elapsed time.Duration) {
+	result := computeResult{
+		Graph:          g,
+		Days:           args.Compute.Days,
+		Rate:           args.Compute.Rate,
+		Model:          args.Compute.Model,
+		Algorithm:      string(algorithm),
+		Probabilities:  r,
+		ElapsedSeconds: elapsed.Seconds(),
+	}
+	within := true
+	if args.Compute.Target >= 0 {
+		delta := r[args.Compute.InitialVertex] - args.Compute.Target
+		within = math.Abs(delta) < args.Compute.Tolerance
+		result.Target, result.Delta, result.WithinTolerance = &args.Compute.Target, &delta, &within
+	}
+	printJSON(result)
+	if !within {
+		exit(exitOutsideTolerance)
+	}
+}
+
+// Output of solve --json.
+type solveResult struct {
+	MinDays     uint                `json:"min_days"`
+	MaxDays     uint                `json:"max_days"`
+	Rate        float64             `json:"rate"`
+	Model       string              `json:"model"`
+	Algorithm   string              `json:"algorithm"`
+	Results     []solveTargetResult `json:"results"` // one per target
+	Processed   int                 `json:"processed"`
+	Malformed   int                 `json:"malformed"`
+	Filtered    int                 `json:"filtered"`
+	Duplicates  int                 `json:"duplicates"`
+	Isomorphic  int                 `json:"isomorphic"`
+	Matches     int                 `json:"matches"`
+	NearMisses  int                 `json:"near_misses"`
+	Undecided   int                 `json:"undecided"`
+	Interrupted bool                `json:"interrupted"`
+
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+type solveTargetResult struct {
+	Target float64         `json:"target"`
+	Best   []solveSolution `json:"best"` // closest first, up to --top
+}
+
+type solveSolution struct {
+	Number        int               `json:"number"`   // line of --graphs, or number of the enumerated graph
+	Original      string            `json:"original"` // line as read from --graphs
+	Graph         pondersolve.Graph `json:"graph"`    // pivoted, the initially infected vertex is vertex 0
+	InitialVertex uint8             `json:"initial_vertex"`
+	Days          uint              `json:"days"`
+	Value         float64           `json:"value"`
+	Values        []float64         `json:"values,omitempty"` // for each --constraint
+	Distance      float64           `json:"distance"`
+}
+
+// Prints solve's summary as JSON.
+func printSolveJSON(summary pondersolve.Summary, minDays, maxDays uint, algorithm pondersolve.Algorithm) {
+	printJSON(newSolveResult(summary, minDays, maxDays, algorithm))
+}
+
+func newSolveResult(summary pondersolve.Summary, minDays, maxDays uint, algorithm pondersolve.Algorithm) solveResult {
+	result := solveResult{
+		MinDays:        minDays,
+		MaxDays:        maxDays,
+		Rate:           args.Solve.Rate,
+		Model:          args.Solve.Model,
+		Algorithm:      string(algorithm),
+		Results:        []solveTargetResult{},
+		Processed:      summary.Processed,
+		Malformed:      summary.Malformed,
+		Filtered:       summary.Filtered,
+		Duplicates:     summary.Duplicates,
+		Isomorphic:     summary.Isomorphic,
+		Matches:        summary.Matches,
+		NearMisses:     summary.NearMisses,
+		Undecided:      summary.Undecided,
+		Interrupted:    summary.Interrupted,
+		ElapsedSeconds: summary.Elapsed.Seconds(),
+	}
+	for _, t := range summary.Results {
+		target := solveTargetResult{Target: t.Target, Best: []solveSolution{}}
+		for _, s := range t.Best {
+			target.Best = append(target.Best, solveSolution{
+				Number:        s.Number,
+				Original:      s.Matrix,
+				Graph:         s.Graph,
+				InitialVertex: s.InitialVertex,
+				Days:          s.Days,
+				Value:         s.Value,
+				Values:        s.Values,
+				Distance:      s.Distance,
+			})
+		}
+		result.Results = append(result.Results, target)
+	}
+	return result
+}
+
+// Returns the summary which newSolveResult was given. Solutions keep their pivoted graph.
+func (r *solveResult) summary() pondersolve.Summary {
+	summary := pondersolve.Summary{
+		Processed:   r.Processed,
+		Malformed:   r.Malformed,
+		Filtered:    r.Filtered,
+		Duplicates:  r.Duplicates,
+		Isomorphic:  r.Isomorphic,
+		Matches:     r.Matches,
+		NearMisses:  r.NearMisses,
+		Undecided:   r.Undecided,
+		Interrupted: r.Interrupted,
+		Elapsed:     time.Duration(r.ElapsedSeconds * float64(time.Second)),
+	}
+	for _, t := range r.Results {
+		result := pondersolve.TargetResult{Target: t.Target}
+		for _, s := range t.Best {
+			result.Best = append(result.Best, pondersolve.Solution{
+				Graph:         s.Graph,
+				Number:        s.Number,
+				Matrix:        s.Original,
+				InitialVertex: s.InitialVertex,
+				Days:          s.Days,
+				Target:        t.Target,
+				Value:         s.Value,
+				Values:        s.Values,
+				Distance:      s.Distance,
+			})
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	return summary
+}
+
+func printJSON(v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Panic(err)
+	}
+	fmt.Println(string(b))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Computes the probability of an observed trajectory: one state per day, starting with day 0.
+func likelihood() {
+	opts := &args.Likelihood
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	states, err := parseTrajectory(opts.Trajectory, g.Size())
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+
+	// the log-likelihood is a sum of logs, which doesn't underflow on long trajectories like the product does
+	likelihood, logLikelihood := 1.0, 0.0
+	for day := 1; day < len(states); day++ {
+		from, to := states[day-1], states[day]
+		p, err := g.TransitionProbability(opts.Rate, from, to)
+		if err != nil {
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		fmt.Printf("day %d: %s -> %s: %g\n", day, formatState(from, g.Size()), formatState(to, g.Size()), p)
+		if p == 0 {
+			fmt.Printf("the trajectory is impossible: %s\n", impossibleStep(g, opts.Rate, from, to))
+			fmt.Println("likelihood: 0")
+			fmt.Println("log-likelihood: -Inf")
+			return
+		}
+		likelihood *= p
+		logLikelihood += math.Log(p)
+	}
+	fmt.Printf("likelihood: %g\n", likelihood)
+	fmt.Printf("log-likelihood: %g\n", logLikelihood)
+}
+
+// Parses comma separated states, one per day, see parseState.
+func parseTrajectory(text string, size uint8) ([]int, error) {
+	var states []int
+	for _, field := range strings.Split(text, ",") {
+		state, err := parseState(strings.TrimSpace(field), size)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// Explains why a step of a trajectory has a probability of 0.
+func impossibleStep(g pondersolve.Graph, rate float64, from, to int) string {
+	for v := uint8(0); v < g.Size(); v++ {
+		if from&(1<<v) != 0 && to&(1<<v) == 0 {
+			return fmt.Sprintf("vertex %d stops being infected, infected vertices stay infected", v)
+		}
+	}
+	for v := uint8(0); v < g.Size(); v++ {
+		if from&(1<<v) != 0 || to&(1<<v) == 0 {
+			continue
+		}
+		exposed := false
+		for _, neighbor := range g.Neighbors(v) {
+			exposed = exposed || from&(1<<neighbor) != 0
+		}
+		if !exposed {
+			return fmt.Sprintf("vertex %d gets infected without any infected neighbor", v)
+		}
+	}
+	if rate == 0 {
+		return "nothing can be infected with a rate of 0"
+	}
+	// with a rate of 1, every exposed vertex gets infected
+	return "vertices with an infected neighbor always get infected with a rate of 1"
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// The limit analysis reports the day on which the probability exceeds 1 - limitEpsilon.
+const limitEpsilon = 1e-6
+
+// Largest number of days iterated to estimate the convergence rate.
+const limitMaxDays = 10000
+
+// Probability that some vertex isn't infected yet below which the convergence rate is no longer estimated, since it's
+// all rounding errors.
+const limitMinTail = 1e-200
+
+// Describes how the probability behaves as the number of days grows, starting from vertex initial: its limit, how
+// fast it converges, and when it gets within limitEpsilon of 1.
+func printLimitAnalysis(ctx context.Context, g pondersolve.Graph, rate float64, initial uint8) error {
+	fmt.Printf("limit analysis, starting from vertex %d:\n", initial)
+	if g.Size() == 1 {
+		fmt.Println("limiting probability: 1, the only vertex is infected from the start")
+		return nil
+	}
+	if rate == 0 {
+		fmt.Println("limiting probability: 0, the infection never spreads at rate 0")
+		return nil
+	}
+	if !g.Connected() {
+		fmt.Println("limiting probability: 0, the graph is disconnected")
+		for _, component := range g.Components() {
+			reached := "never infected"
+			if containsVertex(component, initial) {
+				reached = "infected vertex's component"
+			}
+			fmt.Printf("component %v: %s\n", component, reached)
+		}
+		return nil
+	}
+	fmt.Println("limiting probability: 1, the graph is connected")
+
+	days, err := g.DaysToReach(ctx, rate, initial, 1-limitEpsilon)
+	reached := err == nil
+	if errors.Is(err, pondersolve.ErrNeverReached) {
+		// the rate is so low that the probability is still about 0 after 2^62 days
+		days = limitMaxDays
+	} else if err != nil {
+		return err
+	}
+
+	// The probability that some vertex isn't infected yet shrinks geometrically, by the largest eigenvalue of the
+	// transitions between the other states. It's summed rather than computed as 1 - p, which would cancel out.
+	evolveDays := uint(limitMaxDays)
+	if days < limitMaxDays/2 {
+		evolveDays = uint(2*days) + 1
+	}
+	last := 1<<g.Size() - 1
+	reachable := make([]bool, last+1)
+	var tail, previousTail float64
+	estimatedDay := uint(0)
+	err = g.Evolve(ctx, 1<<initial, rate, evolveDays, func(day uint, distribution []float64) error {
+		t := 0.0
+		for state, p := range distribution[:last] {
+			if p > 0 {
+				reachable[state] = true
+				t += p
+			}
+		}
+		if t > limitMinTail {
+			previousTail, tail, estimatedDay = tail, t, day
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// the eigenvalues of the transitions between transient states are the probabilities of staying in each state
+	slowest := 0.0
+	for state, ok := range reachable {
+		if ok {
+			p, _ := g.TransitionProbability(rate, state, state)
+			if p > slowest {
+				slowest = p
+			}
+		}
+	}
+	if estimatedDay > 1 {
+		fmt.Printf("convergence rate: 1-p shrinks by a factor %g per day (estimated on day %d, slowest transient state: %g)\n",
+			tail/previousTail, estimatedDay, slowest)
+	}
+	if reached {
+		fmt.Printf("the probability exceeds 1-%g after %d days\n", limitEpsilon, days)
+	} else {
+		fmt.Printf("the probability doesn't exceed 1-%g within 2^62 days\n", limitEpsilon)
+	}
+	return nil
+}
+
+func containsVertex(vertices []uint8, v uint8) bool {
+	for _, u := range vertices {
+		if u == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+func checkModel(spec string) error {
+	if _, err := pondersolve.ParseModel(spec); err != nil {
+		return fmt.Errorf("invalid --model: %s", err)
+	}
+	return nil
+}
+
+// Name of the model in the cache keys. The independent model is the SI model the cache was written for, the other
+// models are variations of it.
+func cacheModel(model pondersolve.TransmissionModel) string {
+	if _, ok := model.(pondersolve.Independent); ok {
+		return "si"
+	}
+	return fmt.Sprintf("si-%v", model)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Estimates compute's probability with --algorithm monte-carlo, from --samples runs simulated with --seed.
+func computeMonteCarlo(ctx context.Context, g pondersolve.Graph) {
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(args.Compute.Model)
+	rng := rand.New(rand.NewSource(args.Compute.Seed))
+	estimate, err := g.MonteCarlo(ctx, rng, args.Compute.Days, args.Compute.Rate, args.Compute.InitialVertex, args.Compute.Samples,
+		pondersolve.WithModel(model))
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("computation took longer than %s", args.Compute.MaxDuration)
+		exit(exitInterrupted)
+	case errors.Is(err, context.Canceled):
+		log.Print("computation interrupted")
+		exit(exitInterrupted)
+	case err != nil:
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	fmt.Printf("estimated probability of all vertices infected after %d days, starting from vertex %d: %g%%\n",
+		args.Compute.Days, args.Compute.InitialVertex, estimate.Probability*100.0)
+	fmt.Printf("95%% confidence interval over %d samples: [%g%%, %g%%]\n", estimate.Samples, estimate.Low*100.0, estimate.High*100.0)
+	if args.Compute.Target >= 0 {
+		checkComputeTarget(estimate.Probability)
+	}
+}
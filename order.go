@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Number of lines whose probabilities are computed to calibrate the heuristic order.
+const orderCalibrationLines = 64
+
+// What solve is looking for, used to score the lines of a database for the heuristic order.
+type orderGoals struct {
+	targets     []float64
+	minDays     uint
+	maxDays     uint
+	rate        float64
+	model       pondersolve.TransmissionModel
+	constraints []pondersolve.Constraint
+}
+
+// Returns the lines of a database in the order given by --order: "random" shuffles them, "heuristic" puts first the
+// lines which are predicted to be closest to the targets. The predictions are EstimateDays' mean-field approximation
+// under the goals' model, fitted to the exact probabilities of a few lines first when calibrate is set. lines must be in increasing order.
+func orderLines(database *fileSource, lines []orderedLine, order string, seed int64, calibrate bool, goals orderGoals) []orderedLine {
+	switch order {
+	case "random":
+		rand.New(rand.NewSource(seed)).Shuffle(len(lines), func(i, j int) {
+			lines[i], lines[j] = lines[j], lines[i]
+		})
+	case "heuristic":
+		a, b := 0.0, 1.0
+		if calibrate {
+			a, b = goals.calibrate(database, lines)
+		}
+		// a second pass over the file scores every line, without keeping the matrices in memory
+		database.seek(0, 0)
+		for next := 0; next < len(lines); {
+			lineNumber, line, ok := database.lines.Next()
+			if !ok {
+				break
+			}
+			if lineNumber == lines[next].number {
+				lines[next].score = goals.score(line, a, b)
+				next++
+			}
+		}
+		if err := database.lines.Err(); err != nil {
+			log.Panic(err)
+		}
+		sort.SliceStable(lines, func(i, j int) bool {
+			return lines[i].score < lines[j].score
+		})
+	}
+	return lines
+}
+
+// Returns a and b such that a + b * estimate predicts the probability, fitted by least squares on lines spread across
+// the database. The estimates are biased, but they rank the graphs well: the fit corrects the bias. It computes the
+// exact probabilities of orderCalibrationLines lines, which is why it's only done with --order-calibrate.
+func (goals orderGoals) calibrate(database *fileSource, lines []orderedLine) (a, b float64) {
+	var estimates, values []float64
+	step := len(lines)/orderCalibrationLines + 1
+	for i := 0; i < len(lines); i += step {
+		g, err := pondersolve.ParseGraph(database.readLine(lines[i]))
+		if err != nil || !matchesFilters(g) {
+			continue
+		}
+		r, err := g.ComputeDays(context.Background(), goals.minDays, goals.maxDays, goals.rate, pondersolve.WithModel(goals.model))
+		if err != nil {
+			log.Panic(err)
+		}
+		for d, e := range g.EstimateDays(goals.minDays, goals.maxDays, goals.rate, pondersolve.WithModel(goals.model)) {
+			estimates = append(estimates, e...)
+			values = append(values, r[d]...)
+		}
+	}
+
+	n := float64(len(estimates))
+	var meanE, meanV float64
+	for i := range estimates {
+		meanE += estimates[i] / n
+		meanV += values[i] / n
+	}
+	var covariance, variance float64
+	for i := range estimates {
+		covariance += (estimates[i] - meanE) * (values[i] - meanV)
+		variance += (estimates[i] - meanE) * (estimates[i] - meanE)
+	}
+	if len(estimates) < 2 || variance == 0 {
+		// nothing to fit, the estimates are used as they are
+		return 0, 1
+	}
+	b = covariance / variance
+	return meanV - b*meanE, b
+}
+
+// Returns the predicted distance between a line and the closest target, +Inf for lines which will be skipped.
+func (goals orderGoals) score(line string, a, b float64) float64 {
+	g, err := pondersolve.ParseGraph(line)
+	if err != nil || !matchesFilters(g) {
+		return math.Inf(1)
+	}
+	estimates := g.EstimateDays(goals.minDays, goals.maxDays, goals.rate, pondersolve.WithModel(goals.model))
+	best := math.Inf(1)
+	for i := range estimates[0] {
+		if goals.constraints != nil {
+			distance := 0.0
+			for _, c := range goals.constraints {
+				distance = math.Max(distance, math.Abs(a+b*estimates[c.Days-goals.minDays][i]-c.Target))
+			}
+			best = math.Min(best, distance)
+			continue
+		}
+		for _, values := range estimates {
+			for _, target := range goals.targets {
+				best = math.Min(best, math.Abs(a+b*values[i]-target))
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Writes a database of random graphs with 4 to 8 vertices, along with a malformed line and a line ending with "\r\n".
+func writeTestDatabase(t *testing.T, dir string, count int) string {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	var lines []string
+	for i := 0; i < count; i++ {
+		lines = append(lines, pondersolve.RandomGraph(rng, uint8(4+rng.Intn(5)), 0.2+0.6*rng.Float64()).Matrix())
+	}
+	lines[count/3] = "0110,1011,1102,0110"
+	lines[count/2] += "\r"
+	path := filepath.Join(dir, "graphs.txt")
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOrderDoesNotChangeResults(t *testing.T) {
+	dir, err := ioutil.TempDir("", "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTestDatabase(t, dir, 300)
+	args.Solve.MinVertices, args.Solve.MaxVertices = 0, pondersolve.MaxSize
+	args.Solve.MinEdges, args.Solve.MaxEdges = 0, 28
+	args.Solve.Shard, args.Solve.NumShards = 0, 1
+
+	goals := orderGoals{targets: []float64{0.3, 0.7}, minDays: 3, maxDays: 6, rate: 0.2}
+	opts := pondersolve.SolveOptions{
+		Targets:   goals.targets,
+		Tolerance: 0.05,
+		Top:       5,
+		MinDays:   goals.minDays,
+		MaxDays:   goals.maxDays,
+		Rate:      goals.rate,
+		Filter:    matchesFilters,
+	}
+	tests := []struct {
+		order     string
+		seed      int64
+		calibrate bool
+	}{
+		{"file", 0, false},
+		{"heuristic", 0, false},
+		{"heuristic", 0, true},
+		{"random", 1, false},
+		{"random", 2, false},
+	}
+	var want pondersolve.Summary
+	for i, tt := range tests {
+		var lines []orderedLine
+		database := openFileSource(path, func(lineNumber int, offset int64, line string) {
+			lines = append(lines, orderedLine{number: lineNumber, offset: offset})
+		})
+		if tt.order != "file" {
+			database.ordered = orderLines(database, lines, tt.order, tt.seed, tt.calibrate, goals)
+		}
+		got, err := pondersolve.Solve(context.Background(), database, opts)
+		database.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Processed != 300 || got.Malformed != 1 {
+			t.Errorf("--order %s: %d graphs processed, %d malformed, want 300 and 1", tt.order, got.Processed, got.Malformed)
+		}
+		if got.Matches == 0 {
+			t.Fatalf("--order %s: no matches, the test database is too small", tt.order)
+		}
+		got.Elapsed = 0
+		if i == 0 {
+			want = got
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("--order %s --seed %d --order-calibrate=%t: got %+v, --order file gives %+v", tt.order, tt.seed, tt.calibrate, got, want)
+		}
+	}
+}
+
+func TestOrderHeuristicFirst(t *testing.T) {
+	// the heuristic order puts the lines predicted closest to the target first, whatever their position in the file
+	dir, err := ioutil.TempDir("", "order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := writeTestDatabase(t, dir, 300)
+	args.Solve.MinVertices, args.Solve.MaxVertices = 0, pondersolve.MaxSize
+	args.Solve.MinEdges, args.Solve.MaxEdges = 0, 28
+
+	var lines []orderedLine
+	database := openFileSource(path, func(lineNumber int, offset int64, line string) {
+		lines = append(lines, orderedLine{number: lineNumber, offset: offset})
+	})
+	defer database.Close()
+	goals := orderGoals{targets: []float64{0.7}, minDays: 5, maxDays: 5, rate: 0.2}
+	ordered := orderLines(database, lines, "heuristic", 0, false, goals)
+	if len(ordered) != 300 {
+		t.Fatalf("got %d lines, want 300", len(ordered))
+	}
+	seen := make(map[int]bool)
+	for i, l := range ordered {
+		seen[l.number] = true
+		if i > 0 && l.score < ordered[i-1].score {
+			t.Fatalf("line %d with score %g comes after a line with score %g", l.number, l.score, ordered[i-1].score)
+		}
+	}
+	if len(seen) != 300 {
+		t.Errorf("got %d distinct lines, want 300", len(seen))
+	}
+	if sort.SliceIsSorted(ordered, func(i, j int) bool { return ordered[i].number < ordered[j].number }) {
+		t.Errorf("the heuristic order kept the file order")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parses a --permute flag: comma separated labels, vertex i becomes vertex perm[i]. Whether perm has the right length
+// is checked by Graph.Permute.
+func parsePermutation(text string) ([]uint8, error) {
+	var perm []uint8
+	seen := make(map[uint64]bool)
+	for _, field := range strings.Split(text, ",") {
+		v, err := strconv.ParseUint(strings.TrimSpace(field), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid permutation %q, expecting comma separated vertices", text)
+		}
+		if seen[v] {
+			return nil, fmt.Errorf("invalid permutation %q, vertex %d appears twice", text, v)
+		}
+		seen[v] = true
+		perm = append(perm, uint8(v))
+	}
+	return perm, nil
+}
+
+// Parses a --restrict flag: comma separated vertices of a graph with size vertices, each appearing once.
+func parseVertexSet(text string, size uint8) ([]uint8, error) {
+	var r []uint8
+	seen := make(map[uint64]bool)
+	for _, field := range strings.Split(text, ",") {
+		v, err := strconv.ParseUint(strings.TrimSpace(field), 10, 8)
+		if err != nil || v >= uint64(size) {
+			return nil, fmt.Errorf("invalid vertices %q, expecting comma separated vertices between 0 and %d", text, size-1)
+		}
+		if seen[v] {
+			return nil, fmt.Errorf("invalid vertices %q, vertex %d appears twice", text, v)
+		}
+		seen[v] = true
+		r = append(r, uint8(v))
+	}
+	return r, nil
+}
+
+// Applies a permutation to a state, see parseState.
+func permuteState(state int, perm []uint8) int {
+	r := 0
+	for i, v := range perm {
+		if state&(1<<uint(i)) != 0 {
+			r |= 1 << v
+		}
+	}
+	return r
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Prints the probability as a polynomial in the rate, and checks its value at --rate against the floating point
+// probability.
+func printPolynomial(p pondersolve.Polynomial, value float64) {
+	fmt.Printf("polynomial of degree %d in r: %s\n", p.Degree(), p)
+	fmt.Println("coefficients, lowest degree first:")
+	for i, c := range p {
+		fmt.Printf("r^%d: %s\n", i, c.RatString())
+	}
+	// the rate is parsed again from its shortest representation, e.g. 0.1 is exactly 1/10 rather than the closest
+	// float64
+	rate, _ := new(big.Rat).SetString(strconv.FormatFloat(args.Compute.Rate, 'g', -1, 64))
+	exact, _ := p.Eval(rate).Float64()
+	fmt.Printf("value at r=%s: %g%%, difference with floating point: %g\n", rate.RatString(), exact*100.0, exact-value)
+}
@@ -0,0 +1,76 @@
+package pondersolve_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// The solution published for the puzzle: vertex 0 infects every vertex within 30 days with a probability of 70%, at a
+// rate of 0.1.
+const puzzleGraph = "00001100,00001011,00000110,00000010,11000101,10101001,01110001,01001110"
+
+func TestPuzzleGraph(t *testing.T) {
+	g, err := pondersolve.ParseMatrix(puzzleGraph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, algorithm := range pondersolve.Algorithms {
+		if algorithm == pondersolve.Recursive {
+			// would explore every evolution over 30 days
+			continue
+		}
+		r, err := g.Compute(context.Background(), 30, 0.1, pondersolve.WithAlgorithm(algorithm))
+		if err != nil {
+			t.Fatalf("%s: %s", algorithm, err)
+		}
+		if math.Abs(r[0]-0.70) >= 0.00005 {
+			t.Errorf("%s: probability %g, want 0.70 within the puzzle's tolerance", algorithm, r[0])
+		}
+		if math.Abs(r[0]-0.6999898686018191) > 1e-12 {
+			t.Errorf("%s: probability %.16g, want 0.6999898686018191", algorithm, r[0])
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	triangle, err := pondersolve.ParseMatrix("011,101,110")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"rows of different lengths", parseError("011,10,110"), pondersolve.ErrNotSquare},
+		{"unknown character", parseError("01,x0"), pondersolve.ErrBadCharacter},
+		{"9 vertices", parseError("011111111,101111111,110111111,111011111,111101111,111110111,111111011,111111101,111111110"), pondersolve.ErrTooLarge},
+		{"rate above 1", computeError(triangle.Compute(context.Background(), 3, 1.5)), pondersolve.ErrInvalidRate},
+		{"negative rate", computeError(triangle.Compute(context.Background(), 3, -0.1)), pondersolve.ErrInvalidRate},
+		{"NaN rate", computeError(triangle.Compute(context.Background(), 3, math.NaN())), pondersolve.ErrInvalidRate},
+		{"unknown algorithm", computeError(triangle.Compute(context.Background(), 3, 0.1, pondersolve.WithAlgorithm("bogus"))), pondersolve.ErrUnknownAlgorithm},
+		{"empty range of days", daysError(triangle.ComputeDays(context.Background(), 5, 3, 0.1)), pondersolve.ErrInvalidDays},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: got error %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+}
+
+func parseError(matrix string) error {
+	_, err := pondersolve.ParseMatrix(matrix)
+	return err
+}
+
+func computeError(_ []float64, err error) error {
+	return err
+}
+
+func daysError(_ [][]float64, err error) error {
+	return err
+}
@@ -0,0 +1,169 @@
+package pondersolve
+
+import "math/bits"
+
+// Returns a copy of g where vertex i becomes vertex perm[i].
+func (g *Graph) permute(perm []uint8) Graph {
+	r := Graph{size: g.size}
+	for i := uint8(0); i < g.size; i++ {
+		for j := uint8(0); j < g.size; j++ {
+			if g.HasEdge(i, j) {
+				r.addEdge(perm[i], perm[j])
+			}
+		}
+	}
+	return r
+}
+
+// Canonical returns the canonical form of g: the lexicographically smallest adjacency matrix, compared row by row,
+// over all n! relabelings of g. Two graphs have the same canonical form if and only if they are isomorphic.
+func (g *Graph) Canonical() Graph {
+	c, _ := g.CanonicalPermutation()
+	return c
+}
+
+// CanonicalPermutation returns the canonical form of g along with the relabeling which produces it: vertex i of g is
+// vertex perm[i] of the canonical form.
+//
+// New labels are assigned in increasing order, and a branch is cut as soon as its rows can't beat the best matrix
+// found so far. Row i of the new matrix is known for the labels assigned so far, and its other edges go to the
+// vertices still unlabeled: the smallest it can become puts them on the last labels. Two unlabeled vertices which
+// can be swapped without changing g lead to the same matrices, so only the first one is tried, which takes care of
+// empty and complete graphs.
+func (g *Graph) CanonicalPermutation() (Graph, []uint8) {
+	n := g.size
+	if n == 0 {
+		return *g, []uint8{}
+	}
+	// rows of g as masks over the vertices of g, and the same for the columns
+	var out, in [MaxSize]uint8
+	for i := uint8(0); i < n; i++ {
+		out[i] = uint8(uint64(g.vertices) >> (i * 8))
+		for j := uint8(0); j < n; j++ {
+			if g.HasEdge(j, i) {
+				in[i] |= 1 << j
+			}
+		}
+	}
+	// swappable[u]&(1<<v) is set when exchanging u and v is an automorphism of g
+	var swappable [MaxSize]uint8
+	for u := uint8(0); u < n; u++ {
+		for v := u + 1; v < n; v++ {
+			m := uint8(1)<<u | uint8(1)<<v
+			if out[u]&^m == out[v]&^m && in[u]&^m == in[v]&^m && out[u]>>v&1 == out[v]>>u&1 && out[u]>>u&1 == out[v]>>v&1 {
+				swappable[v] |= 1 << u
+			}
+		}
+	}
+
+	// Rows of the new matrix, new label j being bit n-1-j so that comparing rows as numbers compares them
+	// lexicographically. best is only meaningful once found.
+	var best, rows [MaxSize]uint8
+	var bestPerm [MaxSize]uint8
+	var labeled [MaxSize]uint8 // labeled[p] is the vertex of g with new label p
+	found := false
+	var assign func(p uint8, unlabeled uint8)
+	assign = func(p uint8, unlabeled uint8) {
+		if p == n {
+			if !found || lessRows(rows[:n], best[:n]) {
+				best = rows
+				for l := uint8(0); l < n; l++ {
+					bestPerm[labeled[l]] = l
+				}
+				found = true
+			}
+			return
+		}
+		for v := uint8(0); v < n; v++ {
+			if unlabeled&(1<<v) == 0 || swappable[v]&unlabeled != 0 {
+				continue
+			}
+			labeled[p] = v
+			remaining := unlabeled &^ (1 << v)
+			// fill in column p of the labeled rows and row p, bounding the rows from below
+			bit := uint8(1) << (n - 1 - p)
+			cut := false
+			decided := !found
+			for i := uint8(0); i <= p; i++ {
+				if i == p {
+					rows[p] = 0
+					for j := uint8(0); j <= p; j++ {
+						if out[v]&(1<<labeled[j]) != 0 {
+							rows[p] |= 1 << (n - 1 - j)
+						}
+					}
+				} else if out[labeled[i]]&(1<<v) != 0 {
+					rows[i] |= bit
+				} else {
+					rows[i] &^= bit
+				}
+				if decided {
+					continue
+				}
+				// the bits below bit are left over from other branches
+				bound := rows[i]&^(bit-1) | (uint8(1)<<uint(bits.OnesCount8(out[labeled[i]]&remaining)) - 1)
+				if bound > best[i] {
+					cut = true
+					break
+				}
+				decided = bound < best[i]
+			}
+			if !cut {
+				assign(p+1, remaining)
+			}
+		}
+	}
+	assign(0, uint8(1<<n-1))
+
+	perm := make([]uint8, n)
+	copy(perm, bestPerm[:n])
+	return g.permute(perm), perm
+}
+
+// Compares the rows of two matrices, see CanonicalPermutation.
+func lessRows(a, b []uint8) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+// CanonicalKey returns the edges of the canonical form of g, in the same layout as Graph: edge (i, j) is bit i*8+j.
+// Isomorphic graphs have the same key. The number of vertices isn't part of the key, graphs of different sizes can
+// share a key when the extra vertices are isolated.
+func (g *Graph) CanonicalKey() uint64 {
+	c := g.Canonical()
+	return uint64(c.vertices)
+}
+
+// IsCanonical checks whether g is its own canonical form, i.e. whether g is the representative of its isomorphism class.
+func (g *Graph) IsCanonical() bool {
+	// The first row of the canonical form is as small as a row can be, which is cheap to check first: no self loop
+	// unless every vertex has one, then as few edges as possible, going to the last vertices.
+	smallest := func(v uint8) int {
+		if g.HasEdge(v, v) {
+			return int(g.size) + g.Degree(v)
+		}
+		return g.Degree(v)
+	}
+	first := smallest(0)
+	for v := uint8(1); v < g.size; v++ {
+		if smallest(v) < first {
+			return false
+		}
+	}
+	for j := uint8(1); j < g.size-uint8(g.Degree(0)); j++ {
+		if g.HasEdge(0, j) {
+			return false
+		}
+	}
+	return g.Canonical() == *g
+}
+
+// Compares the adjacency matrices of two graphs of the same size, row by row.
+func lexLess(a, b Graph) bool {
+	// vertex (0, 0) is the lowest bit, reversing the bits makes it the most significant one.
+	return bits.Reverse64(uint64(a.vertices)) < bits.Reverse64(uint64(b.vertices))
+}
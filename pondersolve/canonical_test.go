@@ -0,0 +1,133 @@
+package pondersolve
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Calls f with every permutation of 0..n-1. perm is reused between calls.
+func forEachPermutation(n uint8, f func(perm []uint8)) {
+	perm := make([]uint8, n)
+	used := make([]bool, n)
+	var assign func(p uint8)
+	assign = func(p uint8) {
+		if p == n {
+			f(perm)
+			return
+		}
+		for v := uint8(0); v < n; v++ {
+			if !used[v] {
+				used[v] = true
+				perm[p] = v
+				assign(p + 1)
+				used[v] = false
+			}
+		}
+	}
+	assign(0)
+}
+
+// Returns the lexicographically smallest adjacency matrix over all n! relabelings of g.
+func bruteForceCanonical(g *Graph) Graph {
+	var best Graph
+	found := false
+	forEachPermutation(g.size, func(perm []uint8) {
+		if candidate := g.permute(perm); !found || lexLess(candidate, best) {
+			best, found = candidate, true
+		}
+	})
+	return best
+}
+
+// Returns a random graph with n vertices whose edges each exist with probability 1/2 in each direction.
+func randomDirectedGraph(rng *rand.Rand, n uint8) Graph {
+	g := Graph{size: n}
+	for i := uint8(0); i < n; i++ {
+		for j := uint8(0); j < n; j++ {
+			if i != j && rng.Intn(2) == 0 {
+				g.addEdge(i, j)
+			}
+		}
+	}
+	return g
+}
+
+func TestCanonicalIsSmallestRelabeling(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name   string
+		graphs func() []Graph
+	}{
+		{"every graph with up to 5 vertices", func() []Graph {
+			var graphs []Graph
+			for n := uint8(1); n <= 5; n++ {
+				for pattern := uint64(0); pattern < 1<<(uint(n)*uint(n-1)/2); pattern++ {
+					graphs = append(graphs, FromUpperTriangle(n, pattern))
+				}
+			}
+			return graphs
+		}},
+		{"random graphs with 6 vertices", func() []Graph {
+			var graphs []Graph
+			for i := 0; i < 300; i++ {
+				graphs = append(graphs, RandomGraph(rng, 6, rng.Float64()))
+			}
+			return graphs
+		}},
+		{"random directed graphs", func() []Graph {
+			var graphs []Graph
+			for i := 0; i < 300; i++ {
+				graphs = append(graphs, randomDirectedGraph(rng, uint8(2+rng.Intn(4))))
+			}
+			return graphs
+		}},
+		{"random graphs with self loops", func() []Graph {
+			var graphs []Graph
+			for i := 0; i < 300; i++ {
+				g := randomDirectedGraph(rng, uint8(1+rng.Intn(5)))
+				for v := uint8(0); v < g.size; v++ {
+					if rng.Intn(2) == 0 {
+						g.addEdge(v, v)
+					}
+				}
+				graphs = append(graphs, g)
+			}
+			return graphs
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, g := range tt.graphs() {
+				c := g.Canonical()
+				if b := bruteForceCanonical(&g); c != b {
+					t.Fatalf("Canonical(%s) = %s, want %s", g.Matrix(), c.Matrix(), b.Matrix())
+				}
+				if !c.IsCanonical() {
+					t.Fatalf("IsCanonical(%s) = false for a canonical form", c.Matrix())
+				}
+				if g != c && g.IsCanonical() {
+					t.Fatalf("IsCanonical(%s) = true, its canonical form is %s", g.Matrix(), c.Matrix())
+				}
+			}
+		})
+	}
+}
+
+func TestCanonicalOfPermutation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		n := uint8(1 + rng.Intn(MaxSize))
+		g := RandomGraph(rng, n, rng.Float64())
+		perm := make([]uint8, n)
+		for j, v := range rng.Perm(int(n)) {
+			perm[j] = uint8(v)
+		}
+		h := g.permute(perm)
+		if g.Canonical() != h.Canonical() {
+			t.Errorf("Canonical(%s) != Canonical(%s), which is a relabeling of it", g.Matrix(), h.Matrix())
+		}
+		if g.CanonicalKey() != h.CanonicalKey() {
+			t.Errorf("CanonicalKey(%s) != CanonicalKey(%s), which is a relabeling of it", g.Matrix(), h.Matrix())
+		}
+	}
+}
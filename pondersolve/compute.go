@@ -0,0 +1,602 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"sync"
+
+	"github.com/teivah/bitvector"
+)
+
+// Algorithm selects how probabilities are computed.
+type Algorithm string
+
+// Supported algorithms.
+const (
+	// Recursive explores every possible evolution of the infection. It's only usable for a small number of days.
+	Recursive Algorithm = "recursive"
+	// Memoized is Recursive, remembering the probability of each (days, state) pair it already computed.
+	Memoized Algorithm = "memoized"
+	// DP uses dynamic programming over the 2^n possible states.
+	DP Algorithm = "dp"
+	// Lumped is DP over the orbits of the states under the automorphisms of the graph: states which are relabelings of
+	// each other have the same probability, so they are only computed once. Symmetric graphs have far fewer orbits than
+	// states, e.g. 9 instead of 256 for the complete graph on 8 vertices.
+	Lumped Algorithm = "lumped"
+	// MatrixPower raises the 2^n x 2^n transition matrix to the number of days by repeated squaring, which takes
+	// O(log(days)) matrix products instead of DP's one step per day. It only pays off for thousands of days.
+	MatrixPower Algorithm = "matrix-power"
+)
+
+// Algorithms lists every supported algorithm.
+var Algorithms = []Algorithm{Recursive, Memoized, DP, Lumped, MatrixPower}
+
+// Errors returned by Compute, ComputeDays and ComputeRates.
+var (
+	ErrUnknownAlgorithm = errors.New("unknown algorithm")
+	ErrInvalidRate      = errors.New("rate must be between 0 and 1")
+	ErrInvalidDays      = errors.New("invalid range of days")
+)
+
+type options struct {
+	algorithm       Algorithm
+	firstResultOnly bool
+	threads         int
+	model           TransmissionModel
+}
+
+// Option configures Compute, ComputeDays and ComputeRates.
+type Option func(*options)
+
+// WithAlgorithm selects the algorithm. The default is DP, an empty name also selects the default.
+func WithAlgorithm(algorithm Algorithm) Option {
+	return func(o *options) {
+		if algorithm != "" {
+			o.algorithm = algorithm
+		}
+	}
+}
+
+// FirstResultOnly only computes the probability when vertex 0 is initially infected.
+func FirstResultOnly() Option {
+	return func(o *options) {
+		o.firstResultOnly = true
+	}
+}
+
+// WithThreads sets the number of rates ComputeRates computes concurrently, or the number of goroutines used by the
+// Recursive algorithm. The default is 1.
+func WithThreads(threads int) Option {
+	return func(o *options) {
+		o.threads = threads
+	}
+}
+
+// WithModel selects the transmission model. The default is Independent, nil also selects the default.
+func WithModel(model TransmissionModel) Option {
+	return func(o *options) {
+		if model != nil {
+			o.model = model
+		}
+	}
+}
+
+func newOptions(rate float64, opts []Option) (options, error) {
+	o := options{algorithm: DP, model: Independent{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	known := false
+	for _, algorithm := range Algorithms {
+		known = known || algorithm == o.algorithm
+	}
+	if !known {
+		return o, fmt.Errorf("%w: %s", ErrUnknownAlgorithm, o.algorithm)
+	}
+	if !(rate >= 0 && rate <= 1) {
+		return o, fmt.Errorf("%w: %g", ErrInvalidRate, rate)
+	}
+	return o, nil
+}
+
+// Bit j of masks[i] is set when there's an edge from vertex j to vertex i, i.e. when j can infect i.
+type neighborMasks [MaxSize]bitvector.Len8
+
+func (g *Graph) neighborMasks() *neighborMasks {
+	var masks neighborMasks
+	for i := uint8(0); i < g.size; i++ {
+		for j := uint8(0); j < g.size; j++ {
+			if g.HasEdge(j, i) {
+				masks[i] = masks[i].Set(j, true)
+			}
+		}
+	}
+	return &masks
+}
+
+type stateProbability struct {
+	state       bitvector.Len8
+	probability float64
+}
+
+// Compute returns the probability for all vertices to be infected within the given number of days. r[i] is the
+// probability when vertex i is initially infected. The computation stops early, returning ctx.Err(), when ctx is
+// cancelled.
+func (g *Graph) Compute(ctx context.Context, days uint, rate float64, opts ...Option) ([]float64, error) {
+	r, err := g.ComputeDays(ctx, days, days, rate, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return r[0], nil
+}
+
+// ComputeDays is like Compute, for every number of days in [minDays, maxDays]. r[d][i] is the probability after
+// minDays+d days when vertex i is initially infected.
+func (g *Graph) ComputeDays(ctx context.Context, minDays, maxDays uint, rate float64, opts ...Option) ([][]float64, error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return nil, err
+	}
+	if minDays > maxDays {
+		return nil, fmt.Errorf("%w: %d > %d", ErrInvalidDays, minDays, maxDays)
+	}
+	return g.computeDays(ctx, g.neighborMasks(), o, minDays, maxDays, rate)
+}
+
+// ComputeRates is like Compute, for each rate. r[k][i] is the probability at rates[k] when vertex i is initially
+// infected. The rates are computed concurrently, see WithThreads.
+func (g *Graph) ComputeRates(ctx context.Context, days uint, rates []float64, opts ...Option) ([][]float64, error) {
+	var o options
+	for _, rate := range rates {
+		var err error
+		if o, err = newOptions(rate, opts); err != nil {
+			return nil, err
+		}
+	}
+	if o.threads < 1 {
+		o.threads = 1
+	}
+
+	// the first error stops the other computations
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var once sync.Once
+	var firstErr error
+
+	masks := g.neighborMasks()
+	r := make([][]float64, len(rates))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for t := 0; t < o.threads; t++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// the threads are already busy with the other rates
+			inner := o
+			inner.threads = 1
+			for k := range indexes {
+				values, err := g.computeDays(ctx, masks, inner, days, days, rates[k])
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				r[k] = values[0]
+			}
+		}()
+	}
+	for k := range rates {
+		indexes <- k
+	}
+	close(indexes)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return r, nil
+}
+
+// ComputeFrom is like Compute, starting with every vertex of initial infected instead of a single vertex. It returns
+// the probability for all vertices to be infected within the given number of days. The returned error wraps
+// ErrVertexOutOfRange when initial is empty or has a vertex outside the graph.
+func (g *Graph) ComputeFrom(ctx context.Context, days uint, rate float64, initial []uint8, opts ...Option) (float64, error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return 0, err
+	}
+	if len(initial) == 0 {
+		return 0, fmt.Errorf("%w: no initially infected vertex", ErrVertexOutOfRange)
+	}
+	var state bitvector.Len8
+	for _, v := range initial {
+		if v >= g.size {
+			return 0, fmt.Errorf("%w: initial vertex %d, graph has %d vertices", ErrVertexOutOfRange, v, g.size)
+		}
+		state = state.Set(v, true)
+	}
+	r, err := g.computeStatesDays(ctx, g.neighborMasks(), o, days, days, rate, []bitvector.Len8{state})
+	if err != nil {
+		return 0, err
+	}
+	return r[0][0], nil
+}
+
+// ComputeStates returns the probability for all vertices to be infected within the given number of days, from every
+// initial state. r[state] is the probability when exactly the vertices whose bits are set in state are initially
+// infected, r has 2^n entries. The probabilities are read from a single dp table.
+func (g *Graph) ComputeStates(ctx context.Context, days uint, rate float64) ([]float64, error) {
+	if _, err := newOptions(rate, nil); err != nil {
+		return nil, err
+	}
+	probs, err := g.dpTable(ctx, g.neighborMasks(), days, days, rate, Independent{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append([]float64(nil), probs[0][:1<<g.size]...), nil
+}
+
+func (g *Graph) computeDays(ctx context.Context, masks *neighborMasks, o options, minDays, maxDays uint, rate float64) ([][]float64, error) {
+	return g.computeStatesDays(ctx, masks, o, minDays, maxDays, rate, g.initialStates(o.firstResultOnly))
+}
+
+// Same as computeDays, starting from each of the initial states: r[d][k] is the probability after minDays+d days
+// starting from initial[k].
+func (g *Graph) computeStatesDays(ctx context.Context, masks *neighborMasks, o options, minDays, maxDays uint, rate float64, initial []bitvector.Len8) ([][]float64, error) {
+	var r [][]float64
+	if o.algorithm != DP && o.algorithm != Lumped && o.algorithm != MatrixPower {
+		compute := g.computeRecursive
+		if o.algorithm == Memoized {
+			compute = g.computeMemoized
+		} else if o.threads > 1 {
+			compute = func(ctx context.Context, masks *neighborMasks, days uint, rate float64, model TransmissionModel, initial []bitvector.Len8) ([]float64, error) {
+				return g.computeRecursiveParallel(ctx, masks, days, rate, model, initial, o.threads)
+			}
+		}
+		for days := minDays; days <= maxDays; days++ {
+			values, err := compute(ctx, masks, days, rate, o.model, initial)
+			if err != nil {
+				return nil, err
+			}
+			r = append(r, values)
+		}
+		return r, nil
+	}
+	// the dp table already contains every intermediate day
+	table := g.dpTable
+	switch o.algorithm {
+	case Lumped:
+		table = g.lumpedTable
+	case MatrixPower:
+		table = g.matrixPowerTable
+	}
+	probs, err := table(ctx, masks, minDays, maxDays, rate, o.model, initial)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range probs {
+		values := make([]float64, len(initial))
+		for k, state := range initial {
+			values[k] = row[state]
+		}
+		r = append(r, values)
+	}
+	return r, nil
+}
+
+// Use a recursive function (note: this is going to be slow)
+func (g *Graph) computeRecursive(ctx context.Context, masks *neighborMasks, days uint, rate float64, model TransmissionModel, initial []bitvector.Len8) ([]float64, error) {
+	var r []float64
+	for _, state := range initial {
+		p, err := g._computeRecursive(ctx, masks, days, rate, model, state)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, p)
+	}
+	return r, nil
+}
+
+func (g *Graph) _computeRecursive(ctx context.Context, masks *neighborMasks, days uint, rate float64, model TransmissionModel, state bitvector.Len8) (float64, error) {
+	if state.Count() == g.size {
+		// all vertices were infected, stop further processing
+		return 1.0, nil
+	}
+	if days == 0 {
+		// some vertices were not infected, but we reached the end of our iterations
+		return 0.0, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	// enumerate combinations of edges which can change state
+	r := 0.0
+	nextStates := g.enumerateNextStates(masks, state, rate, model, 0)
+	for _, nextState := range nextStates {
+		p, err := g._computeRecursive(ctx, masks, days-1, rate, model, nextState.state)
+		if err != nil {
+			return 0, err
+		}
+		r += p * nextState.probability
+	}
+	return r, nil
+}
+
+// Depth of the call tree of computeRecursiveParallel above which branches are computed concurrently.
+const recursiveFanOutDepth = 2
+
+// Same as computeRecursive, using the given number of goroutines. The top recursiveFanOutDepth levels of the call tree
+// are expanded up front, the subtrees below are computed concurrently, and the partial results are then added in the
+// same order as computeRecursive, which makes the result identical.
+func (g *Graph) computeRecursiveParallel(ctx context.Context, masks *neighborMasks, days uint, rate float64, model TransmissionModel, initial []bitvector.Len8, threads int) ([]float64, error) {
+	type subtree struct {
+		days   uint
+		state  bitvector.Len8
+		result float64
+	}
+	var subtrees []*subtree
+	// returns a function which adds up the results of the subtrees, once they are computed
+	var expand func(days uint, state bitvector.Len8, depth int) func() float64
+	expand = func(days uint, state bitvector.Len8, depth int) func() float64 {
+		if state.Count() == g.size {
+			return func() float64 { return 1.0 }
+		}
+		if days == 0 {
+			return func() float64 { return 0.0 }
+		}
+		if depth == recursiveFanOutDepth {
+			t := &subtree{days: days, state: state}
+			subtrees = append(subtrees, t)
+			return func() float64 { return t.result }
+		}
+		nextStates := g.enumerateNextStates(masks, state, rate, model, 0)
+		sums := make([]func() float64, len(nextStates))
+		for i, nextState := range nextStates {
+			sums[i] = expand(days-1, nextState.state, depth+1)
+		}
+		return func() float64 {
+			r := 0.0
+			for i, nextState := range nextStates {
+				r += sums[i]() * nextState.probability
+			}
+			return r
+		}
+	}
+	var roots []func() float64
+	for _, state := range initial {
+		roots = append(roots, expand(days, state, 0))
+	}
+
+	// the first error stops the other goroutines
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var once sync.Once
+	var firstErr error
+	work := make(chan *subtree)
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range work {
+				p, err := g._computeRecursive(ctx, masks, t.days, rate, model, t.state)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				t.result = p
+			}
+		}()
+	}
+	for _, t := range subtrees {
+		work <- t
+	}
+	close(work)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	var r []float64
+	for _, sum := range roots {
+		r = append(r, sum())
+	}
+	return r, nil
+}
+
+// Same as computeRecursive, but each (days, state) pair is only computed once.
+func (g *Graph) computeMemoized(ctx context.Context, masks *neighborMasks, days uint, rate float64, model TransmissionModel, initial []bitvector.Len8) ([]float64, error) {
+	type key struct {
+		days  uint
+		state bitvector.Len8
+	}
+	memo := make(map[key]float64)
+	nextStates := make(map[bitvector.Len8][]stateProbability)
+	var compute func(days uint, state bitvector.Len8) (float64, error)
+	compute = func(days uint, state bitvector.Len8) (float64, error) {
+		if state.Count() == g.size {
+			return 1.0, nil
+		}
+		if days == 0 {
+			return 0.0, nil
+		}
+		if p, ok := memo[key{days, state}]; ok {
+			return p, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if _, ok := nextStates[state]; !ok {
+			nextStates[state] = g.enumerateNextStates(masks, state, rate, model, 0)
+		}
+		r := 0.0
+		for _, nextState := range nextStates[state] {
+			p, err := compute(days-1, nextState.state)
+			if err != nil {
+				return 0, err
+			}
+			r += p * nextState.probability
+		}
+		memo[key{days, state}] = r
+		return r, nil
+	}
+
+	var r []float64
+	for _, state := range initial {
+		p, err := compute(days, state)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, p)
+	}
+	return r, nil
+}
+
+// For a given state, returns all possible next states and their probability of happening
+func (g *Graph) enumerateNextStates(masks *neighborMasks, state bitvector.Len8, rate float64, model TransmissionModel, index uint8) []stateProbability {
+	if index == g.size {
+		return []stateProbability{{state: state, probability: 1.0}}
+	}
+	// if index is infected, there's nothing to do for this vertex
+	if state.Get(index) {
+		return g.enumerateNextStates(masks, state, rate, model, index+1)
+	}
+	// count how many infected neighbors this vertex has
+	infected := bits.OnesCount8(uint8(masks[index] & state))
+	if infected == 0 {
+		// there are no infected neighbors
+		return g.enumerateNextStates(masks, state, rate, model, index+1)
+	}
+
+	// The model decides how the infected neighbors combine. Outcomes which can't happen, e.g. with Threshold, are left
+	// out: they would only add zeros.
+	isInfected, p := infectionProbabilities(model, infected, rate)
+	r := g.enumerateNextStates(masks, state, rate, model, index+1)
+	var r2 []stateProbability
+	for _, s := range r {
+		if p != 0 {
+			r2 = append(r2, stateProbability{state: s.state, probability: s.probability * p})
+		}
+		if isInfected != 0 {
+			r2 = append(r2, stateProbability{state: s.state.Set(index, true), probability: s.probability * isInfected})
+		}
+	}
+	return r2
+}
+
+// Returns the rows of the dynamic programming table for days in [minDays, maxDays]. probs[i][state] is the probability
+// of infecting all the vertices within minDays+i days, starting from state. Only the states reachable from initial are
+// computed, the other entries are 0. A nil initial computes every state.
+func (g *Graph) dpTable(ctx context.Context, masks *neighborMasks, minDays, maxDays uint, rate float64, model TransmissionModel, initial []bitvector.Len8) ([][256]float64, error) {
+	lastState := (1 << g.size) - 1
+	if initial == nil {
+		for state := 0; state <= lastState; state++ {
+			initial = append(initial, bitvector.Len8(state))
+		}
+	}
+
+	// Find the reachable states with a BFS over the transitions, numbering them in the order they are found. The
+	// transitions refer to the next states by their number, so that the rows below only hold the reachable states.
+	type transition struct {
+		next        int
+		probability float64
+	}
+	index := make(map[bitvector.Len8]int)
+	var states []bitvector.Len8
+	for _, state := range initial {
+		if _, ok := index[state]; !ok {
+			index[state] = len(states)
+			states = append(states, state)
+		}
+	}
+	m := make([][]transition, 0, len(states))
+	for i := 0; i < len(states); i++ {
+		var transitions []transition
+		for _, nextState := range g.enumerateNextStates(masks, states[i], rate, model, 0) {
+			next, ok := index[nextState.state]
+			if !ok {
+				next = len(states)
+				index[nextState.state] = next
+				states = append(states, nextState.state)
+			}
+			transitions = append(transitions, transition{next, nextState.probability})
+		}
+		m = append(m, transitions)
+	}
+
+	// Each day only depends on the previous one, so we only keep the rows which are returned. The returned rows have
+	// 256 entries, indexed by state, which is a little more convenient than the reachable states' numbering.
+	probs := make([][256]float64, 0, maxDays-minDays+1)
+	row := func(values []float64) [256]float64 {
+		var r [256]float64
+		for i, state := range states {
+			r[state] = values[i]
+		}
+		return r
+	}
+
+	// fill the base case
+	previous := make([]float64, len(states))
+	if last, ok := index[bitvector.Len8(lastState)]; ok {
+		previous[last] = 1.0
+	}
+	if minDays == 0 {
+		probs = append(probs, row(previous))
+	}
+
+	// fill probs table
+	current := make([]float64, len(states))
+	for i := uint(1); i <= maxDays; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// each state depends on probabilities available in m and the previous row
+		for state, transitions := range m {
+			p := 0.0
+			for _, t := range transitions {
+				p += t.probability * previous[t.next]
+			}
+			current[state] = p
+		}
+		previous, current = current, previous
+		if i >= minDays {
+			probs = append(probs, row(previous))
+		}
+	}
+	return probs, nil
+}
+
+// Returns the states where a single vertex is infected, only vertex 0 with firstResultOnly.
+func (g *Graph) initialStates(firstResultOnly bool) []bitvector.Len8 {
+	var r []bitvector.Len8
+	for i := uint8(0); i < g.size; i++ {
+		var state bitvector.Len8
+		r = append(r, state.Set(i, true))
+		if firstResultOnly {
+			break
+		}
+	}
+	return r
+}
+
+// For each possible initial state, perform a single lookup in a row of the dp table.
+func (g *Graph) initialStateProbabilities(probs [256]float64, firstResultOnly bool) []float64 {
+	var r []float64
+	for i := uint8(0); i < g.size; i++ {
+		var initialState bitvector.Len8
+		initialState = initialState.Set(i, true)
+		p := probs[initialState]
+		r = append(r, p)
+		if firstResultOnly {
+			break
+		}
+	}
+	return r
+}
@@ -0,0 +1,205 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Largest number of days for which the tests run the recursive algorithm, which is exponential in the number of days.
+const testRecursiveMaxDays = 6
+
+func TestAlgorithmsAgree(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	models := []TransmissionModel{Independent{}, Linear{}, Threshold{Neighbors: 2}}
+	for i := 0; i < 100; i++ {
+		n := uint8(1 + rng.Intn(6))
+		g := RandomGraph(rng, n, rng.Float64())
+		rate := rng.Float64()
+		days := uint(rng.Intn(10))
+		model := models[i%len(models)]
+		want, err := g.ComputeDays(context.Background(), 0, days, rate, WithModel(model))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, algorithm := range Algorithms {
+			if algorithm == Recursive && days > testRecursiveMaxDays {
+				continue
+			}
+			got, err := g.ComputeDays(context.Background(), 0, days, rate, WithAlgorithm(algorithm), WithModel(model))
+			if err != nil {
+				t.Fatalf("%s with %s: %s", algorithm, model, err)
+			}
+			for d := range want {
+				for v := range want[d] {
+					if math.Abs(got[d][v]-want[d][v]) > 1e-12 {
+						t.Fatalf("%s with %s on %s, rate %g: %g after %d days from vertex %d, dp has %g", algorithm, model, g.Matrix(), rate,
+							got[d][v], d, v, want[d][v])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestComputeCancellation(t *testing.T) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		algorithm Algorithm
+		days      uint
+	}{
+		{DP, 1000000},
+		{Memoized, 1000000},
+		{Recursive, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.algorithm), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				_, err := g.Compute(ctx, tt.days, 0.1, WithAlgorithm(tt.algorithm))
+				done <- err
+			}()
+			time.Sleep(20 * time.Millisecond)
+			cancelled := time.Now()
+			cancel()
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("got error %v, want %v", err, context.Canceled)
+				}
+				if elapsed := time.Since(cancelled); elapsed > 50*time.Millisecond {
+					t.Errorf("returned %s after the cancellation", elapsed)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("still running 5s after the cancellation")
+			}
+		})
+	}
+}
+
+func TestSolveCancelled(t *testing.T) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	summary, err := Solve(ctx, NewSliceSource([]Graph{g, g}), SolveOptions{Targets: []float64{0.7}, Top: 1, MinDays: 30, MaxDays: 30, Rate: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !summary.Interrupted || summary.Processed != 0 {
+		t.Errorf("a cancelled Solve processed %d graphs, interrupted: %t", summary.Processed, summary.Interrupted)
+	}
+}
+
+func TestDPReachableStates(t *testing.T) {
+	// the dp only computes the states reachable from the initial vertices, which must not change the results
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		g := RandomGraph(rng, uint8(1+rng.Intn(MaxSize)), rng.Float64())
+		rate := rng.Float64()
+		days := uint(rng.Intn(30))
+		r, err := g.Compute(context.Background(), days, rate, WithAlgorithm(DP))
+		if err != nil {
+			t.Fatal(err)
+		}
+		states, err := g.ComputeStates(context.Background(), days, rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for v := range r {
+			if want := states[1<<uint(v)]; r[v] != want {
+				t.Fatalf("%s, rate %g, %d days: %g from vertex %d, %g from every state", g.Matrix(), rate, days, r[v], v, want)
+			}
+		}
+	}
+}
+
+func BenchmarkComputeDP(b *testing.B) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := g.Compute(context.Background(), 30, 0.1, WithAlgorithm(DP)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// The dp over every state, for comparison with BenchmarkComputeDP, which only computes the reachable ones.
+func BenchmarkComputeDPAllStates(b *testing.B) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := g.ComputeStates(context.Background(), 30, 0.1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// A 7-cycle and an isolated vertex: half of the states, those where the isolated vertex is infected, aren't reachable
+// from the others.
+func BenchmarkComputeDPUnreachable(b *testing.B) {
+	g, err := ParseMatrix("01000010,10100000,01010000,00101000,00010100,00001010,10000100,00000000")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := g.Compute(context.Background(), 30, 0.1, WithAlgorithm(DP)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRecursiveThreads(t *testing.T) {
+	// the partial sums are added in a fixed order, the results don't depend on the number of goroutines
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		g := RandomGraph(rng, uint8(1+rng.Intn(6)), rng.Float64())
+		rate := rng.Float64()
+		days := uint(rng.Intn(testRecursiveMaxDays + 1))
+		want, err := g.Compute(context.Background(), days, rate, WithAlgorithm(Recursive))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, threads := range []int{2, 3, 8} {
+			got, err := g.Compute(context.Background(), days, rate, WithAlgorithm(Recursive), WithThreads(threads))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s, rate %g, %d days: %v with %d threads, %v with 1", g.Matrix(), rate, days, got, threads, want)
+			}
+		}
+	}
+}
+
+// The recursive algorithm on a 7-cycle with a chord, with 15 days, for an increasing number of goroutines.
+func BenchmarkComputeRecursive(b *testing.B) {
+	g, err := ParseMatrix("0100011,1010000,0101000,0010100,0001010,1000101,1000010")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, threads := range []int{1, 2, 4, 8} {
+		b.Run(strconv.Itoa(threads), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := g.Compute(context.Background(), 15, 0.1, WithAlgorithm(Recursive), WithThreads(threads)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package pondersolve
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/teivah/bitvector"
+)
+
+// StateDistribution returns the probability of each state after the given number of days, when vertex initial is
+// infected on day 0. r[state] is the probability that exactly the vertices whose bits are set in state are infected,
+// r has 2^n entries.
+func (g *Graph) StateDistribution(ctx context.Context, days uint, rate float64, initial uint8) ([]float64, error) {
+	r, err := g.StateDistributions(ctx, days, rate, initial)
+	if err != nil {
+		return nil, err
+	}
+	return r[days], nil
+}
+
+// StateDistributions is like StateDistribution, for every number of days up to days. r[d][state] is the probability
+// of state after d days.
+func (g *Graph) StateDistributions(ctx context.Context, days uint, rate float64, initial uint8) ([][]float64, error) {
+	if initial >= g.size {
+		return nil, fmt.Errorf("%w: initial vertex %d, graph has %d vertices", ErrVertexOutOfRange, initial, g.size)
+	}
+	current := make([]float64, 1<<g.size)
+	current[1<<initial] = 1.0
+	r := [][]float64{current}
+	err := g.Evolve(ctx, 1<<initial, rate, days, func(day uint, distribution []float64) error {
+		r = append(r, append([]float64(nil), distribution...))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Evolve moves the distribution of states forward one day at a time, starting with state initial on day 0, and calls
+// fn with the distribution after each day from 1 to days. The distribution is indexed like StateDistribution's result.
+// Its buffer is reused: it's only valid until fn returns. An error returned by fn stops the evolution, Evolve then
+// returns it.
+func (g *Graph) Evolve(ctx context.Context, initial int, rate float64, days uint, fn func(day uint, distribution []float64) error) error {
+	if _, err := newOptions(rate, nil); err != nil {
+		return err
+	}
+	states := 1 << g.size
+	if initial < 0 || initial >= states {
+		return fmt.Errorf("%w: initial state %b, graph has %d vertices", ErrVertexOutOfRange, initial, g.size)
+	}
+	masks := g.neighborMasks()
+	m := make([][]stateProbability, states)
+
+	current, next := make([]float64, states), make([]float64, states)
+	current[initial] = 1.0
+	for day := uint(1); day <= days; day++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// unlike dpTable, the distribution moves forward in time: each state spreads its probability to its next states
+		for state := range next {
+			next[state] = 0
+		}
+		for state, p := range current {
+			if p == 0 {
+				continue
+			}
+			if m[state] == nil {
+				m[state] = g.enumerateNextStates(masks, bitvector.Len8(state), rate, Independent{}, 0)
+			}
+			for _, nextState := range m[state] {
+				next[nextState.state] += p * nextState.probability
+			}
+		}
+		current, next = next, current
+		if err := fn(day, current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InfectedMoments returns the mean and variance of the number of infected vertices, given a distribution returned by
+// StateDistribution.
+func InfectedMoments(distribution []float64) (mean, variance float64) {
+	var squares float64
+	for state, p := range distribution {
+		x := float64(bits.OnesCount(uint(state)))
+		mean += p * x
+		squares += p * x * x
+	}
+	// E[X²] - E[X]² can be slightly negative due to rounding
+	return mean, math.Max(squares-mean*mean, 0)
+}
+
+// InfectionCovariances returns the covariance of the infection of every pair of vertices, given a distribution returned
+// by StateDistribution for a graph with size vertices. r[i][j] is the covariance of the indicators of vertices i and j
+// being infected, r[i][i] is the variance of vertex i's. The matrix is symmetric. Vertices which are infected in every
+// reachable state, or in none, have a covariance of exactly 0 with every vertex.
+func InfectionCovariances(distribution []float64, size uint8) [][]float64 {
+	marginals := make([]float64, size)
+	joint := make([][]float64, size)
+	for i := range joint {
+		joint[i] = make([]float64, size)
+	}
+	all := bitvector.Len8((1 << size) - 1)
+	always, never := all, all
+	for state, p := range distribution {
+		if p == 0 {
+			continue
+		}
+		always &= bitvector.Len8(state)
+		never &^= bitvector.Len8(state)
+		for i := uint8(0); i < size; i++ {
+			if state&(1<<i) == 0 {
+				continue
+			}
+			marginals[i] += p
+			for j := i; j < size; j++ {
+				if state&(1<<j) != 0 {
+					joint[i][j] += p
+				}
+			}
+		}
+	}
+
+	r := make([][]float64, size)
+	for i := range r {
+		r[i] = make([]float64, size)
+	}
+	for i := uint8(0); i < size; i++ {
+		for j := i; j < size; j++ {
+			// a constant indicator doesn't vary with anything, rounding errors in the marginals aside
+			if always.Get(i) || always.Get(j) || never.Get(i) || never.Get(j) {
+				continue
+			}
+			r[i][j] = joint[i][j] - marginals[i]*marginals[j]
+			r[j][i] = r[i][j]
+		}
+	}
+	return r
+}
+
+// Entropy returns the Shannon entropy in bits of a distribution returned by StateDistribution. States with a zero
+// probability don't contribute, following the convention 0·log(0) = 0.
+func Entropy(distribution []float64) float64 {
+	r := 0.0
+	for _, p := range distribution {
+		if p > 0 {
+			r -= p * math.Log2(p)
+		}
+	}
+	// rounding errors could make a certain outcome slightly negative
+	return math.Max(r, 0)
+}
@@ -0,0 +1,370 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Returns the number of vertices at distance at most days from vertex initial, which are the vertices infected at
+// rate 1.
+func reachableWithin(g *Graph, initial uint8, days uint) int {
+	infected := uint(1) << initial
+	for d := uint(0); d < days; d++ {
+		next := infected
+		for i := uint8(0); i < g.size; i++ {
+			for j := uint8(0); j < g.size; j++ {
+				if infected&(1<<i) != 0 && g.HasEdge(i, j) {
+					next |= 1 << j
+				}
+			}
+		}
+		infected = next
+	}
+	count := 0
+	for ; infected != 0; infected &= infected - 1 {
+		count++
+	}
+	return count
+}
+
+func TestInfectedMomentsAtRateOne(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		g := RandomGraph(rng, uint8(1+rng.Intn(MaxSize)), rng.Float64())
+		initial := uint8(rng.Intn(int(g.Size())))
+		for _, days := range []uint{0, 1, 2, 5} {
+			distribution, err := g.StateDistribution(context.Background(), days, 1, initial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mean, variance := InfectedMoments(distribution)
+			if want := float64(reachableWithin(&g, initial, days)); mean != want || variance != 0 {
+				t.Errorf("%s from vertex %d after %d days at rate 1: mean %g, variance %g, want %g and 0", g.Matrix(), initial, days, mean,
+					variance, want)
+			}
+		}
+	}
+}
+
+func TestInfectedMomentsMatchSimulation(t *testing.T) {
+	const runs = 20000
+	rng := rand.New(rand.NewSource(1))
+	tests := []struct {
+		matrix  string
+		days    uint
+		rate    float64
+		initial uint8
+	}{
+		{"0100,1010,0101,0010", 3, 0.5, 0},
+		{"01111,10000,10000,10000,10000", 2, 0.3, 1},
+		{testPuzzleMatrix, 10, 0.1, 0},
+		{testPuzzleMatrix, 30, 0.2, 3},
+	}
+	for _, tt := range tests {
+		g, err := ParseMatrix(tt.matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		distribution, err := g.StateDistribution(context.Background(), tt.days, tt.rate, tt.initial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mean, variance := InfectedMoments(distribution)
+		sum := 0.0
+		for run := 0; run < runs; run++ {
+			infections, err := g.Simulate(rng, tt.days, tt.rate, tt.initial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum += float64(len(infections))
+		}
+		// within 5 standard errors
+		if sampleMean := sum / runs; math.Abs(mean-sampleMean) > 5*math.Sqrt(variance/runs) {
+			t.Errorf("%s from vertex %d after %d days at rate %g: mean %g, %d simulations give %g", tt.matrix, tt.initial, tt.days, tt.rate,
+				mean, runs, sampleMean)
+		}
+	}
+}
+
+func TestStateDistribution(t *testing.T) {
+	tests := []struct {
+		matrix  string
+		days    uint
+		rate    float64
+		initial uint8
+	}{
+		{"0", 3, 0.5, 0},
+		{"0100,1010,0101,0010", 0, 0.5, 2},
+		{"0100,1010,0101,0010", 3, 0.5, 2},
+		{"0111,1011,1101,1110", 2, 0.2, 3},
+		{"0100,0010,0001,1000", 5, 0.7, 1},
+		{testPuzzleMatrix, 10, 0.1, 0},
+		{testPuzzleMatrix, 30, 0.9, 5},
+	}
+	for _, tt := range tests {
+		g, err := ParseMatrix(tt.matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		distribution, err := g.StateDistribution(context.Background(), tt.days, tt.rate, tt.initial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(distribution) != 1<<g.Size() {
+			t.Fatalf("%s: %d states, want %d", tt.matrix, len(distribution), 1<<g.Size())
+		}
+		sum := 0.0
+		for state, p := range distribution {
+			if state&(1<<tt.initial) == 0 && p != 0 {
+				t.Errorf("%s from vertex %d after %d days: state %b without the initial vertex has probability %g", tt.matrix, tt.initial,
+					tt.days, state, p)
+			}
+			if p < 0 {
+				t.Errorf("%s from vertex %d after %d days: state %b has probability %g", tt.matrix, tt.initial, tt.days, state, p)
+			}
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-12 {
+			t.Errorf("%s from vertex %d after %d days: the probabilities sum to %g", tt.matrix, tt.initial, tt.days, sum)
+		}
+		r, err := g.Compute(context.Background(), tt.days, tt.rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if all := distribution[len(distribution)-1]; math.Abs(all-r[tt.initial]) > 1e-12 {
+			t.Errorf("%s from vertex %d after %d days: every vertex is infected with probability %g, Compute gives %g", tt.matrix,
+				tt.initial, tt.days, all, r[tt.initial])
+		}
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		distribution []float64
+		want         float64
+	}{
+		{[]float64{1}, 0},
+		{[]float64{0, 1, 0, 0}, 0},
+		{[]float64{0.5, 0.5}, 1},
+		{[]float64{0.25, 0.25, 0.25, 0.25}, 2},
+		{[]float64{0, 0.5, 0, 0.5}, 1},
+		{[]float64{0.5, 0.25, 0.25, 0}, 1.5},
+	}
+	for _, tt := range tests {
+		if got := Entropy(tt.distribution); got != tt.want {
+			t.Errorf("Entropy(%v) = %g, want %g", tt.distribution, got, tt.want)
+		}
+	}
+}
+
+func TestEntropyOverTime(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		g := RandomGraph(rng, uint8(1+rng.Intn(MaxSize)), rng.Float64())
+		initial := uint8(rng.Intn(int(g.Size())))
+		for _, rate := range []float64{0, rng.Float64(), 1} {
+			distributions, err := g.StateDistributions(context.Background(), 20, rate, initial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for day, distribution := range distributions {
+				entropy := Entropy(distribution)
+				switch {
+				case math.IsNaN(entropy) || entropy < 0 || entropy > float64(g.Size()):
+					t.Errorf("%s from vertex %d at rate %g: entropy %g on day %d", g.Matrix(), initial, rate, entropy, day)
+				case (day == 0 || rate == 0 || rate == 1) && entropy != 0:
+					t.Errorf("%s from vertex %d at rate %g: entropy %g on day %d, the state is certain", g.Matrix(), initial, rate, entropy, day)
+				}
+			}
+		}
+	}
+}
+
+func TestEvolve(t *testing.T) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, days := range []uint{0, 1, 30} {
+		for _, initial := range []int{1, 1 << 5, 0x0f} {
+			calls := uint(0)
+			err := g.Evolve(context.Background(), initial, 0.1, days, func(day uint, distribution []float64) error {
+				calls++
+				if day != calls {
+					t.Errorf("callback %d for day %d", calls, day)
+				}
+				sum := 0.0
+				for _, p := range distribution {
+					sum += p
+				}
+				if len(distribution) != 1<<g.Size() || math.Abs(sum-1) > 1e-12 {
+					t.Errorf("from state %b, day %d: %d states whose probabilities sum to %g", initial, day, len(distribution), sum)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if calls != days {
+				t.Errorf("from state %b: %d callbacks for %d days", initial, calls, days)
+			}
+		}
+	}
+}
+
+func TestEvolveMatchesStateDistribution(t *testing.T) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := g.StateDistributions(context.Background(), 10, 0.3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Evolve(context.Background(), 1<<2, 0.3, 10, func(day uint, distribution []float64) error {
+		if !reflect.DeepEqual(distribution, want[day]) {
+			t.Errorf("day %d: Evolve and StateDistributions disagree", day)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvolveStops(t *testing.T) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := errors.New("stop")
+	calls := 0
+	err = g.Evolve(context.Background(), 1, 0.1, 30, func(day uint, distribution []float64) error {
+		calls++
+		if day == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || calls != 3 {
+		t.Errorf("got error %v after %d callbacks, want %v after 3", err, calls, stop)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = g.Evolve(ctx, 1, 0.1, 30, func(day uint, distribution []float64) error {
+		calls++
+		if day == 3 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled || calls != 3 {
+		t.Errorf("got error %v after %d callbacks, want %v after 3", err, calls, context.Canceled)
+	}
+}
+
+func TestEvolveErrors(t *testing.T) {
+	g, err := ParseMatrix("011,101,110")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		initial int
+		rate    float64
+		want    error
+	}{
+		{-1, 0.1, ErrVertexOutOfRange},
+		{8, 0.1, ErrVertexOutOfRange},
+		{1, 1.5, ErrInvalidRate},
+		{1, -0.1, ErrInvalidRate},
+	}
+	for _, tt := range tests {
+		err := g.Evolve(context.Background(), tt.initial, tt.rate, 3, func(day uint, distribution []float64) error {
+			t.Errorf("Evolve(%d, %g) called the callback", tt.initial, tt.rate)
+			return nil
+		})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Evolve(%d, %g): got error %v, want %v", tt.initial, tt.rate, err, tt.want)
+		}
+	}
+}
+
+// Computes the covariances of the infection indicators straight from their definition, E[XiXj] - E[Xi]E[Xj].
+func bruteForceCovariances(distribution []float64, size uint8) [][]float64 {
+	infected := func(state int, i uint8) float64 {
+		return float64(state >> i & 1)
+	}
+	r := make([][]float64, size)
+	for i := uint8(0); i < size; i++ {
+		r[i] = make([]float64, size)
+		for j := uint8(0); j < size; j++ {
+			var both, marginalI, marginalJ float64
+			for state, p := range distribution {
+				both += p * infected(state, i) * infected(state, j)
+				marginalI += p * infected(state, i)
+				marginalJ += p * infected(state, j)
+			}
+			r[i][j] = both - marginalI*marginalJ
+		}
+	}
+	return r
+}
+
+func TestInfectionCovariances(t *testing.T) {
+	tests := []struct {
+		matrix  string
+		days    uint
+		rate    float64
+		initial uint8
+	}{
+		{"0", 3, 0.5, 0},
+		{"0100,1010,0101,0010", 3, 0.5, 1},
+		{"01111,10000,10000,10000,10000", 1, 0.3, 0},
+		{"01111,10000,10000,10000,10000", 4, 0.3, 2},
+		{"0110,1000,1000,0000", 5, 0.4, 0}, // vertex 3 is never infected
+		{testPuzzleMatrix, 10, 0.1, 0},
+		{testPuzzleMatrix, 30, 1, 4},
+	}
+	for _, tt := range tests {
+		g, err := ParseMatrix(tt.matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		distribution, err := g.StateDistribution(context.Background(), tt.days, tt.rate, tt.initial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := InfectionCovariances(distribution, g.Size())
+		want := bruteForceCovariances(distribution, g.Size())
+		for i := range got {
+			for j := range got[i] {
+				if got[i][j] != got[j][i] {
+					t.Errorf("%s from vertex %d after %d days: covariance %d-%d is %g, %d-%d is %g", tt.matrix, tt.initial, tt.days, i, j,
+						got[i][j], j, i, got[j][i])
+				}
+				if math.Abs(got[i][j]-want[i][j]) > 1e-12 {
+					t.Errorf("%s from vertex %d after %d days: covariance %d-%d is %g, want %g", tt.matrix, tt.initial, tt.days, i, j,
+						got[i][j], want[i][j])
+				}
+				if (i == int(tt.initial) || j == int(tt.initial)) && got[i][j] != 0 {
+					t.Errorf("%s from vertex %d after %d days: covariance %d-%d with the initial vertex is %g", tt.matrix, tt.initial, tt.days,
+						i, j, got[i][j])
+				}
+			}
+			// the variance of an indicator is p(1-p)
+			p := 0.0
+			for state, q := range distribution {
+				p += q * float64(state>>uint(i)&1)
+			}
+			if math.Abs(got[i][i]-p*(1-p)) > 1e-12 || got[i][i] < 0 {
+				t.Errorf("%s from vertex %d after %d days: variance of vertex %d is %g, it's infected with probability %g", tt.matrix,
+					tt.initial, tt.days, i, got[i][i], p)
+			}
+		}
+	}
+}
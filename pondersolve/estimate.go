@@ -0,0 +1,69 @@
+package pondersolve
+
+// EstimateDays returns a rough estimate of ComputeDays' result, in time linear in the number of days rather than
+// exponential in the number of vertices. It's a mean-field approximation: vertices are assumed to be infected
+// independently of each other, so that a vertex escapes infection from a neighbor infected with probability p with
+// probability 1 - rate * p. It overestimates the probabilities, but ranks graphs about in the same order as
+// ComputeDays, which is what it's meant for. WithModel selects the transmission model as for ComputeDays, the other
+// options are ignored.
+func (g *Graph) EstimateDays(minDays, maxDays uint, rate float64, opts ...Option) [][]float64 {
+	o := options{model: Independent{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	_, independent := o.model.(Independent)
+	r := make([][]float64, maxDays-minDays+1)
+	for d := range r {
+		r[d] = make([]float64, g.size)
+	}
+	// the vertices which can infect each vertex
+	neighbors := make([][]uint8, g.size)
+	for v := uint8(0); v < g.size; v++ {
+		for u := uint8(0); u < g.size; u++ {
+			if g.HasEdge(u, v) {
+				neighbors[v] = append(neighbors[v], u)
+			}
+		}
+	}
+	var p, next [MaxSize]float64
+	for initial := uint8(0); initial < g.size; initial++ {
+		p = [MaxSize]float64{}
+		p[initial] = 1
+		for day := uint(0); day <= maxDays; day++ {
+			if day >= minDays {
+				all := 1.0
+				for _, x := range p[:g.size] {
+					all *= x
+				}
+				r[day-minDays][initial] = all
+			}
+			for v := uint8(0); v < g.size; v++ {
+				if independent {
+					escape := 1 - p[v]
+					for _, n := range neighbors[v] {
+						escape *= 1 - rate*p[n]
+					}
+					next[v] = 1 - escape
+					continue
+				}
+				// the number of infected neighbors, which are independent of each other, has a Poisson binomial
+				// distribution
+				var count [MaxSize + 1]float64
+				count[0] = 1
+				for i, n := range neighbors[v] {
+					for k := i + 1; k > 0; k-- {
+						count[k] = count[k]*(1-p[n]) + count[k-1]*p[n]
+					}
+					count[0] *= 1 - p[n]
+				}
+				infection := 0.0
+				for k := 1; k <= len(neighbors[v]); k++ {
+					infection += count[k] * o.model.InfectionProbability(k, rate)
+				}
+				next[v] = p[v] + (1-p[v])*infection
+			}
+			p = next
+		}
+	}
+	return r
+}
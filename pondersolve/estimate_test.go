@@ -0,0 +1,48 @@
+package pondersolve
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Independent, hidden from EstimateDays' shortcut for it.
+type wrappedIndependent struct{ Independent }
+
+func TestEstimateDaysModel(t *testing.T) {
+	// the shortcut for Independent and the general case agree
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		g := RandomGraph(rng, uint8(1+rng.Intn(MaxSize)), rng.Float64())
+		rate := rng.Float64()
+		want := g.EstimateDays(0, 6, rate)
+		got := g.EstimateDays(0, 6, rate, WithModel(wrappedIndependent{}))
+		for d := range want {
+			for v := range want[d] {
+				if math.Abs(got[d][v]-want[d][v]) > 1e-12 {
+					t.Fatalf("%s at rate %g, day %d from vertex %d: got %g, Independent gives %g", g.Matrix(), rate, d, v, got[d][v], want[d][v])
+				}
+			}
+		}
+	}
+
+	// From the center of a star, the leaves get infected independently of each other: the mean-field approximation is
+	// exact, whatever the model.
+	g, err := ParseMatrix("01111,10000,10000,10000,10000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []TransmissionModel{Independent{}, Linear{}, Threshold{Neighbors: 1}, Threshold{Neighbors: 2}} {
+		want, err := g.ComputeDays(context.Background(), 0, 6, 0.3, WithModel(model))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := g.EstimateDays(0, 6, 0.3, WithModel(model))
+		for d := range want {
+			if math.Abs(got[d][0]-want[d][0]) > 1e-12 {
+				t.Errorf("%v, day %d: got %g, want %g", model, d, got[d][0], want[d][0])
+			}
+		}
+	}
+}
@@ -0,0 +1,428 @@
+package pondersolve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Format is a text representation of a graph, which fits on a single line.
+type Format string
+
+// Supported formats. Every format besides FormatMatrix only represents undirected graphs without self loops.
+const (
+	// FormatMatrix is the adjacency matrix read by ParseMatrix, e.g. "011,100,100".
+	FormatMatrix Format = "matrix"
+	// FormatEdgeList is the number of vertices followed by the edges, e.g. "3 0-1 0-2".
+	FormatEdgeList Format = "edge-list"
+	// FormatGraph6 is the graph6 format used by nauty, e.g. "Bo".
+	FormatGraph6 Format = "graph6"
+	// FormatSparse6 is the sparse6 format used by nauty, e.g. ":Bc".
+	FormatSparse6 Format = "sparse6"
+	// FormatJSON is a JSON object, e.g. {"vertices":3,"edges":[[0,1],[0,2]]}.
+	FormatJSON Format = "json"
+	// FormatDOT is a Graphviz undirected graph, e.g. "graph { 0; 1; 2; 0 -- 1; 0 -- 2; }".
+	FormatDOT Format = "dot"
+)
+
+// Formats lists every supported format.
+var Formats = []Format{FormatMatrix, FormatEdgeList, FormatGraph6, FormatSparse6, FormatJSON, FormatDOT}
+
+// DetectFormat guesses the format of a line of a graphs file, for ParseGraph: sparse6 lines start with ':', graph6
+// lines only use the characters '?' to '~', and anything else is taken as an adjacency matrix. nauty's optional
+// ">>graph6<<" and ">>sparse6<<" headers are recognized too.
+func DetectFormat(text string) Format {
+	switch {
+	case strings.HasPrefix(text, ":") || strings.HasPrefix(text, ">>sparse6<<"):
+		return FormatSparse6
+	case strings.HasPrefix(text, ">>graph6<<"):
+		return FormatGraph6
+	case text == "":
+		return FormatMatrix
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] < 63 || text[i] > 126 {
+			return FormatMatrix
+		}
+	}
+	return FormatGraph6
+}
+
+// ParseGraph parses an adjacency matrix, a graph6 or a sparse6 line, see DetectFormat. Matrices and graph6 never
+// overlap: matrices only use '0', '1' and ','.
+func ParseGraph(text string) (Graph, error) {
+	return Decode(DetectFormat(text), text)
+}
+
+// GuessSize returns the number of vertices of a line of a graphs file without decoding it, to plan the work: it's read
+// from the header of graph6 and sparse6 lines, and from the number of rows of anything else. Malformed lines get a
+// guess all the same.
+func GuessSize(text string) int {
+	switch DetectFormat(text) {
+	case FormatGraph6:
+		if text = strings.TrimPrefix(text, ">>graph6<<"); len(text) > 0 {
+			return int(text[0]) - 63
+		}
+	case FormatSparse6:
+		if text = strings.TrimPrefix(text, ">>sparse6<<"); len(text) > 1 {
+			return int(text[1]) - 63
+		}
+	}
+	return strings.Count(text, ",") + 1
+}
+
+// Errors returned by Decode and Encode.
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	ErrBadEncoding   = errors.New("malformed graph")
+	ErrNotUndirected = errors.New("graph is not undirected")
+)
+
+// Decode parses a graph in the given format.
+func Decode(format Format, text string) (Graph, error) {
+	switch format {
+	case FormatMatrix:
+		return ParseMatrix(text)
+	case FormatEdgeList:
+		return decodeEdgeList(text)
+	case FormatGraph6:
+		return decodeGraph6(text)
+	case FormatSparse6:
+		return decodeSparse6(text)
+	case FormatJSON:
+		return decodeJSON(text)
+	case FormatDOT:
+		return decodeDOT(text)
+	default:
+		return Graph{}, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+// Encode formats the graph in the given format. The returned error wraps ErrNotUndirected when the format can't
+// represent the graph.
+func (g Graph) Encode(format Format) (string, error) {
+	if format == FormatMatrix {
+		return g.Matrix(), nil
+	}
+	known := false
+	for _, f := range Formats {
+		known = known || f == format
+	}
+	if !known {
+		return "", fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	if !g.undirected() {
+		return "", fmt.Errorf("%w: %s can only represent undirected graphs without self loops", ErrNotUndirected, format)
+	}
+	switch format {
+	case FormatEdgeList:
+		r := []string{strconv.Itoa(int(g.size))}
+		for _, e := range g.edges() {
+			r = append(r, fmt.Sprintf("%d-%d", e[0], e[1]))
+		}
+		return strings.Join(r, " "), nil
+	case FormatGraph6:
+		return g.encodeGraph6(), nil
+	case FormatSparse6:
+		return g.encodeSparse6(), nil
+	case FormatJSON:
+		b, err := json.Marshal(jsonGraph{Vertices: g.size, Edges: g.edges()})
+		return string(b), err
+	default:
+		var r strings.Builder
+		r.WriteString("graph {")
+		for i := uint8(0); i < g.size; i++ {
+			fmt.Fprintf(&r, " %d;", i)
+		}
+		for _, e := range g.edges() {
+			fmt.Fprintf(&r, " %d -- %d;", e[0], e[1])
+		}
+		r.WriteString(" }")
+		return r.String(), nil
+	}
+}
+
+// Checks whether every edge goes both ways and no vertex has an edge to itself.
+func (g *Graph) undirected() bool {
+	for i := uint8(0); i < g.size; i++ {
+		for j := i; j < g.size; j++ {
+			if g.HasEdge(i, j) != g.HasEdge(j, i) || (i == j && g.HasEdge(i, i)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Returns the undirected edges, each as a pair of vertices in increasing order.
+func (g *Graph) edges() [][2]uint8 {
+	r := [][2]uint8{}
+	for i := uint8(0); i < g.size; i++ {
+		for j := i + 1; j < g.size; j++ {
+			if g.HasEdge(i, j) {
+				r = append(r, [2]uint8{i, j})
+			}
+		}
+	}
+	return r
+}
+
+// Builds a graph from a vertex count and a list of edges, returning errors from NewGraph and AddEdge.
+func fromEdges(vertices int, edges [][2]int) (Graph, error) {
+	g, err := newGraph(vertices)
+	if err != nil {
+		return Graph{}, err
+	}
+	for _, e := range edges {
+		if e[0] < 0 || e[1] < 0 || e[0] >= vertices || e[1] >= vertices {
+			return Graph{}, fmt.Errorf("%w: edge %d-%d, graph has %d vertices", ErrVertexOutOfRange, e[0], e[1], vertices)
+		}
+		if err := g.AddEdge(uint8(e[0]), uint8(e[1])); err != nil {
+			return Graph{}, err
+		}
+	}
+	return *g, nil
+}
+
+func decodeEdgeList(text string) (Graph, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return Graph{}, fmt.Errorf("%w: missing vertex count", ErrBadEncoding)
+	}
+	vertices, err := strconv.Atoi(fields[0])
+	if err != nil || vertices < 0 {
+		return Graph{}, fmt.Errorf("%w: bad vertex count %q", ErrBadEncoding, fields[0])
+	}
+	var edges [][2]int
+	for _, field := range fields[1:] {
+		var e [2]int
+		if _, err := fmt.Sscanf(field, "%d-%d", &e[0], &e[1]); err != nil {
+			return Graph{}, fmt.Errorf("%w: bad edge %q", ErrBadEncoding, field)
+		}
+		edges = append(edges, e)
+	}
+	return fromEdges(vertices, edges)
+}
+
+type jsonGraph struct {
+	Vertices uint8      `json:"vertices"`
+	Edges    [][2]uint8 `json:"edges"`
+}
+
+func decodeJSON(text string) (Graph, error) {
+	var j struct {
+		Vertices int      `json:"vertices"`
+		Edges    [][2]int `json:"edges"`
+	}
+	if err := json.Unmarshal([]byte(text), &j); err != nil {
+		return Graph{}, fmt.Errorf("%w: %s", ErrBadEncoding, err)
+	}
+	if j.Vertices < 0 {
+		return Graph{}, fmt.Errorf("%w: bad vertex count %d", ErrBadEncoding, j.Vertices)
+	}
+	return fromEdges(j.Vertices, j.Edges)
+}
+
+// Only the graphs written by Encode are supported: a single "graph { ... }" block with numeric vertices, declared
+// before being used in edges.
+func decodeDOT(text string) (Graph, error) {
+	text = strings.TrimSpace(text)
+	start, end := strings.Index(text, "{"), strings.LastIndex(text, "}")
+	if start < 0 || end < start || !strings.HasPrefix(text, "graph") || strings.TrimSpace(text[end+1:]) != "" {
+		return Graph{}, fmt.Errorf("%w: expecting \"graph { ... }\"", ErrBadEncoding)
+	}
+	vertices := 0
+	var edges [][2]int
+	for _, statement := range strings.Split(text[start+1:end], ";") {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		if ends := strings.Split(statement, "--"); len(ends) == 2 {
+			var e [2]int
+			var err error
+			for k, vertex := range ends {
+				if e[k], err = strconv.Atoi(strings.TrimSpace(vertex)); err != nil {
+					return Graph{}, fmt.Errorf("%w: bad edge %q", ErrBadEncoding, statement)
+				}
+			}
+			edges = append(edges, e)
+			continue
+		}
+		v, err := strconv.Atoi(statement)
+		if err != nil || v != vertices {
+			return Graph{}, fmt.Errorf("%w: bad vertex %q", ErrBadEncoding, statement)
+		}
+		vertices++
+	}
+	return fromEdges(vertices, edges)
+}
+
+// graph6 stores the number of vertices as a byte offset by 63, followed by the upper triangle of the adjacency matrix
+// column by column, 6 bits per byte, each byte offset by 63.
+func (g *Graph) encodeGraph6() string {
+	r := []byte{g.size + 63}
+	var current, bits byte
+	for j := uint8(1); j < g.size; j++ {
+		for i := uint8(0); i < j; i++ {
+			current <<= 1
+			if g.HasEdge(i, j) {
+				current |= 1
+			}
+			bits++
+			if bits == 6 {
+				r = append(r, current+63)
+				current, bits = 0, 0
+			}
+		}
+	}
+	if bits > 0 {
+		r = append(r, current<<(6-bits)+63)
+	}
+	return string(r)
+}
+
+func decodeGraph6(text string) (Graph, error) {
+	text = strings.TrimPrefix(text, ">>graph6<<")
+	if len(text) == 0 || text[0] < 63 || text[0] > 126 {
+		return Graph{}, fmt.Errorf("%w: bad graph6 header", ErrBadEncoding)
+	}
+	if text[0] == 126 {
+		return Graph{}, fmt.Errorf("%w: more than 62 vertices", ErrTooLarge)
+	}
+	vertices := int(text[0]) - 63
+	g, err := newGraph(vertices)
+	if err != nil {
+		return Graph{}, err
+	}
+	data := text[1:]
+	if len(data) != (vertices*(vertices-1)/2+5)/6 {
+		return Graph{}, fmt.Errorf("%w: expecting %d bytes of edges, got %d", ErrBadEncoding, (vertices*(vertices-1)/2+5)/6, len(data))
+	}
+	bit := 0
+	for j := 1; j < vertices; j++ {
+		for i := 0; i < j; i++ {
+			c := data[bit/6]
+			if c < 63 || c > 126 {
+				return Graph{}, fmt.Errorf("%w: bad graph6 character '%c'", ErrBadEncoding, c)
+			}
+			if (c-63)&(1<<uint(5-bit%6)) != 0 {
+				g.addEdge(uint8(i), uint8(j))
+				g.addEdge(uint8(j), uint8(i))
+			}
+			bit++
+		}
+	}
+	return *g, nil
+}
+
+// sparse6 starts with ':' and the number of vertices n like graph6, followed by a list of units of 1 bit b and k bits x,
+// where k is the number of bits of n-1, 6 bits per byte offset by 63. A current vertex v starts at 0, b increments it,
+// then x > v moves v to x while x <= v is an edge between x and v. The last byte is padded with 1s.
+func (g *Graph) encodeSparse6() string {
+	k := uint(0)
+	if g.size > 1 {
+		k = uint(bits.Len8(g.size - 1))
+	}
+	r := []byte{':', g.size + 63}
+	var current byte
+	var used uint
+	write := func(value uint8, width uint) {
+		for w := width; w > 0; w-- {
+			current = current<<1 | (value>>(w-1))&1
+			used++
+			if used == 6 {
+				r = append(r, current+63)
+				current, used = 0, 0
+			}
+		}
+	}
+	// the edges are listed by larger vertex, so that v only moves forward
+	v := uint8(0)
+	for j := uint8(1); j < g.size; j++ {
+		for i := uint8(0); i < j; i++ {
+			if !g.HasEdge(i, j) {
+				continue
+			}
+			switch {
+			case j == v:
+				write(0, 1)
+			case j == v+1:
+				write(1, 1)
+			default:
+				write(1, 1)
+				write(j, k)
+				write(0, 1)
+			}
+			v = j
+			write(i, k)
+		}
+	}
+	if used > 0 {
+		// padding with 1s would read as an edge between n-1 and itself when v is n-2 and n is 2^k, a 0 avoids it
+		if k < 6 && v+2 == g.size && g.size == 1<<k && 6-used >= k+1 {
+			write(0, 1)
+		}
+		for used > 0 {
+			write(1, 1)
+		}
+	}
+	return string(r)
+}
+
+func decodeSparse6(text string) (Graph, error) {
+	text = strings.TrimPrefix(text, ">>sparse6<<")
+	if len(text) < 2 || text[0] != ':' || text[1] < 63 || text[1] > 126 {
+		return Graph{}, fmt.Errorf("%w: bad sparse6 header", ErrBadEncoding)
+	}
+	if text[1] == 126 {
+		return Graph{}, fmt.Errorf("%w: more than 62 vertices", ErrTooLarge)
+	}
+	vertices := int(text[1]) - 63
+	k := 0
+	if vertices > 1 {
+		k = bits.Len(uint(vertices - 1))
+	}
+	data := text[2:]
+	for i := 0; i < len(data); i++ {
+		if data[i] < 63 || data[i] > 126 {
+			return Graph{}, fmt.Errorf("%w: bad sparse6 character '%c'", ErrBadEncoding, data[i])
+		}
+	}
+	bit := 0
+	read := func(width int) (int, bool) {
+		value := 0
+		for ; width > 0; width-- {
+			if bit == len(data)*6 {
+				return 0, false
+			}
+			value = value<<1 | int(data[bit/6]-63)>>uint(5-bit%6)&1
+			bit++
+		}
+		return value, true
+	}
+	var edges [][2]int
+	v := 0
+	for {
+		b, ok := read(1)
+		if !ok {
+			break
+		}
+		x, ok := read(k)
+		if !ok {
+			break
+		}
+		if b == 1 {
+			v++
+		}
+		if x > v {
+			v = x
+		} else if v < vertices {
+			edges = append(edges, [2]int{x, v})
+		}
+	}
+	return fromEdges(vertices, edges)
+}
@@ -0,0 +1,152 @@
+package pondersolve
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestFormatRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// a matrix needs at least one vertex
+	var graphs []Graph
+	for n := uint8(1); n <= 6; n++ {
+		for pattern := uint64(0); pattern < 1<<(uint(n)*uint(n-1)/2); pattern++ {
+			graphs = append(graphs, FromUpperTriangle(n, pattern))
+		}
+	}
+	for i := 0; i < 2000; i++ {
+		graphs = append(graphs, RandomGraph(rng, uint8(7+rng.Intn(2)), rng.Float64()))
+	}
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			for _, g := range graphs {
+				text, err := g.Encode(format)
+				if err != nil {
+					t.Fatalf("Encode(%s): %s", g.Matrix(), err)
+				}
+				got, err := Decode(format, text)
+				if err != nil {
+					t.Fatalf("Decode(%q), encoded from %s: %s", text, g.Matrix(), err)
+				}
+				if got != g {
+					t.Fatalf("%s encodes to %q which decodes to %s", g.Matrix(), text, got.Matrix())
+				}
+				if format == FormatMatrix || format == FormatGraph6 || format == FormatSparse6 {
+					if DetectFormat(text) != format {
+						t.Fatalf("DetectFormat(%q) = %s, want %s", text, DetectFormat(text), format)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		matrix string
+		format Format
+		want   string
+	}{
+		{"011,100,100", FormatEdgeList, "3 0-1 0-2"},
+		{"011,100,100", FormatGraph6, "Bo"},
+		{"011,100,100", FormatSparse6, ":Bc"},
+		{"011,100,100", FormatJSON, `{"vertices":3,"edges":[[0,1],[0,2]]}`},
+		{"011,100,100", FormatDOT, "graph { 0; 1; 2; 0 -- 1; 0 -- 2; }"},
+		{"0111,1011,1101,1110", FormatGraph6, "C~"},
+		{"000,000,000", FormatJSON, `{"vertices":3,"edges":[]}`},
+	}
+	for _, tt := range tests {
+		g, err := ParseMatrix(tt.matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := g.Encode(tt.format); err != nil || got != tt.want {
+			t.Errorf("Encode(%s, %s) = %q, %v, want %q", tt.matrix, tt.format, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	directed, err := ParseMatrix("01,00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range Formats {
+		if format == FormatMatrix {
+			continue
+		}
+		if _, err := directed.Encode(format); !errors.Is(err, ErrNotUndirected) {
+			t.Errorf("Encode(01,00, %s): got error %v, want %v", format, err, ErrNotUndirected)
+		}
+	}
+	if _, err := directed.Encode("adjacency"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Encode(01,00, adjacency): got error %v, want %v", err, ErrUnknownFormat)
+	}
+
+	tests := []struct {
+		format Format
+		text   string
+		want   error
+	}{
+		{"adjacency", "011,100,100", ErrUnknownFormat},
+		{FormatEdgeList, "", ErrBadEncoding},
+		{FormatEdgeList, "3 0-1 0+2", ErrBadEncoding},
+		{FormatEdgeList, "3 0-3", ErrVertexOutOfRange},
+		{FormatEdgeList, "3 1-1", ErrSelfLoop},
+		{FormatEdgeList, "9", ErrTooLarge},
+		{FormatGraph6, "B", ErrBadEncoding},
+		{FormatGraph6, "Bo?", ErrBadEncoding},
+		{FormatGraph6, "H~~~~~~", ErrTooLarge},
+		{FormatSparse6, "Bc", ErrBadEncoding},
+		{FormatSparse6, ":B ", ErrBadEncoding},
+		{FormatJSON, `{"vertices":3,"edges":[[0,1]`, ErrBadEncoding},
+		{FormatJSON, `{"vertices":-1}`, ErrBadEncoding},
+		{FormatDOT, "digraph { 0; 1; 0 -> 1; }", ErrBadEncoding},
+		{FormatDOT, "graph { 0; 2; }", ErrBadEncoding},
+	}
+	for _, tt := range tests {
+		if _, err := Decode(tt.format, tt.text); !errors.Is(err, tt.want) {
+			t.Errorf("Decode(%s, %q): got error %v, want %v", tt.format, tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestGuessSize(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := uint8(1); n <= MaxSize; n++ {
+		g := RandomGraph(rng, n, 0.5)
+		for _, format := range []Format{FormatMatrix, FormatGraph6, FormatSparse6} {
+			text, err := g.Encode(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := []string{text}
+			if format != FormatMatrix {
+				// nauty's optional header
+				lines = append(lines, ">>"+string(format)+"<<"+text)
+			}
+			for _, line := range lines {
+				if got := GuessSize(line); got != int(n) {
+					t.Errorf("GuessSize(%q) = %d, want %d", line, got, n)
+				}
+			}
+		}
+	}
+	// malformed lines
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 1},
+		{"01,1", 2},
+		{"Bo?", 3},
+		{":D", 5},
+		{">>graph6<<", 1},
+	}
+	for _, tt := range tests {
+		if got := GuessSize(tt.text); got != tt.want {
+			t.Errorf("GuessSize(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
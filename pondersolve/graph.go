@@ -0,0 +1,357 @@
+// Package pondersolve computes the probability for an infection to reach every vertex of a graph within a number of
+// days, as described in the IBM Ponder This - April 2020 (COVID-19 outbreak) challenge.
+// See https://quaxio.com/ponder_this_april_2020_writeup/ for writeup.
+package pondersolve
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/teivah/bitvector"
+)
+
+// MaxSize is the maximum number of vertices of a Graph.
+const MaxSize = 8
+
+// Graph is a graph with at most MaxSize vertices, stored as an adjacency matrix. The zero value is a graph without
+// any vertices.
+//
+// The infection passes along the direction of the edges: from vertex i to vertex j when HasEdge(i, j). Undirected
+// graphs, like the ones built with AddEdge, have both directions of every edge, see Symmetric.
+type Graph struct {
+	size     uint8 // number of vertices
+	vertices bitvector.Len64
+}
+
+// Errors returned when building a graph.
+var (
+	ErrTooLarge         = errors.New("matrix size is too large")
+	ErrNotSquare        = errors.New("matrix is not square")
+	ErrBadCharacter     = errors.New("unknown character in matrix")
+	ErrVertexOutOfRange = errors.New("vertex out of range")
+	ErrSelfLoop         = errors.New("edge from a vertex to itself")
+	ErrNotPermutation   = errors.New("not a permutation of the vertices")
+	ErrAsymmetric       = errors.New("matrix isn't symmetric")
+)
+
+// NewGraph returns a graph with n vertices and no edges. n can be at most MaxSize.
+func NewGraph(n uint8) (*Graph, error) {
+	return newGraph(int(n))
+}
+
+func newGraph(n int) (*Graph, error) {
+	if n > MaxSize {
+		return nil, fmt.Errorf("%w: %d > %d", ErrTooLarge, n, MaxSize)
+	}
+	return &Graph{size: uint8(n)}, nil
+}
+
+// ParseMatrix parses an adjacency matrix made of comma separated rows of 0s and 1s, e.g. "011,100,100". The cell in
+// row i and column j is the edge from vertex i to vertex j, so asymmetric matrices are directed graphs. The returned
+// error wraps ErrTooLarge, ErrNotSquare or ErrBadCharacter.
+func ParseMatrix(matrix string) (Graph, error) {
+	rows := strings.Split(matrix, ",")
+	// check that we have at most 8 rows/cols
+	g, err := newGraph(len(rows))
+	if err != nil {
+		return Graph{}, err
+	}
+
+	// check that we have a square matrix + convert string to bits
+	for i, row := range rows {
+		if len(row) != len(rows) {
+			return Graph{}, fmt.Errorf("%w: row %d has length %d but expecting %d", ErrNotSquare, i, len(row), len(rows))
+		}
+		for j, char := range row {
+			switch char {
+			case '0':
+			case '1':
+				// the matrix lists both directions of an edge, each cell only sets its own direction
+				if err := g.setEdge(uint8(i), uint8(j), true); err != nil {
+					return Graph{}, err
+				}
+			default:
+				return Graph{}, fmt.Errorf("%w: '%c'", ErrBadCharacter, char)
+			}
+		}
+	}
+
+	return *g, nil
+}
+
+// FromUpperTriangle builds an undirected graph from a bit pattern of the upper triangle of its adjacency matrix. Bits
+// are taken in row order: (0, 1), (0, 2), ..., (1, 2), ...
+func FromUpperTriangle(size uint8, pattern uint64) Graph {
+	g := Graph{size: size}
+	bit := uint(0)
+	for i := uint8(0); i < size; i++ {
+		for j := i + 1; j < size; j++ {
+			if pattern&(1<<bit) != 0 {
+				g.addEdge(i, j)
+				g.addEdge(j, i)
+			}
+			bit++
+		}
+	}
+	return g
+}
+
+// RandomGraph returns a G(n, p) random graph: each edge is present with probability p.
+func RandomGraph(rng *rand.Rand, n uint8, p float64) Graph {
+	g := Graph{size: n}
+	for i := uint8(0); i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if rng.Float64() < p {
+				g.addEdge(i, j)
+				g.addEdge(j, i)
+			}
+		}
+	}
+	return g
+}
+
+// Size returns the number of vertices.
+func (g *Graph) Size() uint8 {
+	return g.size
+}
+
+func (g *Graph) addEdge(vertex1, vertex2 uint8) {
+	g.vertices = g.vertices.Set(vertex1*8+vertex2, true)
+}
+
+// Checks that both vertices are in the graph.
+func (g *Graph) checkVertices(vertex1, vertex2 uint8) error {
+	for _, v := range []uint8{vertex1, vertex2} {
+		if v >= g.size {
+			return fmt.Errorf("%w: %d, graph has %d vertices", ErrVertexOutOfRange, v, g.size)
+		}
+	}
+	return nil
+}
+
+// Sets or clears the edge from vertex1 to vertex2.
+func (g *Graph) setEdge(vertex1, vertex2 uint8, value bool) error {
+	if err := g.checkVertices(vertex1, vertex2); err != nil {
+		return err
+	}
+	g.vertices = g.vertices.Set(vertex1*8+vertex2, value)
+	return nil
+}
+
+// AddEdge adds an undirected edge between two vertices. Adding an edge which already exists does nothing. The
+// returned error wraps ErrVertexOutOfRange or ErrSelfLoop.
+func (g *Graph) AddEdge(vertex1, vertex2 uint8) error {
+	if err := g.checkVertices(vertex1, vertex2); err != nil {
+		return err
+	}
+	if vertex1 == vertex2 {
+		return fmt.Errorf("%w: %d", ErrSelfLoop, vertex1)
+	}
+	g.addEdge(vertex1, vertex2)
+	g.addEdge(vertex2, vertex1)
+	return nil
+}
+
+// RemoveEdge removes the edge between two vertices, in both directions. Removing an edge which doesn't exist does
+// nothing. The returned error wraps ErrVertexOutOfRange.
+func (g *Graph) RemoveEdge(vertex1, vertex2 uint8) error {
+	if err := g.setEdge(vertex1, vertex2, false); err != nil {
+		return err
+	}
+	return g.setEdge(vertex2, vertex1, false)
+}
+
+// HasEdge checks whether there's an edge from vertex1 to vertex2. Vertices outside the graph have no edges.
+func (g *Graph) HasEdge(vertex1, vertex2 uint8) bool {
+	if vertex1 >= g.size || vertex2 >= g.size {
+		return false
+	}
+	return g.vertices.Get(vertex1*8 + vertex2)
+}
+
+// Symmetric checks whether every edge goes both ways, i.e. whether the graph is undirected. Unlike the undirected
+// graphs of Encode, self loops are allowed: they don't change how the infection spreads.
+func (g *Graph) Symmetric() bool {
+	for i := uint8(0); i < g.size; i++ {
+		for j := i + 1; j < g.size; j++ {
+			if g.HasEdge(i, j) != g.HasEdge(j, i) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Degree returns the number of edges going out of vertex v.
+func (g *Graph) Degree(v uint8) int {
+	degree := 0
+	for i := uint8(0); i < g.size; i++ {
+		if g.HasEdge(v, i) {
+			degree++
+		}
+	}
+	return degree
+}
+
+// Neighbors returns the vertices which v has an edge to, in increasing order.
+func (g *Graph) Neighbors(v uint8) []uint8 {
+	var r []uint8
+	for i := uint8(0); i < g.size; i++ {
+		if g.HasEdge(v, i) {
+			r = append(r, i)
+		}
+	}
+	return r
+}
+
+// ToggleEdge adds the edge between two vertices if it's missing, removes it otherwise.
+func (g *Graph) ToggleEdge(vertex1, vertex2 uint8) {
+	g.vertices = g.vertices.Toggle(vertex1*8 + vertex2).Toggle(vertex2*8 + vertex1)
+}
+
+// Compact returns a 9 bytes representation of the graph: its size followed by its edges.
+func (g *Graph) Compact() [9]byte {
+	var r [9]byte
+	r[0] = g.size
+	binary.LittleEndian.PutUint64(r[1:], uint64(g.vertices))
+	return r
+}
+
+// Connected checks whether every vertex can be reached from vertex 0.
+func (g *Graph) Connected() bool {
+	if g.size == 0 {
+		return true
+	}
+	var reached bitvector.Len8
+	reached = reached.Set(0, true)
+	queue := []uint8{0}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for i := uint8(0); i < g.size; i++ {
+			if g.HasEdge(v, i) && !reached.Get(i) {
+				reached = reached.Set(i, true)
+				queue = append(queue, i)
+			}
+		}
+	}
+	return reached.Count() == g.size
+}
+
+// EdgeCount returns the number of edges, assuming the graph is undirected.
+func (g *Graph) EdgeCount() int {
+	return int(g.vertices.Count()) / 2
+}
+
+// InducedSubgraph returns the graph made of the given vertices and the edges between them. Vertex i of the subgraph is
+// vertex keep[i] of g.
+func (g *Graph) InducedSubgraph(keep []uint8) Graph {
+	r := Graph{size: uint8(len(keep))}
+	for i, vertex1 := range keep {
+		for j, vertex2 := range keep {
+			if g.HasEdge(vertex1, vertex2) {
+				r.addEdge(uint8(i), uint8(j))
+			}
+		}
+	}
+	return r
+}
+
+// Complement returns the graph with the same vertices, where two distinct vertices are adjacent exactly when they
+// aren't adjacent in g.
+func (g *Graph) Complement() Graph {
+	r := Graph{size: g.size}
+	for i := uint8(0); i < g.size; i++ {
+		for j := uint8(0); j < g.size; j++ {
+			if i != j && !g.HasEdge(i, j) {
+				r.addEdge(i, j)
+			}
+		}
+	}
+	return r
+}
+
+// Permute returns a copy of g where vertex i becomes vertex perm[i]. perm must contain every vertex exactly once.
+func (g *Graph) Permute(perm []uint8) (Graph, error) {
+	if len(perm) != int(g.size) {
+		return Graph{}, fmt.Errorf("%w: %d labels for %d vertices", ErrNotPermutation, len(perm), g.size)
+	}
+	var seen [MaxSize]bool
+	for _, v := range perm {
+		if v >= g.size || seen[v] {
+			return Graph{}, fmt.Errorf("%w: %v", ErrNotPermutation, perm)
+		}
+		seen[v] = true
+	}
+	return g.permute(perm), nil
+}
+
+// Pivot transforms the graph so that the infected vertex becomes the first vertex, by swapping the labels of vertex 0
+// and the infected vertex. The other vertices keep their labels, and pivoting twice with the same vertex restores the
+// graph.
+func (g *Graph) Pivot(infected uint8) {
+	perm := make([]uint8, g.size)
+	for i := range perm {
+		perm[i] = uint8(i)
+	}
+	perm[0], perm[infected] = infected, 0
+	*g = g.permute(perm)
+}
+
+// Matrix formats the graph on a single line, using the same comma separated rows format as ParseMatrix.
+func (g Graph) Matrix() string {
+	var r strings.Builder
+	for i := byte(0); i < g.size; i++ {
+		if i > 0 {
+			r.WriteByte(',')
+		}
+		for j := byte(0); j < g.size; j++ {
+			if g.HasEdge(i, j) {
+				r.WriteByte('1')
+			} else {
+				r.WriteByte('0')
+			}
+		}
+	}
+	return r.String()
+}
+
+// MarshalText implements encoding.TextMarshaler, using the single line format returned by Matrix.
+func (g Graph) MarshalText() ([]byte, error) {
+	return []byte(g.Matrix()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, parsing the format read by ParseMatrix. An empty text is a graph
+// without any vertices.
+func (g *Graph) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*g = Graph{}
+		return nil
+	}
+	parsed, err := ParseMatrix(string(text))
+	if err != nil {
+		return err
+	}
+	*g = parsed
+	return nil
+}
+
+// String formats the graph as a matrix, one row per line, for human display. Use Matrix or MarshalText for a format
+// which can be parsed back.
+func (g Graph) String() string {
+	var r strings.Builder
+	for i := byte(0); i < g.size; i++ {
+		for j := byte(0); j < g.size; j++ {
+			if g.HasEdge(i, j) {
+				fmt.Fprintf(&r, "1")
+			} else {
+				fmt.Fprintf(&r, "0")
+			}
+		}
+		fmt.Fprintln(&r, "")
+	}
+	return r.String()
+}
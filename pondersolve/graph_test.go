@@ -0,0 +1,397 @@
+package pondersolve_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+func TestNewGraph(t *testing.T) {
+	for n := uint8(0); n <= pondersolve.MaxSize; n++ {
+		g, err := pondersolve.NewGraph(n)
+		if err != nil {
+			t.Fatalf("NewGraph(%d): %s", n, err)
+		}
+		if g.Size() != n || g.EdgeCount() != 0 {
+			t.Errorf("NewGraph(%d) has %d vertices and %d edges", n, g.Size(), g.EdgeCount())
+		}
+	}
+	if _, err := pondersolve.NewGraph(pondersolve.MaxSize + 1); !errors.Is(err, pondersolve.ErrTooLarge) {
+		t.Errorf("NewGraph(%d): got error %v, want %v", pondersolve.MaxSize+1, err, pondersolve.ErrTooLarge)
+	}
+}
+
+func TestEdgeErrors(t *testing.T) {
+	g, err := pondersolve.NewGraph(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"AddEdge(0, 3)", g.AddEdge(0, 3), pondersolve.ErrVertexOutOfRange},
+		{"AddEdge(3, 0)", g.AddEdge(3, 0), pondersolve.ErrVertexOutOfRange},
+		{"AddEdge(200, 1)", g.AddEdge(200, 1), pondersolve.ErrVertexOutOfRange},
+		{"AddEdge(1, 1)", g.AddEdge(1, 1), pondersolve.ErrSelfLoop},
+		{"RemoveEdge(0, 3)", g.RemoveEdge(0, 3), pondersolve.ErrVertexOutOfRange},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: got error %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+	if g.EdgeCount() != 0 {
+		t.Errorf("failed calls added %d edges", g.EdgeCount())
+	}
+}
+
+func TestAddEdgeTwice(t *testing.T) {
+	g, err := pondersolve.NewGraph(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := g.AddEdge(0, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.AddEdge(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if g.EdgeCount() != 1 || g.Degree(0) != 1 || g.Degree(2) != 1 || !g.HasEdge(0, 2) || !g.HasEdge(2, 0) {
+		t.Errorf("adding the same edge three times gives %s", g.Matrix())
+	}
+	for i := 0; i < 2; i++ {
+		if err := g.RemoveEdge(2, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g.EdgeCount() != 0 || g.HasEdge(0, 2) || g.HasEdge(2, 0) {
+		t.Errorf("removing the edge twice gives %s", g.Matrix())
+	}
+}
+
+func TestBuilderMatchesMatrix(t *testing.T) {
+	const matrix = "0110,1011,1100,0100"
+	g, err := pondersolve.NewGraph(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range [][2]uint8{{0, 1}, {0, 2}, {1, 2}, {3, 1}} {
+		if err := g.AddEdge(e[0], e[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if g.Matrix() != matrix {
+		t.Fatalf("built %s, want %s", g.Matrix(), matrix)
+	}
+	if got := g.Neighbors(1); !reflect.DeepEqual(got, []uint8{0, 2, 3}) {
+		t.Errorf("Neighbors(1) = %v, want [0 2 3]", got)
+	}
+	if g.EdgeCount() != 4 || g.Degree(1) != 3 || g.Degree(3) != 1 {
+		t.Errorf("%s has %d edges, vertex 1 has degree %d and vertex 3 degree %d", matrix, g.EdgeCount(), g.Degree(1), g.Degree(3))
+	}
+
+	parsed, err := pondersolve.ParseMatrix(matrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := parsed.Compute(context.Background(), 5, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := g.Compute(context.Background(), 5, 0.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("built graph computes %v, parsed matrix %v", got, want)
+	}
+}
+
+// Returns the n×n matrix whose cell (i, j) is bit i*n+j of pattern, in the format read by ParseMatrix.
+func matrixString(n int, pattern uint) string {
+	rows := make([]string, n)
+	for i := range rows {
+		var row strings.Builder
+		for j := 0; j < n; j++ {
+			if pattern&(1<<uint(i*n+j)) != 0 {
+				row.WriteByte('1')
+			} else {
+				row.WriteByte('0')
+			}
+		}
+		rows[i] = row.String()
+	}
+	return strings.Join(rows, ",")
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	// every matrix up to 4 vertices, including directed edges and self loops
+	for n := 1; n <= 4; n++ {
+		for pattern := uint(0); pattern < 1<<uint(n*n); pattern++ {
+			matrix := matrixString(n, pattern)
+			g, err := pondersolve.ParseMatrix(matrix)
+			if err != nil {
+				t.Fatalf("ParseMatrix(%q): %s", matrix, err)
+			}
+			text, err := g.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText(%s): %s", matrix, err)
+			}
+			if string(text) != matrix {
+				t.Fatalf("MarshalText(%s) = %q", matrix, text)
+			}
+			var parsed pondersolve.Graph
+			if err := parsed.UnmarshalText(text); err != nil {
+				t.Fatalf("UnmarshalText(%q): %s", text, err)
+			}
+			if parsed != g {
+				t.Fatalf("UnmarshalText(%q) gives %s", text, parsed.Matrix())
+			}
+		}
+	}
+}
+
+func TestTextEmpty(t *testing.T) {
+	g, err := pondersolve.ParseMatrix("01,10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.UnmarshalText(nil); err != nil {
+		t.Fatal(err)
+	}
+	if g != (pondersolve.Graph{}) {
+		t.Errorf("UnmarshalText(\"\") gives %d vertices and %d edges", g.Size(), g.EdgeCount())
+	}
+}
+
+func TestTextErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want error
+	}{
+		{"01,1", pondersolve.ErrNotSquare},
+		{"012,100,100", pondersolve.ErrBadCharacter},
+		{"000000000,000000000,000000000,000000000,000000000,000000000,000000000,000000000,000000000", pondersolve.ErrTooLarge},
+	}
+	for _, tt := range tests {
+		var g pondersolve.Graph
+		if err := g.UnmarshalText([]byte(tt.text)); !errors.Is(err, tt.want) {
+			t.Errorf("UnmarshalText(%q): got error %v, want %v", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type result struct {
+		Graph         pondersolve.Graph `json:"graph"`
+		InitialVertex uint8             `json:"initial_vertex"`
+	}
+	in := result{InitialVertex: 2}
+	var err error
+	if in.Graph, err = pondersolve.ParseMatrix("011,101,110"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"graph":"011,101,110","initial_vertex":2}`; string(b) != want {
+		t.Errorf("json.Marshal gives %s, want %s", b, want)
+	}
+	var out result
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out != in {
+		t.Errorf("json.Unmarshal(%s) gives %+v", b, out)
+	}
+}
+
+func TestPermuteErrors(t *testing.T) {
+	g, err := pondersolve.ParseMatrix("011,101,110")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, perm := range [][]uint8{nil, {0, 1}, {0, 1, 2, 3}, {0, 1, 1}, {0, 1, 3}, {2, 2, 2}} {
+		if _, err := g.Permute(perm); !errors.Is(err, pondersolve.ErrNotPermutation) {
+			t.Errorf("Permute(%v): got error %v, want %v", perm, err, pondersolve.ErrNotPermutation)
+		}
+	}
+}
+
+func TestPermute(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		n := uint8(1 + rng.Intn(pondersolve.MaxSize))
+		g := pondersolve.RandomGraph(rng, n, rng.Float64())
+		perm := make([]uint8, n)
+		inverse := make([]uint8, n)
+		for j, v := range rng.Perm(int(n)) {
+			perm[j], inverse[v] = uint8(v), uint8(j)
+		}
+		h, err := g.Permute(perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for a := uint8(0); a < n; a++ {
+			if g.Degree(a) != h.Degree(perm[a]) {
+				t.Fatalf("Permute(%s, %v): vertex %d has degree %d, it had %d as vertex %d", g.Matrix(), perm, perm[a], h.Degree(perm[a]),
+					g.Degree(a), a)
+			}
+			for b := uint8(0); b < n; b++ {
+				if g.HasEdge(a, b) != h.HasEdge(perm[a], perm[b]) {
+					t.Fatalf("Permute(%s, %v) = %s doesn't map edge %d-%d", g.Matrix(), perm, h.Matrix(), a, b)
+				}
+			}
+		}
+		if back, err := h.Permute(inverse); err != nil || back != g {
+			t.Fatalf("Permute(%s, %v) = %s, the inverse permutation gives %s, %v", g.Matrix(), perm, h.Matrix(), back.Matrix(), err)
+		}
+	}
+}
+
+func TestPivot(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		n := uint8(1 + rng.Intn(pondersolve.MaxSize))
+		g := pondersolve.RandomGraph(rng, n, rng.Float64())
+		infected := uint8(rng.Intn(int(n)))
+		h := g
+		h.Pivot(infected)
+		// vertex 0 and the infected vertex swap labels, the other vertices keep theirs
+		label := func(v uint8) uint8 {
+			switch v {
+			case 0:
+				return infected
+			case infected:
+				return 0
+			}
+			return v
+		}
+		for a := uint8(0); a < n; a++ {
+			if g.Degree(a) != h.Degree(label(a)) {
+				t.Fatalf("Pivot(%s, %d): vertex %d has degree %d, it had %d as vertex %d", g.Matrix(), infected, label(a),
+					h.Degree(label(a)), g.Degree(a), a)
+			}
+			for b := uint8(0); b < n; b++ {
+				if g.HasEdge(a, b) != h.HasEdge(label(a), label(b)) {
+					t.Fatalf("Pivot(%s, %d) = %s doesn't swap vertices 0 and %d", g.Matrix(), infected, h.Matrix(), infected)
+				}
+			}
+		}
+		h.Pivot(infected)
+		if h != g {
+			t.Fatalf("pivoting %s twice on vertex %d gives %s", g.Matrix(), infected, h.Matrix())
+		}
+	}
+}
+
+func TestSolutionGraphIsPivoted(t *testing.T) {
+	// the puzzle graph relabeled so that the solution's initial vertex isn't 0
+	puzzle, err := pondersolve.ParseMatrix(puzzleGraph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := puzzle.Permute([]uint8{5, 1, 2, 3, 4, 0, 6, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := pondersolve.Solve(context.Background(), pondersolve.NewSliceSource([]pondersolve.Graph{g}), pondersolve.SolveOptions{
+		Targets:   []float64{0.7},
+		Tolerance: 0.01,
+		Top:       1,
+		MinDays:   30,
+		MaxDays:   30,
+		Rate:      0.1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Results) != 1 || len(summary.Results[0].Best) != 1 {
+		t.Fatalf("got results %v", summary.Results)
+	}
+	sol := summary.Results[0].Best[0]
+	if sol.InitialVertex != 5 || sol.Matrix != g.Matrix() || sol.Graph != puzzle {
+		t.Errorf("got initial vertex %d, matrix %s, pivoted graph %s", sol.InitialVertex, sol.Matrix, sol.Graph.Matrix())
+	}
+	// the graph from the source is reconstructed by pivoting the solution's graph again
+	original := sol.Graph
+	original.Pivot(sol.InitialVertex)
+	if original.Matrix() != sol.Matrix {
+		t.Errorf("pivoting %s on vertex %d gives %s, want %s", sol.Graph.Matrix(), sol.InitialVertex, original.Matrix(), sol.Matrix)
+	}
+}
+
+func TestComplement(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := uint8(1); n <= pondersolve.MaxSize; n++ {
+		empty, err := pondersolve.NewGraph(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		complete := empty.Complement()
+		if want := int(n) * int(n-1) / 2; complete.EdgeCount() != want {
+			t.Errorf("the complement of the empty graph on %d vertices has %d edges, want %d", n, complete.EdgeCount(), want)
+		}
+		if back := complete.Complement(); back != *empty {
+			t.Errorf("the complement of the complete graph on %d vertices is %s", n, back.Matrix())
+		}
+		g := pondersolve.RandomGraph(rng, n, rng.Float64())
+		c := g.Complement()
+		for i := uint8(0); i < n; i++ {
+			for j := uint8(0); j < n; j++ {
+				if c.HasEdge(i, j) == (i != j && !g.HasEdge(i, j)) {
+					continue
+				}
+				t.Fatalf("the complement of %s is %s, edge %d-%d", g.Matrix(), c.Matrix(), i, j)
+			}
+		}
+	}
+}
+
+func TestSelfComplementary(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix string
+	}{
+		{"5-cycle", "01001,10100,01010,00101,10010"},
+		{"path on 4 vertices", "0100,1010,0101,0010"},
+		{"bull graph", "01100,10110,11001,01000,00100"},
+	}
+	for _, tt := range tests {
+		g, err := pondersolve.ParseMatrix(tt.matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := g.Complement()
+		// vertex v of g plays the role of vertex perm[v] of its complement
+		perm, ok := pondersolve.Isomorphism(g, c)
+		if !ok {
+			t.Fatalf("the %s isn't isomorphic to its complement %s", tt.name, c.Matrix())
+		}
+		want, err := g.Compute(context.Background(), 10, 0.1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := c.Compute(context.Background(), 10, 0.1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for v := range want {
+			if math.Abs(got[perm[v]]-want[v]) > 1e-12 {
+				t.Errorf("%s: %g from vertex %d, %g from vertex %d of the complement", tt.name, want[v], v, got[perm[v]], perm[v])
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package pondersolve
+
+import (
+	"context"
+	"fmt"
+)
+
+// ComputeImmune is like Compute, when the immune vertices never get infected. It returns the probability for all the
+// other vertices to be infected: r[i] is the probability when vertex i is initially infected, 0 when vertex i is
+// immune. FirstResultOnly is ignored.
+//
+// Immune vertices never pass the infection on, so this is the probability for the graph without the immune vertices.
+func (g *Graph) ComputeImmune(ctx context.Context, days uint, rate float64, immune []uint8, opts ...Option) ([]float64, error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return nil, err
+	}
+	isImmune := make([]bool, g.size)
+	for _, v := range immune {
+		if v >= g.size {
+			return nil, fmt.Errorf("%w: immune vertex %d, graph has %d vertices", ErrVertexOutOfRange, v, g.size)
+		}
+		isImmune[v] = true
+	}
+	var keep []uint8
+	for v := uint8(0); v < g.size; v++ {
+		if !isImmune[v] {
+			keep = append(keep, v)
+		}
+	}
+	r := make([]float64, g.size)
+	if len(keep) == 0 {
+		return r, nil
+	}
+	sub := g.InducedSubgraph(keep)
+	values, err := sub.Compute(ctx, days, rate, WithAlgorithm(o.algorithm), WithModel(o.model))
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range keep {
+		r[v] = values[i]
+	}
+	return r, nil
+}
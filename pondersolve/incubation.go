@@ -0,0 +1,124 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/teivah/bitvector"
+)
+
+// MaxIncubation is the longest incubation supported by ComputeIncubation, in days.
+const MaxIncubation = 14
+
+// ErrInvalidIncubation is returned by ComputeIncubation.
+var ErrInvalidIncubation = errors.New("incubation is too long")
+
+// In the states of ComputeIncubation, each vertex has 4 bits: 0 when it's susceptible, the number of days left before
+// it becomes infectious when it's exposed, or stageInfectious.
+const stageInfectious = 15
+
+type stagedProbability struct {
+	state       uint64
+	probability float64
+}
+
+// ComputeIncubation is like Compute in the SEI model: a vertex infected on day d is exposed until day d+incubation,
+// and only infects its neighbors from the next day on. Vertex initial is infectious on day 0. infected is the
+// probability that every vertex is exposed or infectious after the given number of days, infectious the probability
+// that every vertex is infectious. With an incubation of 0, infected is Compute's probability. The algorithm option is
+// ignored.
+//
+// The states track how many days each exposed vertex has left, there can be up to (incubation+2)^n of them.
+func (g *Graph) ComputeIncubation(ctx context.Context, days uint, rate float64, incubation uint, initial uint8, opts ...Option) (infected, infectious float64, err error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return 0, 0, err
+	}
+	if incubation > MaxIncubation {
+		return 0, 0, fmt.Errorf("%w: %d days, at most %d are supported", ErrInvalidIncubation, incubation, MaxIncubation)
+	}
+	if initial >= g.size {
+		return 0, 0, fmt.Errorf("%w: initial vertex %d, graph has %d vertices", ErrVertexOutOfRange, initial, g.size)
+	}
+	masks := g.neighborMasks()
+	exposed := uint64(incubation)
+	if incubation == 0 {
+		exposed = stageInfectious
+	}
+
+	// The distribution is kept in the order the states are found rather than in a map, so that the probabilities are
+	// always added up in the same order.
+	current := []stagedProbability{{stageInfectious << (4 * uint(initial)), 1.0}}
+	for day := uint(1); day <= days; day++ {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		var next []stagedProbability
+		index := make(map[uint64]int, len(current))
+		for _, c := range current {
+			state, p := c.state, c.probability
+			// the exposed vertices get one day closer to being infectious, which doesn't depend on chance
+			var spreading bitvector.Len8
+			base := uint64(0)
+			for v := uint8(0); v < g.size; v++ {
+				switch stage := state >> (4 * uint(v)) & 15; {
+				case stage == stageInfectious:
+					spreading = spreading.Set(v, true)
+					base |= stageInfectious << (4 * uint(v))
+				case stage == 1:
+					base |= stageInfectious << (4 * uint(v))
+				case stage > 1:
+					base |= (stage - 1) << (4 * uint(v))
+				}
+			}
+			// susceptible vertices get exposed by the neighbors which were infectious at the start of the day
+			nextStates := []stagedProbability{{base, p}}
+			for v := uint8(0); v < g.size; v++ {
+				k := bits.OnesCount8(uint8(masks[v] & spreading))
+				if state>>(4*uint(v))&15 != 0 || k == 0 {
+					continue
+				}
+				isInfected, escaped := infectionProbabilities(o.model, k, rate)
+				var expanded []stagedProbability
+				for _, s := range nextStates {
+					if escaped != 0 {
+						expanded = append(expanded, stagedProbability{s.state, s.probability * escaped})
+					}
+					if isInfected != 0 {
+						expanded = append(expanded, stagedProbability{s.state | exposed<<(4*uint(v)), s.probability * isInfected})
+					}
+				}
+				nextStates = expanded
+			}
+			for _, s := range nextStates {
+				i, ok := index[s.state]
+				if !ok {
+					i = len(next)
+					index[s.state] = i
+					next = append(next, stagedProbability{state: s.state})
+				}
+				next[i].probability += s.probability
+			}
+		}
+		current = next
+	}
+
+	for _, c := range current {
+		state, p := c.state, c.probability
+		allInfected, allInfectious := true, true
+		for v := uint8(0); v < g.size; v++ {
+			stage := state >> (4 * uint(v)) & 15
+			allInfected = allInfected && stage != 0
+			allInfectious = allInfectious && stage == stageInfectious
+		}
+		if allInfected {
+			infected += p
+		}
+		if allInfectious {
+			infectious += p
+		}
+	}
+	return infected, infectious, nil
+}
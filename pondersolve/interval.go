@@ -0,0 +1,166 @@
+package pondersolve
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/teivah/bitvector"
+)
+
+// Interval is a guaranteed enclosure [Lo, Hi] of a probability, despite floating point rounding errors.
+type Interval struct {
+	Lo float64
+	Hi float64
+}
+
+// Width returns Hi - Lo.
+func (a Interval) Width() float64 {
+	return a.Hi - a.Lo
+}
+
+// Contains returns whether x is in the interval.
+func (a Interval) Contains(x float64) bool {
+	return a.Lo <= x && x <= a.Hi
+}
+
+// Classification of an interval with respect to a target and tolerance.
+type Classification int
+
+const (
+	Outside   Classification = iota // every value of the interval is at least tolerance away from the target
+	Within                          // every value of the interval is less than tolerance away from the target
+	Undecided                       // the interval straddles a bound of (target - tolerance, target + tolerance)
+)
+
+// Classify returns whether the interval is within tolerance of the target, outside of it, or both.
+func (a Interval) Classify(target, tolerance float64) Classification {
+	// the bounds are rounded outward, so that rounding never turns an undecided interval into a decided one
+	low := math.Nextafter(target-tolerance, math.Inf(1))
+	high := math.Nextafter(target+tolerance, math.Inf(-1))
+	switch {
+	case a.Lo > low && a.Hi < high:
+		return Within
+	case a.Hi <= math.Nextafter(target-tolerance, math.Inf(-1)) || a.Lo >= math.Nextafter(target+tolerance, math.Inf(1)):
+		return Outside
+	default:
+		return Undecided
+	}
+}
+
+// Operations round outward by one ulp, which is enough since the IEEE 754 operations are correctly rounded. The
+// operands are probabilities, so the bounds are clamped to [0, 1].
+func (a Interval) add(b Interval) Interval {
+	return Interval{roundDown(a.Lo + b.Lo), roundUp(a.Hi + b.Hi)}
+}
+
+func (a Interval) mul(b Interval) Interval {
+	return Interval{roundDown(a.Lo * b.Lo), roundUp(a.Hi * b.Hi)}
+}
+
+func (a Interval) complement() Interval {
+	return Interval{roundDown(1 - a.Hi), roundUp(1 - a.Lo)}
+}
+
+func roundDown(x float64) float64 {
+	return math.Max(math.Nextafter(x, math.Inf(-1)), 0)
+}
+
+func roundUp(x float64) float64 {
+	return math.Min(math.Nextafter(x, math.Inf(1)), 1)
+}
+
+type stateInterval struct {
+	state       bitvector.Len8
+	probability Interval
+}
+
+// Same as enumerateNextStates, with interval probabilities.
+func (g *Graph) enumerateNextStateIntervals(masks *neighborMasks, state bitvector.Len8, rate Interval, index uint8) []stateInterval {
+	if index == g.size {
+		return []stateInterval{{state: state, probability: Interval{1, 1}}}
+	}
+	r := g.enumerateNextStateIntervals(masks, state, rate, index+1)
+	infected := bits.OnesCount8(uint8(masks[index] & state))
+	if state.Get(index) || infected == 0 {
+		return r
+	}
+	// (1-rate)^infected, one multiplication at a time so that each one is rounded outward
+	p := rate.complement()
+	for k := 1; k < infected; k++ {
+		p = p.mul(rate.complement())
+	}
+	notP := p.complement()
+	var r2 []stateInterval
+	for _, s := range r {
+		r2 = append(r2, stateInterval{state: s.state, probability: s.probability.mul(p)})
+		r2 = append(r2, stateInterval{state: s.state.Set(index, true), probability: s.probability.mul(notP)})
+	}
+	return r2
+}
+
+// ComputeInterval is like Compute, returning intervals which are guaranteed to contain the exact probabilities. The
+// rate is widened to the neighboring floats, so that the intervals also contain the probabilities for the decimal
+// rate the float was parsed from, e.g. exactly 1/10 for 0.1. The algorithm option is ignored, and only the Independent
+// model is supported, like ComputePolynomial.
+func (g *Graph) ComputeInterval(ctx context.Context, days uint, rate float64, opts ...Option) ([]Interval, error) {
+	r, err := g.ComputeIntervalDays(ctx, days, days, rate, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return r[0], nil
+}
+
+// ComputeIntervalDays is like ComputeInterval, for every number of days in [minDays, maxDays], see ComputeDays.
+func (g *Graph) ComputeIntervalDays(ctx context.Context, minDays, maxDays uint, rate float64, opts ...Option) ([][]Interval, error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIndependent(o.model); err != nil {
+		return nil, err
+	}
+	if minDays > maxDays {
+		return nil, fmt.Errorf("%w: %d > %d", ErrInvalidDays, minDays, maxDays)
+	}
+	rateInterval := Interval{roundDown(rate), roundUp(rate)}
+	masks := g.neighborMasks()
+	lastState := (1 << g.size) - 1
+	m := make([][]stateInterval, lastState+1)
+	for state := 0; state <= lastState; state++ {
+		m[state] = g.enumerateNextStateIntervals(masks, bitvector.Len8(state), rateInterval, 0)
+	}
+
+	var r [][]Interval
+	var previous [256]Interval
+	previous[lastState] = Interval{1, 1}
+	for i := uint(0); i <= maxDays; i++ {
+		if i > 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			var current [256]Interval
+			for state := 0; state <= lastState; state++ {
+				var p Interval
+				for _, nextState := range m[state] {
+					p = p.add(nextState.probability.mul(previous[nextState.state]))
+				}
+				current[state] = p
+			}
+			previous = current
+		}
+		if i < minDays {
+			continue
+		}
+		var values []Interval
+		for v := uint8(0); v < g.size; v++ {
+			values = append(values, previous[1<<v])
+			if o.firstResultOnly {
+				break
+			}
+		}
+		r = append(r, values)
+	}
+	return r, nil
+}
@@ -0,0 +1,85 @@
+package pondersolve
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// Checks that the exact value is within the interval, comparing rationals since the exact value isn't a float64.
+func containsExact(a Interval, exact *big.Rat) bool {
+	lo, hi := new(big.Rat).SetFloat64(a.Lo), new(big.Rat).SetFloat64(a.Hi)
+	return lo.Cmp(exact) <= 0 && exact.Cmp(hi) <= 0
+}
+
+func TestIntervalPuzzle(t *testing.T) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intervals, err := g.ComputeInterval(context.Background(), 30, 0.1, FirstResultOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	polynomials, err := g.ComputePolynomial(context.Background(), 30, 1000, FirstResultOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exact := polynomials[0].Eval(big.NewRat(1, 10))
+	if a := intervals[0]; a.Width() >= 1e-10 || !containsExact(a, exact) {
+		t.Errorf("got [%.20g, %.20g] of width %g, the exact value is %s", a.Lo, a.Hi, a.Width(), exact.FloatString(20))
+	}
+}
+
+func TestIntervalContainsExact(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 30; i++ {
+		g := RandomGraph(rng, uint8(1+rng.Intn(6)), rng.Float64())
+		// the exact value is computed at the float64 rate, not at the decimal it approximates
+		rateFloat := float64(1+rng.Intn(99)) / 100
+		rate := new(big.Rat).SetFloat64(rateFloat)
+		days := uint(rng.Intn(10))
+		intervals, err := g.ComputeInterval(context.Background(), days, rateFloat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		polynomials, err := g.ComputePolynomial(context.Background(), days, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := g.Compute(context.Background(), days, rateFloat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for v, a := range intervals {
+			if exact := polynomials[v].Eval(rate); !containsExact(a, exact) || !a.Contains(r[v]) || a.Lo < 0 || a.Hi > 1 {
+				t.Errorf("%s, rate %g, %d days, vertex %d: [%g, %g] doesn't contain %s or %g", g.Matrix(), rateFloat, days, v, a.Lo, a.Hi,
+					exact.FloatString(20), r[v])
+			}
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		interval Interval
+		want     Classification
+	}{
+		{Interval{0.69, 0.71}, Within},
+		{Interval{0.5, 0.59}, Outside},
+		{Interval{0.8, 0.9}, Outside},
+		{Interval{0.5, 0.9}, Undecided},
+		{Interval{0.55, 0.65}, Undecided},
+		{Interval{0.75, 0.85}, Undecided},
+		{Interval{0.7, 0.7}, Within},
+		// target-tolerance is rounded, an interval which ends on it could be within tolerance
+		{Interval{math.Nextafter(0.6, 0), 0.6}, Undecided},
+	}
+	for _, tt := range tests {
+		if got := tt.interval.Classify(0.7, 0.1); got != tt.want {
+			t.Errorf("Classify([%g, %g], 0.7, 0.1) = %d, want %d", tt.interval.Lo, tt.interval.Hi, got, tt.want)
+		}
+	}
+}
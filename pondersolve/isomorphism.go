@@ -0,0 +1,86 @@
+package pondersolve
+
+import "sort"
+
+// Isomorphic checks whether b is a relabeling of a.
+func Isomorphic(a, b Graph) bool {
+	_, ok := Isomorphism(a, b)
+	return ok
+}
+
+// Isomorphism returns a permutation mapping a onto b when the graphs are isomorphic: vertex i of a is vertex perm[i]
+// of b. Graphs with different degree sequences are rejected without any search.
+func Isomorphism(a, b Graph) (perm []uint8, ok bool) {
+	if a.size != b.size || a.vertices.Count() != b.vertices.Count() {
+		return nil, false
+	}
+	degreesA, degreesB := make([]int, a.size), make([]int, b.size)
+	for i := uint8(0); i < a.size; i++ {
+		degreesA[i], degreesB[i] = a.Degree(i), b.Degree(i)
+	}
+	sortedA, sortedB := append([]int(nil), degreesA...), append([]int(nil), degreesB...)
+	sort.Ints(sortedA)
+	sort.Ints(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return nil, false
+		}
+	}
+
+	isomorphisms(a, b, degreesA, degreesB, func(p []uint8) bool {
+		perm = append([]uint8(nil), p...)
+		return false
+	})
+	return perm, perm != nil
+}
+
+// Automorphisms returns every relabeling which maps g onto itself, starting with the identity: vertex i becomes vertex
+// perm[i]. Symmetric graphs have many, e.g. 8! for the complete graph on 8 vertices.
+func (g *Graph) Automorphisms() [][]uint8 {
+	degrees := make([]int, g.size)
+	for i := uint8(0); i < g.size; i++ {
+		degrees[i] = g.Degree(i)
+	}
+	var r [][]uint8
+	isomorphisms(*g, *g, degrees, degrees, func(perm []uint8) bool {
+		r = append(r, append([]uint8(nil), perm...))
+		return true
+	})
+	return r
+}
+
+// Calls fn with every permutation mapping a onto b, in lexicographic order, until fn returns false. The permutation is
+// only valid until fn returns.
+//
+// The search tries every mapping between vertices of the same degree, checking edges as soon as both their vertices
+// are mapped.
+func isomorphisms(a, b Graph, degreesA, degreesB []int, fn func(perm []uint8) bool) {
+	perm := make([]uint8, a.size)
+	used := make([]bool, b.size)
+	var assign func(i uint8) bool
+	assign = func(i uint8) bool {
+		if i == a.size {
+			return fn(perm)
+		}
+		for v := uint8(0); v < b.size; v++ {
+			if used[v] || degreesA[i] != degreesB[v] || a.HasEdge(i, i) != b.HasEdge(v, v) {
+				continue
+			}
+			consistent := true
+			for j := uint8(0); j < i && consistent; j++ {
+				consistent = a.HasEdge(i, j) == b.HasEdge(v, perm[j]) && a.HasEdge(j, i) == b.HasEdge(perm[j], v)
+			}
+			if !consistent {
+				continue
+			}
+			used[v] = true
+			perm[i] = v
+			if !assign(i + 1) {
+				return false
+			}
+			used[v] = false
+		}
+		return true
+	}
+	assign(0)
+}
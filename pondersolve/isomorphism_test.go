@@ -0,0 +1,111 @@
+package pondersolve
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func mustParseMatrix(t *testing.T, matrix string) Graph {
+	t.Helper()
+	g, err := ParseMatrix(matrix)
+	if err != nil {
+		t.Fatalf("ParseMatrix(%q): %s", matrix, err)
+	}
+	return g
+}
+
+func TestIsomorphismOfPermutation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		n := uint8(1 + rng.Intn(MaxSize))
+		var g Graph
+		if i%2 == 0 {
+			g = RandomGraph(rng, n, rng.Float64())
+		} else {
+			g = randomDirectedGraph(rng, n)
+		}
+		perm := make([]uint8, n)
+		for j, v := range rng.Perm(int(n)) {
+			perm[j] = uint8(v)
+		}
+		h := g.permute(perm)
+		witness, ok := Isomorphism(g, h)
+		if !ok {
+			t.Fatalf("Isomorphism(%s, %s) = false, the second graph is a relabeling of the first", g.Matrix(), h.Matrix())
+		}
+		if got := g.permute(witness); got != h {
+			t.Fatalf("Isomorphism(%s, %s) returned %v which maps the first graph onto %s", g.Matrix(), h.Matrix(), witness, got.Matrix())
+		}
+	}
+}
+
+func TestNotIsomorphic(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"6-cycle and two triangles", "010001,101000,010100,001010,000101,100010", "011000,101000,110000,000011,000101,000110"},
+		{"8-cycle and two 4-cycles", "01000001,10100000,01010000,00101000,00010100,00001010,00000101,10000010", "01010000,10100000,01010000,10100000,00000101,00001010,00000101,00001010"},
+		{"directed 3-cycle and transitive triangle", "010,001,100", "011,001,000"},
+		{"different sizes", "01,10", "010,100,000"},
+	}
+	for _, tt := range tests {
+		a, b := mustParseMatrix(t, tt.a), mustParseMatrix(t, tt.b)
+		if perm, ok := Isomorphism(a, b); ok {
+			t.Errorf("%s: Isomorphism(%s, %s) = %v", tt.name, tt.a, tt.b, perm)
+		}
+	}
+}
+
+func TestIsomorphicMatchesBruteForce(t *testing.T) {
+	// every pair of graphs with up to 4 vertices: isomorphic graphs have the same brute force canonical form
+	for n := uint8(1); n <= 4; n++ {
+		var graphs, forms []Graph
+		for pattern := uint64(0); pattern < 1<<(uint(n)*uint(n-1)/2); pattern++ {
+			g := FromUpperTriangle(n, pattern)
+			graphs, forms = append(graphs, g), append(forms, bruteForceCanonical(&g))
+		}
+		for i := range graphs {
+			for j := range graphs {
+				if got, want := Isomorphic(graphs[i], graphs[j]), forms[i] == forms[j]; got != want {
+					t.Fatalf("Isomorphic(%s, %s) = %t, want %t", graphs[i].Matrix(), graphs[j].Matrix(), got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestAutomorphisms(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix string
+		want   int
+	}{
+		{"single vertex", "0", 1},
+		{"empty graph on 4 vertices", "0000,0000,0000,0000", 24},
+		{"complete graph on 5 vertices", "01111,10111,11011,11101,11110", 120},
+		{"path on 4 vertices", "0100,1010,0101,0010", 2},
+		{"star on 5 vertices", "01111,10000,10000,10000,10000", 24},
+		{"6-cycle", "010001,101000,010100,001010,000101,100010", 12},
+		{"directed 3-cycle", "010,001,100", 3},
+		{"puzzle graph", "00001100,00001011,00000110,00000010,11000101,10101001,01110001,01001110", 1},
+	}
+	for _, tt := range tests {
+		g := mustParseMatrix(t, tt.matrix)
+		automorphisms := g.Automorphisms()
+		if len(automorphisms) != tt.want {
+			t.Errorf("%s has %d automorphisms, want %d", tt.name, len(automorphisms), tt.want)
+		}
+		for i, perm := range automorphisms {
+			if got := g.permute(perm); got != g {
+				t.Errorf("%s: %v isn't an automorphism", tt.name, perm)
+			}
+			for v, p := range perm {
+				if i == 0 && p != uint8(v) {
+					t.Errorf("%s: the first automorphism is %v, not the identity", tt.name, perm)
+					break
+				}
+			}
+		}
+	}
+}
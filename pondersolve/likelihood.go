@@ -0,0 +1,44 @@
+package pondersolve
+
+import (
+	"fmt"
+
+	"github.com/teivah/bitvector"
+)
+
+// TransitionProbability returns the probability of moving from one state to another in a single day. Bit i of a state
+// is set when vertex i is infected. Infected vertices stay infected, so the probability is 0 unless to contains from.
+func (g *Graph) TransitionProbability(rate float64, from, to int) (float64, error) {
+	if _, err := newOptions(rate, nil); err != nil {
+		return 0, err
+	}
+	for _, state := range []int{from, to} {
+		if state < 0 || state >= 1<<g.size {
+			return 0, fmt.Errorf("%w: state %b, graph has %d vertices", ErrVertexOutOfRange, state, g.size)
+		}
+	}
+	p := 0.0
+	for _, next := range g.enumerateNextStates(g.neighborMasks(), bitvector.Len8(from), rate, Independent{}, 0) {
+		if int(next.state) == to {
+			p += next.probability
+		}
+	}
+	return p, nil
+}
+
+// TransitionMatrix returns the one-day transition matrix: m[from][to] is the probability of moving from state from to
+// state to, with states numbered like in TransitionProbability. The matrix has 2^n rows of 2^n entries.
+func (g *Graph) TransitionMatrix(rate float64) ([][]float64, error) {
+	if _, err := newOptions(rate, nil); err != nil {
+		return nil, err
+	}
+	masks := g.neighborMasks()
+	m := make([][]float64, 1<<g.size)
+	for from := range m {
+		m[from] = make([]float64, 1<<g.size)
+		for _, next := range g.enumerateNextStates(masks, bitvector.Len8(from), rate, Independent{}, 0) {
+			m[from][next.state] += next.probability
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,128 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNeverReached is returned by DaysToReach when the probability never reaches the threshold, e.g. in a
+// disconnected graph.
+var ErrNeverReached = errors.New("the probability never reaches the threshold")
+
+// Largest number of squarings done by DaysToReach, which keeps the number of days within a uint64.
+const maxSquarings = 62
+
+// DaysToReach returns the smallest number of days after which every vertex is infected with probability at least
+// threshold, when vertex initial is infected on day 0. Rather than iterating day by day, the transition matrix is
+// squared until the threshold is reached, and the number of days is then found by binary search among the powers of
+// the matrix, in O(log(days)) products.
+func (g *Graph) DaysToReach(ctx context.Context, rate float64, initial uint8, threshold float64) (uint64, error) {
+	if initial >= g.size {
+		return 0, fmt.Errorf("%w: initial vertex %d, graph has %d vertices", ErrVertexOutOfRange, initial, g.size)
+	}
+	if !(threshold > 0 && threshold <= 1) {
+		return 0, fmt.Errorf("invalid threshold %g, expecting a probability in (0, 1]", threshold)
+	}
+	m, err := g.TransitionMatrix(rate)
+	if err != nil {
+		return 0, err
+	}
+	last := len(m) - 1
+	start := make([]float64, len(m))
+	start[1<<initial] = 1.0
+	if start[last] >= threshold {
+		return 0, nil
+	}
+	if !g.Connected() || rate == 0 {
+		return 0, ErrNeverReached
+	}
+
+	// powers[k] is m^(2^k)
+	powers := [][][]float64{m}
+	for k := 0; multiplyVector(start, powers[k])[last] < threshold; k++ {
+		if k == maxSquarings {
+			return 0, ErrNeverReached
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		powers = append(powers, multiplyMatrices(powers[k], powers[k]))
+	}
+	// the largest number of days below the threshold is built one bit at a time, the answer is the next day
+	days := uint64(0)
+	current := start
+	for k := len(powers) - 2; k >= 0; k-- {
+		next := multiplyVector(current, powers[k])
+		if next[last] < threshold {
+			current = next
+			days += 1 << uint(k)
+		}
+	}
+	return days + 1, nil
+}
+
+// Stops Evolve once DaysToTarget found the number of days.
+var errTargetReached = errors.New("target reached")
+
+// DaysToTarget is like DaysToReach, moving the distribution of states forward one day at a time with Evolve: each day
+// reuses the previous one, so the answer costs one DP step per day. It returns the number of days along with the
+// probability after them, and gives up with ErrNeverReached after maxDays.
+func (g *Graph) DaysToTarget(ctx context.Context, rate float64, initial uint8, target float64, maxDays uint) (uint, float64, error) {
+	if initial >= g.size {
+		return 0, 0, fmt.Errorf("%w: initial vertex %d, graph has %d vertices", ErrVertexOutOfRange, initial, g.size)
+	}
+	if !(target > 0 && target <= 1) {
+		return 0, 0, fmt.Errorf("invalid target %g, expecting a probability in (0, 1]", target)
+	}
+	last := (1 << g.size) - 1
+	if 1<<initial == last {
+		return 0, 1.0, nil
+	}
+	// the infection only reaches the vertices which can be reached from initial, following the edges
+	for _, d := range g.distances(initial) {
+		if d < 0 || rate == 0 {
+			return 0, 0, ErrNeverReached
+		}
+	}
+	var days uint
+	var p float64
+	err := g.Evolve(ctx, 1<<initial, rate, maxDays, func(day uint, distribution []float64) error {
+		days, p = day, distribution[last]
+		if p >= target {
+			return errTargetReached
+		}
+		return nil
+	})
+	switch {
+	case err == errTargetReached:
+		return days, p, nil
+	case err != nil:
+		return 0, 0, err
+	}
+	return 0, 0, fmt.Errorf("%w within %d days, it is only %g by then", ErrNeverReached, maxDays, p)
+}
+
+// Returns the row vector v multiplied by m.
+func multiplyVector(v []float64, m [][]float64) []float64 {
+	r := make([]float64, len(v))
+	for i, p := range v {
+		if p == 0 {
+			continue
+		}
+		for j, q := range m[i] {
+			r[j] += p * q
+		}
+	}
+	return r
+}
+
+// Returns a times b, skipping the zero entries of a: transition matrices are mostly zeros, since infected vertices
+// stay infected.
+func multiplyMatrices(a, b [][]float64) [][]float64 {
+	r := make([][]float64, len(a))
+	for i := range a {
+		r[i] = multiplyVector(a[i], b)
+	}
+	return r
+}
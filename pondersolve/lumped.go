@@ -0,0 +1,132 @@
+package pondersolve
+
+import (
+	"context"
+
+	"github.com/teivah/bitvector"
+)
+
+// Returns the orbit of every state under the automorphisms of g: orbit[state] is the smallest state the automorphisms
+// map state to. States in the same orbit have the same probabilities, and their transitions are relabelings of each
+// other.
+func (g *Graph) stateOrbits() []bitvector.Len8 {
+	states := 1 << g.size
+	orbit := make([]bitvector.Len8, states)
+	for state := range orbit {
+		orbit[state] = bitvector.Len8(state)
+	}
+	for _, perm := range g.Automorphisms()[1:] {
+		// the image of a state is the union of the images of its low and high nibbles
+		var low, high [16]bitvector.Len8
+		for nibble := 0; nibble < 16; nibble++ {
+			for bit := uint8(0); bit < 4; bit++ {
+				if nibble&(1<<bit) == 0 {
+					continue
+				}
+				if bit < g.size {
+					low[nibble] = low[nibble].Set(perm[bit], true)
+				}
+				if bit+4 < g.size {
+					high[nibble] = high[nibble].Set(perm[bit+4], true)
+				}
+			}
+		}
+		for state := range orbit {
+			if image := low[state&15] | high[state>>4]; image < orbit[state] {
+				orbit[state] = image
+			}
+		}
+	}
+	return orbit
+}
+
+type stateOrbit struct {
+	state bitvector.Len8
+	orbit int
+}
+
+// Same as dpTable, over the orbits of the states under the automorphisms of g instead of the states themselves, see
+// Lumped. The rows have an entry for every state, like dpTable's.
+func (g *Graph) lumpedTable(ctx context.Context, masks *neighborMasks, minDays, maxDays uint, rate float64, model TransmissionModel, initial []bitvector.Len8) ([][256]float64, error) {
+	orbit := g.stateOrbits()
+
+	// Like dpTable, the orbits reachable from the initial states are numbered in the order they are found. The
+	// transitions of an orbit are those of its smallest state, added up by next orbit.
+	type transition struct {
+		next        int
+		probability float64
+	}
+	index := make(map[bitvector.Len8]int)
+	var orbits []bitvector.Len8
+	for _, state := range initial {
+		if _, ok := index[orbit[state]]; !ok {
+			index[orbit[state]] = len(orbits)
+			orbits = append(orbits, orbit[state])
+		}
+	}
+	m := make([][]transition, 0, len(orbits))
+	for i := 0; i < len(orbits); i++ {
+		var transitions []transition
+		position := make(map[int]int)
+		for _, nextState := range g.enumerateNextStates(masks, orbits[i], rate, model, 0) {
+			nextOrbit := orbit[nextState.state]
+			next, ok := index[nextOrbit]
+			if !ok {
+				next = len(orbits)
+				index[nextOrbit] = next
+				orbits = append(orbits, nextOrbit)
+			}
+			if k, ok := position[next]; ok {
+				transitions[k].probability += nextState.probability
+				continue
+			}
+			position[next] = len(transitions)
+			transitions = append(transitions, transition{next, nextState.probability})
+		}
+		m = append(m, transitions)
+	}
+
+	// the number of the orbit of each reachable state
+	var members []stateOrbit
+	for state := range orbit {
+		if i, ok := index[orbit[state]]; ok {
+			members = append(members, stateOrbit{bitvector.Len8(state), i})
+		}
+	}
+	probs := make([][256]float64, 0, maxDays-minDays+1)
+	row := func(values []float64) [256]float64 {
+		var r [256]float64
+		for _, member := range members {
+			r[member.state] = values[member.orbit]
+		}
+		return r
+	}
+
+	// the last state is alone in its orbit
+	lastState := bitvector.Len8((1 << g.size) - 1)
+	previous := make([]float64, len(orbits))
+	if last, ok := index[lastState]; ok {
+		previous[last] = 1.0
+	}
+	if minDays == 0 {
+		probs = append(probs, row(previous))
+	}
+	current := make([]float64, len(orbits))
+	for i := uint(1); i <= maxDays; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for o, transitions := range m {
+			p := 0.0
+			for _, t := range transitions {
+				p += t.probability * previous[t.next]
+			}
+			current[o] = p
+		}
+		previous, current = current, previous
+		if i >= minDays {
+			probs = append(probs, row(previous))
+		}
+	}
+	return probs, nil
+}
@@ -0,0 +1,69 @@
+package pondersolve
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestLumpedMatchesDP(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix string
+		orbits int // number of distinct orbits of states
+	}{
+		{"complete graph", "01111111,10111111,11011111,11101111,11110111,11111011,11111101,11111110", 9},
+		{"8-cycle", "01000001,10100000,01010000,00101000,00010100,00001010,00000101,10000010", 30},
+		{"puzzle graph", testPuzzleMatrix, 256},
+	}
+	for _, tt := range tests {
+		g, err := ParseMatrix(tt.matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orbits := make(map[uint8]bool)
+		for _, orbit := range g.stateOrbits() {
+			orbits[uint8(orbit)] = true
+		}
+		if len(orbits) != tt.orbits {
+			t.Errorf("%s: %d orbits, want %d", tt.name, len(orbits), tt.orbits)
+		}
+		for _, model := range []TransmissionModel{Independent{}, Linear{}, Threshold{Neighbors: 2}} {
+			for _, rate := range []float64{0.01, 0.1, 0.5, 0.99} {
+				want, err := g.ComputeDays(context.Background(), 0, 60, rate, WithAlgorithm(DP), WithModel(model))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := g.ComputeDays(context.Background(), 0, 60, rate, WithAlgorithm(Lumped), WithModel(model))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for d := range want {
+					for v := range want[d] {
+						if math.Abs(got[d][v]-want[d][v]) > 1e-12 {
+							t.Fatalf("%s with %s, rate %g: lumped gives %g after %d days from vertex %d, dp %g", tt.name, model, rate, got[d][v], d,
+								v, want[d][v])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// The complete graph on 8 vertices over a long day count, which pays for finding its 8! automorphisms.
+func BenchmarkComputeLumped(b *testing.B) {
+	complete, err := ParseMatrix("01111111,10111111,11011111,11101111,11110111,11111011,11111101,11111110")
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, algorithm := range []Algorithm{DP, Lumped} {
+		b.Run(string(algorithm), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := complete.Compute(context.Background(), 100000, 0.1, WithAlgorithm(algorithm)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
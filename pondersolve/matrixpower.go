@@ -0,0 +1,99 @@
+package pondersolve
+
+import (
+	"context"
+	"math"
+
+	"github.com/teivah/bitvector"
+)
+
+// Same as dpTable, raising the transition matrix to the power minDays by repeated squaring instead of iterating day by
+// day, see MatrixPower. The days after minDays are then computed one at a time, like dpTable.
+func (g *Graph) matrixPowerTable(ctx context.Context, masks *neighborMasks, minDays, maxDays uint, rate float64, model TransmissionModel, initial []bitvector.Len8) ([][256]float64, error) {
+	lastState := (1 << g.size) - 1
+	if initial == nil {
+		for state := 0; state <= lastState; state++ {
+			initial = append(initial, bitvector.Len8(state))
+		}
+	}
+
+	// Like dpTable, the matrix only has rows and columns for the states reachable from initial, numbered in the order
+	// they are found.
+	index := make(map[bitvector.Len8]int)
+	var states []bitvector.Len8
+	for _, state := range initial {
+		if _, ok := index[state]; !ok {
+			index[state] = len(states)
+			states = append(states, state)
+		}
+	}
+	var transitions [][]stateProbability
+	for i := 0; i < len(states); i++ {
+		nextStates := g.enumerateNextStates(masks, states[i], rate, model, 0)
+		for _, nextState := range nextStates {
+			if _, ok := index[nextState.state]; !ok {
+				index[nextState.state] = len(states)
+				states = append(states, nextState.state)
+			}
+		}
+		transitions = append(transitions, nextStates)
+	}
+	m := make([][]float64, len(states))
+	for i := range m {
+		m[i] = make([]float64, len(states))
+		for _, t := range transitions[i] {
+			m[i][index[t.state]] += t.probability
+		}
+	}
+
+	probs := make([][256]float64, 0, maxDays-minDays+1)
+	// the rounding errors of the products can add up to slightly more than 1
+	row := func(values []float64) [256]float64 {
+		var r [256]float64
+		for i, state := range states {
+			r[state] = math.Min(values[i], 1.0)
+		}
+		return r
+	}
+
+	// The probabilities after d days are the column m^d times the base case. m^minDays is the product of the powers
+	// m^(2^k) for the bits k of minDays, which commute with each other.
+	current := make([]float64, len(states))
+	if last, ok := index[bitvector.Len8(lastState)]; ok {
+		current[last] = 1.0
+	}
+	power := m
+	for days := minDays; days > 0; days >>= 1 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if days&1 == 1 {
+			current = multiplyColumn(power, current)
+		}
+		if days > 1 {
+			power = multiplyMatrices(power, power)
+		}
+	}
+	probs = append(probs, row(current))
+	for i := minDays + 1; i <= maxDays; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		current = multiplyColumn(m, current)
+		probs = append(probs, row(current))
+	}
+	return probs, nil
+}
+
+// Returns m multiplied by the column vector v.
+func multiplyColumn(m [][]float64, v []float64) []float64 {
+	r := make([]float64, len(m))
+	for i, values := range m {
+		p := 0.0
+		for j, q := range values {
+			p += q * v[j]
+		}
+		r[i] = p
+	}
+	return r
+}
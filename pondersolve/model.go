@@ -0,0 +1,110 @@
+package pondersolve
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TransmissionModel decides how a vertex's infected neighbors combine into its probability of getting infected on the
+// next day.
+type TransmissionModel interface {
+	// InfectionProbability returns the probability that a vertex with this many infected neighbors, at least 1, gets
+	// infected on the next day. It must be between 0 and 1.
+	InfectionProbability(infectedNeighbors int, rate float64) float64
+}
+
+// Models which compute the probability of not getting infected directly, more precisely than 1 minus the probability
+// of getting infected.
+type escapeModel interface {
+	escapeProbability(infectedNeighbors int, rate float64) float64
+}
+
+// Independent is the default model: each infected neighbor passes the infection on independently with probability
+// rate, so a vertex gets infected with probability 1 - (1-rate)^k.
+type Independent struct{}
+
+// InfectionProbability implements TransmissionModel.
+func (Independent) InfectionProbability(infectedNeighbors int, rate float64) float64 {
+	return 1.0 - math.Pow(1.0-rate, float64(infectedNeighbors))
+}
+
+func (Independent) escapeProbability(infectedNeighbors int, rate float64) float64 {
+	return math.Pow(1.0-rate, float64(infectedNeighbors))
+}
+
+func (Independent) String() string {
+	return "independent"
+}
+
+// Linear saturates: a vertex gets infected with probability min(1, rate·k).
+type Linear struct{}
+
+// InfectionProbability implements TransmissionModel.
+func (Linear) InfectionProbability(infectedNeighbors int, rate float64) float64 {
+	return math.Min(1.0, rate*float64(infectedNeighbors))
+}
+
+func (Linear) String() string {
+	return "linear"
+}
+
+// Threshold ignores the rate: a vertex gets infected as soon as at least Neighbors of its neighbors are infected.
+type Threshold struct {
+	Neighbors int
+}
+
+// InfectionProbability implements TransmissionModel.
+func (t Threshold) InfectionProbability(infectedNeighbors int, rate float64) float64 {
+	if infectedNeighbors >= t.Neighbors {
+		return 1.0
+	}
+	return 0.0
+}
+
+func (t Threshold) String() string {
+	return fmt.Sprintf("threshold:%d", t.Neighbors)
+}
+
+// ErrUnknownModel is returned by ParseModel.
+var ErrUnknownModel = errors.New("unknown transmission model")
+
+// ErrUnsupportedModel is returned by the computations which only support the Independent model.
+var ErrUnsupportedModel = errors.New("only the independent transmission model is supported")
+
+// ParseModel returns the model for "independent", "linear" or "threshold:t", with t at least 1.
+func ParseModel(spec string) (TransmissionModel, error) {
+	switch {
+	case spec == "independent":
+		return Independent{}, nil
+	case spec == "linear":
+		return Linear{}, nil
+	case strings.HasPrefix(spec, "threshold:"):
+		t, err := strconv.Atoi(strings.TrimPrefix(spec, "threshold:"))
+		if err != nil || t < 1 {
+			return nil, fmt.Errorf("%w: %s, the threshold must be a number of neighbors of at least 1", ErrUnknownModel, spec)
+		}
+		return Threshold{t}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownModel, spec)
+	}
+}
+
+// Returns the probabilities of getting infected and of escaping the infection.
+func infectionProbabilities(model TransmissionModel, infectedNeighbors int, rate float64) (infected, escaped float64) {
+	infected = model.InfectionProbability(infectedNeighbors, rate)
+	if m, ok := model.(escapeModel); ok {
+		return infected, m.escapeProbability(infectedNeighbors, rate)
+	}
+	return infected, 1.0 - infected
+}
+
+// Checks that the model is Independent, for the computations which depend on its formula.
+func checkIndependent(model TransmissionModel) error {
+	if _, ok := model.(Independent); !ok {
+		return fmt.Errorf("%w, got %v", ErrUnsupportedModel, model)
+	}
+	return nil
+}
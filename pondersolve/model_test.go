@@ -0,0 +1,121 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// The graph from the puzzle statement.
+const testPuzzleMatrix = "00001100,00001011,00000110,00000010,11000101,10101001,01110001,01001110"
+
+func TestParseModel(t *testing.T) {
+	tests := []struct {
+		spec string
+		want TransmissionModel
+	}{
+		{"independent", Independent{}},
+		{"linear", Linear{}},
+		{"threshold:1", Threshold{Neighbors: 1}},
+		{"threshold:3", Threshold{Neighbors: 3}},
+	}
+	for _, tt := range tests {
+		got, err := ParseModel(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseModel(%q) = %v, %v, want %v", tt.spec, got, err, tt.want)
+		}
+		if s := got.(interface{ String() string }).String(); s != tt.spec {
+			t.Errorf("%#v.String() = %q, want %q", got, s, tt.spec)
+		}
+	}
+	for _, spec := range []string{"", "Independent", "threshold", "threshold:", "threshold:0", "threshold:-1", "threshold:x", "quadratic"} {
+		if _, err := ParseModel(spec); !errors.Is(err, ErrUnknownModel) {
+			t.Errorf("ParseModel(%q): got error %v, want %v", spec, err, ErrUnknownModel)
+		}
+	}
+}
+
+func TestInfectionProbability(t *testing.T) {
+	tests := []struct {
+		model     TransmissionModel
+		neighbors int
+		rate      float64
+		want      float64
+	}{
+		{Independent{}, 1, 0.1, 0.1},
+		{Independent{}, 2, 0.5, 0.75},
+		{Independent{}, 3, 1, 1},
+		{Linear{}, 1, 0.1, 0.1},
+		{Linear{}, 3, 0.25, 0.75},
+		{Linear{}, 3, 0.5, 1},
+		{Threshold{Neighbors: 2}, 1, 0.9, 0},
+		{Threshold{Neighbors: 2}, 2, 0.1, 1},
+		{Threshold{Neighbors: 2}, 5, 0, 1},
+	}
+	for _, tt := range tests {
+		if got := tt.model.InfectionProbability(tt.neighbors, tt.rate); math.Abs(got-tt.want) > 1e-15 {
+			t.Errorf("%v.InfectionProbability(%d, %g) = %g, want %g", tt.model, tt.neighbors, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultModel(t *testing.T) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := g.Compute(context.Background(), 30, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []TransmissionModel{nil, Independent{}} {
+		got, err := g.Compute(context.Background(), 30, 0.1, WithModel(model))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("WithModel(%v) gives %v, %v, want %v", model, got, err, want)
+		}
+	}
+}
+
+func TestThresholdStar(t *testing.T) {
+	// vertex 0 is the center of the star, every leaf only has the center as a neighbor
+	star, err := ParseMatrix("01111111,10000000,10000000,10000000,10000000,10000000,10000000,10000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		threshold int
+		days      uint
+		want      []float64 // probability from the center, then from the leaves
+	}{
+		// with a threshold of 1, the center infects every leaf on day 1, a leaf infects the center on day 1
+		{1, 0, []float64{0, 0}},
+		{1, 1, []float64{1, 0}},
+		{1, 2, []float64{1, 1}},
+		{1, 10, []float64{1, 1}},
+		// leaves never have 2 infected neighbors, nothing ever spreads
+		{2, 1, []float64{0, 0}},
+		{2, 10, []float64{0, 0}},
+	}
+	for _, tt := range tests {
+		for _, algorithm := range Algorithms {
+			for _, rate := range []float64{0, 0.1, 0.9, 1} {
+				r, err := star.Compute(context.Background(), tt.days, rate, WithModel(Threshold{Neighbors: tt.threshold}), WithAlgorithm(algorithm))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for v, p := range r {
+					want := tt.want[1]
+					if v == 0 {
+						want = tt.want[0]
+					}
+					if p != want {
+						t.Errorf("threshold:%d, %s, rate %g, %d days: %g from vertex %d, want %g", tt.threshold, algorithm, rate, tt.days, p, v,
+							want)
+					}
+				}
+			}
+		}
+	}
+}
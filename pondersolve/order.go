@@ -0,0 +1,73 @@
+package pondersolve
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/teivah/bitvector"
+)
+
+// InfectionOrder compares the days on which vertices a and b get infected, within the given number of days, when
+// vertex initial is infected on day 0. A vertex which isn't infected within the number of days counts as infected
+// after every other vertex, neither is the probability that neither a nor b gets infected. The four probabilities add
+// up to 1.
+func (g *Graph) InfectionOrder(ctx context.Context, days uint, rate float64, initial, a, b uint8) (aFirst, sameDay, bFirst, neither float64, err error) {
+	if _, err := newOptions(rate, nil); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	for _, v := range []uint8{initial, a, b} {
+		if v >= g.size {
+			return 0, 0, 0, 0, fmt.Errorf("%w: vertex %d, graph has %d vertices", ErrVertexOutOfRange, v, g.size)
+		}
+	}
+	masks := g.neighborMasks()
+
+	// Like StateDistributions, only keeping the states where neither a nor b is infected yet. The probability of
+	// reaching a state where either is infected is taken out of the distribution, and attributed to a, b or both.
+	classify := func(state bitvector.Len8, p float64) bool {
+		switch {
+		case state.Get(a) && state.Get(b):
+			sameDay += p
+		case state.Get(a):
+			aFirst += p
+		case state.Get(b):
+			bFirst += p
+		default:
+			return false
+		}
+		return true
+	}
+	states := 1 << g.size
+	m := make([][]stateProbability, states)
+	current := make([]float64, states)
+	var state bitvector.Len8
+	state = state.Set(initial, true)
+	if !classify(state, 1.0) {
+		current[state] = 1.0
+	}
+	for day := uint(1); day <= days; day++ {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, 0, 0, err
+		}
+		next := make([]float64, states)
+		for state, p := range current {
+			if p == 0 {
+				continue
+			}
+			if m[state] == nil {
+				m[state] = g.enumerateNextStates(masks, bitvector.Len8(state), rate, Independent{}, 0)
+			}
+			for _, nextState := range m[state] {
+				q := p * nextState.probability
+				if !classify(nextState.state, q) {
+					next[nextState.state] += q
+				}
+			}
+		}
+		current = next
+	}
+	for _, p := range current {
+		neither += p
+	}
+	return aFirst, sameDay, bFirst, neither, nil
+}
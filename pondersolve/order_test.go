@@ -0,0 +1,92 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestInfectionOrderPath(t *testing.T) {
+	// On the path 0-1-2, vertex 2 can only get infected through vertex 1, on a later day.
+	g, err := ParseMatrix("010,101,010")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		days                             uint
+		rate                             float64
+		a, b                             uint8
+		aFirst, sameDay, bFirst, neither float64
+	}{
+		{2, 1, 1, 2, 1, 0, 0, 0},
+		{2, 1, 2, 1, 0, 0, 1, 0},
+		{1, 1, 1, 2, 1, 0, 0, 0},
+		{10, 0.3, 1, 2, 1 - math.Pow(0.7, 10), 0, 0, math.Pow(0.7, 10)},
+		{10, 0.3, 2, 1, 0, 0, 1 - math.Pow(0.7, 10), math.Pow(0.7, 10)},
+		{10, 0, 1, 2, 0, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		aFirst, sameDay, bFirst, neither, err := g.InfectionOrder(context.Background(), tt.days, tt.rate, 0, tt.a, tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []float64{aFirst, sameDay, bFirst, neither}
+		want := []float64{tt.aFirst, tt.sameDay, tt.bFirst, tt.neither}
+		for i := range got {
+			if math.Abs(got[i]-want[i]) > 1e-12 {
+				t.Errorf("%d before %d within %d days at rate %g: got %v, want %v", tt.a, tt.b, tt.days, tt.rate, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestInfectionOrderSymmetric(t *testing.T) {
+	// Vertices 1 and 2 play the same role in the star centered on vertex 0, so each gets infected first with
+	// probability 0.5 minus half the probability of a tie. Both get infected on a given day with probability rate²,
+	// until one of them is, so a tie has probability rate² / (1 - (1-rate)²) = rate / (2-rate).
+	g, err := ParseMatrix("0111,1000,1000,1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rate := range []float64{0.1, 0.5, 0.9, 1} {
+		aFirst, sameDay, bFirst, neither, err := g.InfectionOrder(context.Background(), 500, rate, 0, 1, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if neither > 1e-12 {
+			t.Errorf("rate %g: neither infected within 500 days with probability %g", rate, neither)
+		}
+		if want := rate / (2 - rate); math.Abs(sameDay-want) > 1e-12 {
+			t.Errorf("rate %g: same day with probability %g, want %g", rate, sameDay, want)
+		}
+		want := 0.5 - sameDay/2
+		if math.Abs(aFirst-want) > 1e-12 || math.Abs(bFirst-want) > 1e-12 {
+			t.Errorf("rate %g: 1 before 2 with probability %g, 2 before 1 with probability %g, want %g", rate, aFirst, bFirst, want)
+		}
+	}
+}
+
+func TestInfectionOrderErrors(t *testing.T) {
+	g, err := ParseMatrix("011,101,110")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		initial, a, b uint8
+		rate          float64
+		want          error
+	}{
+		{3, 1, 2, 0.1, ErrVertexOutOfRange},
+		{0, 3, 2, 0.1, ErrVertexOutOfRange},
+		{0, 1, 3, 0.1, ErrVertexOutOfRange},
+		{0, 1, 2, 1.5, ErrInvalidRate},
+	}
+	for _, tt := range tests {
+		_, _, _, _, err := g.InfectionOrder(context.Background(), 3, tt.rate, tt.initial, tt.a, tt.b)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("InfectionOrder(%d, %d, %d, %g): got error %v, want %v", tt.initial, tt.a, tt.b, tt.rate, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strings"
+
+	"github.com/teivah/bitvector"
+)
+
+// Polynomial is a polynomial in the rate, p[i] is the coefficient of rate^i.
+type Polynomial []*big.Rat
+
+// ErrDegreeTooLarge is returned by ComputePolynomial when the polynomials could exceed the maximum degree.
+var ErrDegreeTooLarge = errors.New("polynomial degree too large")
+
+// Degree returns the degree of the polynomial, -1 for the zero polynomial.
+func (p Polynomial) Degree() int {
+	for i := len(p) - 1; i >= 0; i-- {
+		if p[i].Sign() != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Eval returns the value of the polynomial at x.
+func (p Polynomial) Eval(x *big.Rat) *big.Rat {
+	r := new(big.Rat)
+	for i := len(p) - 1; i >= 0; i-- {
+		r.Mul(r, x)
+		r.Add(r, p[i])
+	}
+	return r
+}
+
+// String returns the polynomial in increasing powers of r, e.g. "3r^2 - 2r^3".
+func (p Polynomial) String() string {
+	var r strings.Builder
+	for i, c := range p {
+		if c.Sign() == 0 {
+			continue
+		}
+		abs := new(big.Rat).Abs(c)
+		switch {
+		case r.Len() == 0 && c.Sign() < 0:
+			r.WriteString("-")
+		case r.Len() > 0 && c.Sign() < 0:
+			r.WriteString(" - ")
+		case r.Len() > 0:
+			r.WriteString(" + ")
+		}
+		if i == 0 || abs.Cmp(big.NewRat(1, 1)) != 0 {
+			r.WriteString(abs.RatString())
+		}
+		if i > 0 {
+			r.WriteString("r")
+		}
+		if i > 1 {
+			fmt.Fprintf(&r, "^%d", i)
+		}
+	}
+	if r.Len() == 0 {
+		return "0"
+	}
+	return r.String()
+}
+
+// Polynomial with integer coefficients, in increasing powers of the rate. Every transition probability is a product of
+// (1-r)^k and 1-(1-r)^k terms, so the coefficients stay integers and the dp below avoids the cost of big.Rat.
+type intPolynomial []*big.Int
+
+// Returns (1-r)^k, using the binomial expansion.
+func oneMinusRatePow(k int) intPolynomial {
+	r := make(intPolynomial, k+1)
+	for i := range r {
+		r[i] = new(big.Int).Binomial(int64(k), int64(i))
+		if i%2 == 1 {
+			r[i].Neg(r[i])
+		}
+	}
+	return r
+}
+
+// Returns a*b.
+func (a intPolynomial) mul(b intPolynomial) intPolynomial {
+	r := make(intPolynomial, len(a)+len(b)-1)
+	for i := range r {
+		r[i] = new(big.Int)
+	}
+	a.mulAdd(r, b)
+	return r
+}
+
+// Adds a*b to r, which must have room for every coefficient of the product.
+func (a intPolynomial) mulAdd(r, b intPolynomial) {
+	var t big.Int
+	for i, x := range a {
+		if x.Sign() == 0 {
+			continue
+		}
+		for j, y := range b {
+			r[i+j].Add(r[i+j], t.Mul(x, y))
+		}
+	}
+}
+
+type statePolynomial struct {
+	state      bitvector.Len8
+	polynomial intPolynomial
+}
+
+// Same as enumerateNextStates, with exact polynomials instead of probabilities.
+func (g *Graph) enumerateNextStatePolynomials(masks *neighborMasks, state bitvector.Len8, index uint8) []statePolynomial {
+	if index == g.size {
+		return []statePolynomial{{state: state, polynomial: intPolynomial{big.NewInt(1)}}}
+	}
+	r := g.enumerateNextStatePolynomials(masks, state, index+1)
+	infected := bits.OnesCount8(uint8(masks[index] & state))
+	if state.Get(index) || infected == 0 {
+		return r
+	}
+	notInfected := oneMinusRatePow(infected)
+	isInfected := oneMinusRatePow(infected)
+	for _, c := range isInfected {
+		c.Neg(c)
+	}
+	isInfected[0].Add(isInfected[0], big.NewInt(1))
+	var r2 []statePolynomial
+	for _, s := range r {
+		r2 = append(r2, statePolynomial{state: s.state, polynomial: s.polynomial.mul(notInfected)})
+		r2 = append(r2, statePolynomial{state: s.state.Set(index, true), polynomial: s.polynomial.mul(isInfected)})
+	}
+	return r2
+}
+
+// ComputePolynomial is like Compute, returning the exact probabilities as polynomials in the rate. The degree grows
+// with the number of days and edges: ErrDegreeTooLarge is returned without computing anything when the polynomials
+// could have a degree larger than maxDegree. The algorithm option is ignored, and only the Independent model is
+// supported: ErrUnsupportedModel is returned for the others.
+func (g *Graph) ComputePolynomial(ctx context.Context, days uint, maxDegree int, opts ...Option) ([]Polynomial, error) {
+	o, err := newOptions(0, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIndependent(o.model); err != nil {
+		return nil, err
+	}
+	masks := g.neighborMasks()
+	lastState := bitvector.Len8((1 << g.size) - 1)
+
+	// only the states reachable from the initial states are computed
+	var initial []bitvector.Len8
+	for i := uint8(0); i < g.size; i++ {
+		var state bitvector.Len8
+		initial = append(initial, state.Set(i, true))
+		if o.firstResultOnly {
+			break
+		}
+	}
+	transitions := make(map[bitvector.Len8][]statePolynomial)
+	queue := append([]bitvector.Len8(nil), initial...)
+	stepDegree := 0
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		if _, ok := transitions[state]; ok || state == lastState {
+			continue
+		}
+		transitions[state] = g.enumerateNextStatePolynomials(masks, state, 0)
+		for _, next := range transitions[state] {
+			if len(next.polynomial)-1 > stepDegree {
+				stepDegree = len(next.polynomial) - 1
+			}
+			queue = append(queue, next.state)
+		}
+	}
+	if bound := uint64(days) * uint64(stepDegree); bound > uint64(maxDegree) {
+		return nil, fmt.Errorf("%w: up to %d after %d days (%d per day), the limit is %d", ErrDegreeTooLarge, bound, days, stepDegree, maxDegree)
+	}
+
+	// previous[state] is the probability of infecting all the vertices within the previous number of days
+	previous := map[bitvector.Len8]intPolynomial{lastState: {big.NewInt(1)}}
+	for day := uint(1); day <= days; day++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		current := map[bitvector.Len8]intPolynomial{lastState: {big.NewInt(1)}}
+		for state, nextStates := range transitions {
+			length := 0
+			for _, next := range nextStates {
+				if p, ok := previous[next.state]; ok && len(next.polynomial)+len(p)-1 > length {
+					length = len(next.polynomial) + len(p) - 1
+				}
+			}
+			if length == 0 {
+				continue
+			}
+			p := make(intPolynomial, length)
+			for i := range p {
+				p[i] = new(big.Int)
+			}
+			for _, next := range nextStates {
+				if q, ok := previous[next.state]; ok {
+					next.polynomial.mulAdd(p, q)
+				}
+			}
+			// terms often cancel out, trimming them keeps the next day cheaper
+			for len(p) > 0 && p[len(p)-1].Sign() == 0 {
+				p = p[:len(p)-1]
+			}
+			if len(p) > 0 {
+				current[state] = p
+			}
+		}
+		previous = current
+	}
+
+	var r []Polynomial
+	for _, state := range initial {
+		p := Polynomial{}
+		for _, c := range previous[state] {
+			p = append(p, new(big.Rat).SetInt(c))
+		}
+		r = append(r, p[:p.Degree()+1])
+	}
+	return r, nil
+}
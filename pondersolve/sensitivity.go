@@ -0,0 +1,97 @@
+package pondersolve
+
+import (
+	"context"
+	"math"
+	"math/bits"
+
+	"github.com/teivah/bitvector"
+)
+
+// A value along with its derivative with respect to the rate, for forward-mode differentiation.
+type dual struct {
+	value      float64
+	derivative float64
+}
+
+func (a dual) add(b dual) dual {
+	return dual{a.value + b.value, a.derivative + b.derivative}
+}
+
+func (a dual) mul(b dual) dual {
+	return dual{a.value * b.value, a.derivative*b.value + a.value*b.derivative}
+}
+
+type stateDual struct {
+	state       bitvector.Len8
+	probability dual
+}
+
+// Same as enumerateNextStates, also differentiating each probability with respect to the rate.
+func (g *Graph) enumerateNextStateDuals(masks *neighborMasks, state bitvector.Len8, rate float64, index uint8) []stateDual {
+	if index == g.size {
+		return []stateDual{{state: state, probability: dual{1, 0}}}
+	}
+	r := g.enumerateNextStateDuals(masks, state, rate, index+1)
+	infected := bits.OnesCount8(uint8(masks[index] & state))
+	if state.Get(index) || infected == 0 {
+		return r
+	}
+	// d/dr (1-rate)^k = -k(1-rate)^(k-1)
+	k := float64(infected)
+	p := dual{math.Pow(1.0-rate, k), -k * math.Pow(1.0-rate, k-1)}
+	notP := dual{1 - p.value, -p.derivative}
+	var r2 []stateDual
+	for _, s := range r {
+		r2 = append(r2, stateDual{state: s.state, probability: s.probability.mul(p)})
+		r2 = append(r2, stateDual{state: s.state.Set(index, true), probability: s.probability.mul(notP)})
+	}
+	return r2
+}
+
+// ComputeSensitivity is like Compute, also returning the derivatives of the probabilities with respect to the rate.
+// The derivatives are exact up to rounding: they are carried through the dp along with the probabilities. The
+// algorithm option is ignored, and only the Independent model is supported, like ComputePolynomial.
+func (g *Graph) ComputeSensitivity(ctx context.Context, days uint, rate float64, opts ...Option) (r, derivatives []float64, err error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkIndependent(o.model); err != nil {
+		return nil, nil, err
+	}
+	masks := g.neighborMasks()
+	lastState := (1 << g.size) - 1
+	m := make([][]stateDual, lastState+1)
+	for state := 0; state <= lastState; state++ {
+		m[state] = g.enumerateNextStateDuals(masks, bitvector.Len8(state), rate, 0)
+	}
+
+	var previous [256]dual
+	previous[lastState] = dual{1, 0}
+	for i := uint(1); i <= days; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		var current [256]dual
+		for state := 0; state <= lastState; state++ {
+			var p dual
+			for _, nextState := range m[state] {
+				p = p.add(nextState.probability.mul(previous[nextState.state]))
+			}
+			current[state] = p
+		}
+		previous = current
+	}
+
+	for i := uint8(0); i < g.size; i++ {
+		var initialState bitvector.Len8
+		initialState = initialState.Set(i, true)
+		r = append(r, previous[initialState].value)
+		derivatives = append(derivatives, previous[initialState].derivative)
+		if o.firstResultOnly {
+			break
+		}
+	}
+	return r, derivatives, nil
+}
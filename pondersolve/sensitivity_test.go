@@ -0,0 +1,71 @@
+package pondersolve
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestSensitivityMatchesFiniteDifferences(t *testing.T) {
+	// central differences are accurate to O(h²), rounding errors aside
+	const h = 1e-5
+	graphs := []string{
+		"0100,1010,0101,0010", // path
+		"0111,1000,1000,1000", // star
+		"0101,1010,0101,1010", // cycle
+		"0111,1011,1101,1110", // complete
+		"0100,0010,0001,1000", // directed cycle
+	}
+	for _, matrix := range graphs {
+		g, err := ParseMatrix(matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, rate := range []float64{0.05, 0.1, 0.5, 0.9} {
+			for _, days := range []uint{0, 1, 3, 10, 30} {
+				r, derivatives, err := g.ComputeSensitivity(context.Background(), days, rate)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want, err := g.Compute(context.Background(), days, rate)
+				if err != nil {
+					t.Fatal(err)
+				}
+				above, err := g.Compute(context.Background(), days, rate+h)
+				if err != nil {
+					t.Fatal(err)
+				}
+				below, err := g.Compute(context.Background(), days, rate-h)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for v := range want {
+					if math.Abs(r[v]-want[v]) > 1e-12 {
+						t.Errorf("%s, rate %g, %d days, vertex %d: probability %g, Compute gives %g", matrix, rate, days, v, r[v], want[v])
+					}
+					if difference := (above[v] - below[v]) / (2 * h); math.Abs(derivatives[v]-difference) > 1e-6 {
+						t.Errorf("%s, rate %g, %d days, vertex %d: derivative %g, finite differences give %g", matrix, rate, days, v, derivatives[v], difference)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSensitivityFirstResultOnly(t *testing.T) {
+	g, err := ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, allDerivatives, err := g.ComputeSensitivity(context.Background(), 30, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, derivatives, err := g.ComputeSensitivity(context.Background(), 30, 0.1, FirstResultOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r) != 1 || len(derivatives) != 1 || r[0] != all[0] || derivatives[0] != allDerivatives[0] {
+		t.Errorf("FirstResultOnly gives %v and %v, want %g and %g", r, derivatives, all[0], allDerivatives[0])
+	}
+}
@@ -0,0 +1,124 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/rand"
+
+	"github.com/teivah/bitvector"
+)
+
+// Infection is a vertex getting infected during a simulated run.
+type Infection struct {
+	Day     uint
+	Vertex  uint8
+	Exposed []uint8 // neighbors which were infected on the previous day, empty for the initial vertex
+	Source  uint8   // neighbor the infection is attributed to, the vertex itself for the initial vertex
+}
+
+// Simulate samples a single run of the infection for up to the given number of days, when vertex initial is infected
+// on day 0. It returns the infections in the order they happened, by day and then by vertex, starting with the initial
+// vertex. The run stops early once every vertex is infected.
+//
+// Each infection is attributed to one of the exposing neighbors, picked proportionally to the probability that this
+// neighbor alone would have passed the infection on. Every neighbor passes it on with the same probability, so the
+// source is picked uniformly.
+func (g *Graph) Simulate(rng *rand.Rand, days uint, rate float64, initial uint8, opts ...Option) ([]Infection, error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return nil, err
+	}
+	if initial >= g.size {
+		return nil, fmt.Errorf("%w: initial vertex %d, graph has %d vertices", ErrVertexOutOfRange, initial, g.size)
+	}
+	r := []Infection{{Day: 0, Vertex: initial, Source: initial}}
+	g.simulate(rng, g.neighborMasks(), days, rate, o.model, initial, func(day uint, v uint8, exposed bitvector.Len8) {
+		infection := Infection{Day: day, Vertex: v}
+		for u := uint8(0); u < g.size; u++ {
+			if exposed.Get(u) {
+				infection.Exposed = append(infection.Exposed, u)
+			}
+		}
+		infection.Source = infection.Exposed[rng.Intn(len(infection.Exposed))]
+		r = append(r, infection)
+	})
+	return r, nil
+}
+
+// Samples a run like Simulate, calling infected, unless it's nil, for every infection after the initial one with the
+// neighbors the vertex was exposed to. Returns the state at the end of the run.
+func (g *Graph) simulate(rng *rand.Rand, masks *neighborMasks, days uint, rate float64, model TransmissionModel, initial uint8,
+	infected func(day uint, v uint8, exposed bitvector.Len8)) bitvector.Len8 {
+	var state bitvector.Len8
+	state = state.Set(initial, true)
+	for day := uint(1); day <= days && state.Count() < g.size; day++ {
+		// every vertex is exposed to the neighbors infected on the previous day
+		next := state
+		for v := uint8(0); v < g.size; v++ {
+			exposed := masks[v] & state
+			if state.Get(v) || exposed == 0 {
+				continue
+			}
+			if rng.Float64() >= model.InfectionProbability(bits.OnesCount8(uint8(exposed)), rate) {
+				continue
+			}
+			if infected != nil {
+				infected(day, v, exposed)
+			}
+			next = next.Set(v, true)
+		}
+		state = next
+	}
+	return state
+}
+
+// Estimate is a probability estimated by sampling, along with a 95% confidence interval.
+type Estimate struct {
+	Probability float64
+	Low, High   float64
+	Samples     int
+}
+
+// ErrNoSamples is returned by MonteCarlo when asked for fewer than 1 sample.
+var ErrNoSamples = errors.New("at least one sample is needed")
+
+// Number of samples between two checks of the context in MonteCarlo.
+const samplesPerCheck = 1024
+
+// MonteCarlo estimates the probability Compute returns for vertex initial, by simulating the given number of runs, see
+// Simulate. The confidence interval is Wilson's score interval, which stays within [0, 1] and doesn't collapse when
+// every run, or none, infects every vertex. The algorithm option is ignored.
+func (g *Graph) MonteCarlo(ctx context.Context, rng *rand.Rand, days uint, rate float64, initial uint8, samples int, opts ...Option) (Estimate, error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return Estimate{}, err
+	}
+	if initial >= g.size {
+		return Estimate{}, fmt.Errorf("%w: initial vertex %d, graph has %d vertices", ErrVertexOutOfRange, initial, g.size)
+	}
+	if samples < 1 {
+		return Estimate{}, fmt.Errorf("%w: %d", ErrNoSamples, samples)
+	}
+	masks := g.neighborMasks()
+	complete := 0
+	for k := 0; k < samples; k++ {
+		if k%samplesPerCheck == 0 {
+			if err := ctx.Err(); err != nil {
+				return Estimate{}, err
+			}
+		}
+		if g.simulate(rng, masks, days, rate, o.model, initial, nil).Count() == g.size {
+			complete++
+		}
+	}
+
+	const z = 1.959963984540054 // 97.5th percentile of the standard normal distribution
+	n := float64(samples)
+	p := float64(complete) / n
+	center := (p + z*z/(2*n)) / (1 + z*z/n)
+	margin := z / (1 + z*z/n) * math.Sqrt(p*(1-p)/n+z*z/(4*n*n))
+	return Estimate{Probability: p, Low: math.Max(center-margin, 0), High: math.Min(center+margin, 1), Samples: samples}, nil
+}
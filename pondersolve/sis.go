@@ -0,0 +1,88 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/teivah/bitvector"
+)
+
+// ErrInvalidRecovery is returned by SISDistribution.
+var ErrInvalidRecovery = errors.New("recovery probability must be between 0 and 1")
+
+// SISDistribution is like StateDistribution in the SIS model: every day, each infected vertex becomes susceptible again
+// with probability recovery, while the susceptible ones get infected by the neighbors which were infected at the start
+// of the day, see WithModel. The algorithm option is ignored.
+//
+// Once no vertex is infected the infection can't come back: r[0] is the probability that it died out within days, and
+// 1 - r[0] the probability that it persists.
+func (g *Graph) SISDistribution(ctx context.Context, days uint, rate, recovery float64, initial uint8, opts ...Option) ([]float64, error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !(recovery >= 0 && recovery <= 1) {
+		return nil, fmt.Errorf("%w: %g", ErrInvalidRecovery, recovery)
+	}
+	if initial >= g.size {
+		return nil, fmt.Errorf("%w: initial vertex %d, graph has %d vertices", ErrVertexOutOfRange, initial, g.size)
+	}
+	states := 1 << g.size
+	masks := g.neighborMasks()
+	m := make([][]stateProbability, states)
+
+	current, next := make([]float64, states), make([]float64, states)
+	current[1<<initial] = 1.0
+	for day := uint(1); day <= days; day++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// like Evolve, each state spreads its probability to its next states
+		for state := range next {
+			next[state] = 0
+		}
+		for state, p := range current {
+			if p == 0 {
+				continue
+			}
+			if m[state] == nil {
+				m[state] = g.sisNextStates(masks, bitvector.Len8(state), rate, recovery, o.model)
+			}
+			for _, nextState := range m[state] {
+				next[nextState.state] += p * nextState.probability
+			}
+		}
+		current, next = next, current
+	}
+	return current, nil
+}
+
+// Returns the states the SIS model can move to from state in a day, with their probability. Each vertex changes
+// independently of the others, given state.
+func (g *Graph) sisNextStates(masks *neighborMasks, state bitvector.Len8, rate, recovery float64, model TransmissionModel) []stateProbability {
+	r := []stateProbability{{probability: 1.0}}
+	for v := uint8(0); v < g.size; v++ {
+		var infected, susceptible float64
+		if state.Get(v) {
+			infected, susceptible = 1.0-recovery, recovery
+		} else if k := bits.OnesCount8(uint8(masks[v] & state)); k > 0 {
+			infected, susceptible = infectionProbabilities(model, k, rate)
+		} else {
+			susceptible = 1.0
+		}
+		// outcomes which can't happen are left out, like in enumerateNextStates
+		var expanded []stateProbability
+		for _, s := range r {
+			if susceptible != 0 {
+				expanded = append(expanded, stateProbability{state: s.state, probability: s.probability * susceptible})
+			}
+			if infected != 0 {
+				expanded = append(expanded, stateProbability{state: s.state.Set(v, true), probability: s.probability * infected})
+			}
+		}
+		r = expanded
+	}
+	return r
+}
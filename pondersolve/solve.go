@@ -0,0 +1,384 @@
+package pondersolve
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Source provides the graphs processed by Solve.
+type Source interface {
+	// Next returns the next graph as a matrix, along with a number identifying it (e.g. a line number in a database).
+	// ok is false once every graph has been returned.
+	Next() (number int, matrix string, ok bool)
+	// Progress returns the fraction of the source which was consumed so far.
+	Progress() float64
+}
+
+// Estimator estimates the time left to process a Source. It is told about each graph once the graph has been
+// processed or skipped, size is the number of rows of the graph's matrix.
+type Estimator interface {
+	Processed(size int, d time.Duration)
+	Skipped(size int)
+	ETA() time.Duration
+}
+
+// Cache stores the probabilities computed by Solve, e.g. to reuse them across runs. The probabilities depend on
+// SolveOptions.Model, which isn't part of the methods' arguments: a cache only holds the probabilities of a single model.
+type Cache interface {
+	// Get returns the probability for each initial vertex of g after the given number of days, ok is false when they
+	// aren't cached.
+	Get(g Graph, days uint, rate float64) (r []float64, ok bool)
+	// Put stores the probability for each initial vertex of g after the given number of days.
+	Put(g Graph, days uint, rate float64, r []float64)
+}
+
+// Solution is a graph whose probability is within tolerance of a target.
+type Solution struct {
+	Graph         Graph  // pivoted: vertex 0 and the initially infected vertex are swapped, see Graph.Pivot
+	Number        int    // number of the graph in the source
+	Matrix        string // line exactly as returned by the source, a matrix, graph6 or sparse6, see ParseGraph
+	InitialVertex uint8  // initially infected vertex, before pivoting
+	Days          uint
+	Target        float64
+	Value         float64
+	Values        []float64 // value for each of SolveOptions.Constraints, nil without constraints
+	Distance      float64
+}
+
+// Breaks ties between solutions at the same distance: the one which comes first in the source wins, regardless of the
+// order in which the graphs were processed.
+func (s Solution) before(other Solution) bool {
+	if s.Number != other.Number {
+		return s.Number < other.Number
+	}
+	if s.Days != other.Days {
+		return s.Days < other.Days
+	}
+	return s.InitialVertex < other.InitialVertex
+}
+
+// TargetResult holds the best solutions for a target.
+type TargetResult struct {
+	Target float64
+	Best   []Solution // closest to the target first
+}
+
+// Summary describes a Solve run.
+type Summary struct {
+	Results     []TargetResult // in the same order as SolveOptions.Targets
+	Processed   int            // graphs read from the source, including the skipped ones
+	Malformed   int            // graphs which failed to parse
+	Filtered    int            // graphs rejected by SolveOptions.Filter
+	Duplicates  int            // graphs skipped by SolveOptions.DedupeExact
+	Isomorphic  int            // graphs skipped by SolveOptions.DedupeIsomorphic
+	Matches     int            // solutions within tolerance, for every target, day count and initial vertex
+	NearMisses  int            // graphs within SolveOptions.NearMiss of a target, counted once per target
+	Undecided   int            // candidates whose interval straddles the tolerance, with SolveOptions.Interval
+	Elapsed     time.Duration
+	Interrupted bool // the context was cancelled before every graph was processed
+}
+
+// Constraint requires the probability after Days days to be within Tolerance of Target.
+type Constraint struct {
+	Days      uint
+	Target    float64
+	Tolerance float64
+}
+
+// SolveOptions configures Solve.
+//
+// Callbacks are optional. They are called from the goroutine running Solve, which waits for them to return.
+type SolveOptions struct {
+	Targets   []float64
+	Tolerance float64 // maximum distance between a solution and its target
+	Top       int     // number of solutions to keep for each target, at least 1
+	MinDays   uint    // every number of days in [MinDays, MaxDays] is solved for
+	MaxDays   uint
+	Rate      float64
+	Algorithm Algorithm
+	Model     TransmissionModel // nil is Independent
+	// Constraints replace Targets, Tolerance, MinDays and MaxDays: a solution must meet every constraint, its distance
+	// is the largest distance to a constraint's target. Solutions are reported as for a single target, the first
+	// constraint's, with Solution.Values holding the value for each constraint.
+	Constraints []Constraint
+
+	Filter           func(g Graph) bool // graphs for which Filter returns false are skipped, nil keeps every graph
+	DedupeExact      bool               // skip graphs which are identical to a graph already processed
+	DedupeIsomorphic bool               // skip graphs which are isomorphic to a graph already processed
+	Strict           bool               // stop on the first malformed graph instead of skipping it
+	Directed         bool               // read asymmetric matrices as directed graphs, instead of rejecting them with ErrAsymmetric
+	Cache            Cache              // consulted before computing a graph, nil computes every graph
+	NearMiss         float64            // report graphs within this distance of a target to OnNearMiss, 0 disables it
+	// Interval also computes a guaranteed enclosure of each probability, see ComputeInterval. Candidates whose
+	// enclosure is within tolerance of a target are solutions, those which straddle the tolerance are reported to
+	// OnUndecided instead. It takes about twice as long, and isn't supported with Constraints or a Model other than
+	// Independent.
+	Interval bool
+
+	Total            int           // number of graphs in the source, passed to OnProgress
+	Estimator        Estimator     // nil extrapolates the time left from the source's progress
+	ProgressInterval time.Duration // minimum time between OnProgress calls, 0 calls it after every graph
+
+	// OnProgress is called after processing a graph. best is the value closest to the first target so far, 0 if no
+	// solution was found yet.
+	OnProgress func(processed, total int, best float64, eta time.Duration)
+	// OnImproved is called when a solution is closer to its target than any solution found before.
+	OnImproved func(s Solution)
+	// OnMatch is called for every solution within tolerance.
+	OnMatch func(s Solution)
+	// OnNearMiss is called with the initial vertex and day count closest to each target, when it's within NearMiss of
+	// the target. It doesn't affect the solutions kept for each target.
+	OnNearMiss func(s Solution)
+	// OnUndecided is called for every candidate whose interval straddles the tolerance, with Interval.
+	OnUndecided func(s Solution)
+	// OnMalformed is called for every graph which fails to parse, unless Strict is set.
+	OnMalformed func(number int, matrix string, err error)
+	// OnFinished is called once every graph was processed, or the context was cancelled.
+	OnFinished func(summary Summary)
+}
+
+// ErrNoTargets is returned by Solve and NewSolver when no targets are provided.
+var ErrNoTargets = errors.New("no targets to solve for")
+
+// ErrIntervalConstraints is returned by Solve and NewSolver when both SolveOptions.Interval and SolveOptions.Constraints are set.
+var ErrIntervalConstraints = errors.New("interval classification isn't supported with constraints")
+
+// Solve computes the probability of infecting every vertex of each graph provided by source, and keeps the solutions
+// closest to each target. Solve stops early when ctx is cancelled, the summary is then marked as interrupted and
+// includes the solutions found so far. It's a loop over Solver.Next, see Solver to process one graph at a time.
+func Solve(ctx context.Context, source Source, opts SolveOptions) (Summary, error) {
+	s, err := NewSolver(source, opts)
+	if err != nil {
+		return Summary{}, err
+	}
+	for {
+		_, ok, err := s.Next(ctx)
+		if err != nil {
+			return s.Summary(), err
+		}
+		if !ok {
+			break
+		}
+	}
+	summary := s.Summary()
+	if opts.OnFinished != nil {
+		opts.OnFinished(summary)
+	}
+	return summary, nil
+}
+
+// Computes the probabilities for every number of days, using the cache when every day count is cached.
+func (opts *SolveOptions) compute(ctx context.Context, g Graph) ([][]float64, error) {
+	if opts.Cache == nil {
+		return g.ComputeDays(ctx, opts.MinDays, opts.MaxDays, opts.Rate, WithAlgorithm(opts.Algorithm), WithModel(opts.Model))
+	}
+	var r [][]float64
+	for days := opts.MinDays; days <= opts.MaxDays; days++ {
+		values, ok := opts.Cache.Get(g, days, opts.Rate)
+		if !ok {
+			r = nil
+			break
+		}
+		r = append(r, values)
+	}
+	if r != nil {
+		return r, nil
+	}
+	r, err := g.ComputeDays(ctx, opts.MinDays, opts.MaxDays, opts.Rate, WithAlgorithm(opts.Algorithm), WithModel(opts.Model))
+	if err != nil {
+		return nil, err
+	}
+	for d, values := range r {
+		opts.Cache.Put(g, opts.MinDays+uint(d), opts.Rate, values)
+	}
+	return r, nil
+}
+
+// Extrapolates the time left from the time spent so far and the source's progress.
+type progressEstimator struct {
+	source Source
+	start  time.Time
+}
+
+func (e *progressEstimator) Processed(size int, d time.Duration) {}
+
+func (e *progressEstimator) Skipped(size int) {}
+
+func (e *progressEstimator) ETA() time.Duration {
+	p := e.source.Progress()
+	if p <= 0 {
+		return 0
+	}
+	elapsed := time.Since(e.start)
+	return time.Duration(float64(elapsed) * (1 - p) / p).Round(time.Millisecond)
+}
+
+// Keeps track of the best solutions for a given target.
+type targetSolutions struct {
+	target       float64
+	tolerance    float64
+	top          int
+	bestValue    float64
+	bestDistance float64
+	best         solutions
+	found        int
+}
+
+// Records s, the probability of infecting all the vertices of g, if it is within tolerance of the target. Returns the
+// pivoted solution, whether it's the closest to the target so far and whether it was within tolerance.
+func (t *targetSolutions) consider(g Graph, s Solution) (Solution, bool, bool) {
+	distance := math.Abs(s.Value - t.target)
+	if distance >= t.tolerance {
+		return Solution{}, false, false
+	}
+	s, improved := t.record(g, s, distance)
+	return s, improved, true
+}
+
+// Like consider, for each initial vertex of g when solving with opts.Constraints: r holds the probabilities for every
+// number of days in [opts.MinDays, opts.MaxDays]. notify is called for each solution which meets every constraint.
+func (t *targetSolutions) considerConstraints(g Graph, number int, matrix string, r [][]float64, opts *SolveOptions, notify func(s Solution, improved bool)) {
+	for i := range r[0] {
+		s := Solution{Number: number, Matrix: matrix, InitialVertex: uint8(i), Days: opts.Constraints[0].Days}
+		distance, ok := opts.constraintDistance(r, i, &s)
+		if !ok {
+			continue
+		}
+		s, improved := t.record(g, s, distance)
+		notify(s, improved)
+	}
+}
+
+// Sets the values of s for each constraint, from the probabilities when vertex i is initially infected. Returns the
+// largest distance to a constraint's target, and whether every constraint is met.
+func (opts *SolveOptions) constraintDistance(r [][]float64, i int, s *Solution) (float64, bool) {
+	distance, ok := 0.0, true
+	for _, c := range opts.Constraints {
+		v := r[c.Days-opts.MinDays][i]
+		s.Values = append(s.Values, v)
+		d := math.Abs(v - c.Target)
+		ok = ok && d < c.Tolerance
+		distance = math.Max(distance, d)
+	}
+	s.Value = s.Values[0]
+	return distance, ok
+}
+
+// Calls OnNearMiss with the initial vertex and day count of g closest to each target, when within opts.NearMiss.
+// Returns the number of near misses.
+func (opts *SolveOptions) nearMisses(g Graph, number int, matrix string, r [][]float64) int {
+	var closest []Solution
+	if len(opts.Constraints) > 0 {
+		var best Solution
+		for i := range r[0] {
+			s := Solution{Number: number, Matrix: matrix, InitialVertex: uint8(i), Days: opts.Constraints[0].Days, Target: opts.Constraints[0].Target}
+			s.Distance, _ = opts.constraintDistance(r, i, &s)
+			if i == 0 || s.Distance < best.Distance {
+				best = s
+			}
+		}
+		closest = append(closest, best)
+	} else {
+		for _, target := range opts.Targets {
+			var best Solution
+			for d, values := range r {
+				for i, v := range values {
+					distance := math.Abs(v - target)
+					if (d == 0 && i == 0) || distance < best.Distance {
+						best = Solution{Number: number, Matrix: matrix, InitialVertex: uint8(i), Days: opts.MinDays + uint(d), Target: target, Value: v, Distance: distance}
+					}
+				}
+			}
+			closest = append(closest, best)
+		}
+	}
+
+	count := 0
+	for _, s := range closest {
+		if s.Distance >= opts.NearMiss {
+			continue
+		}
+		count++
+		if opts.OnNearMiss != nil {
+			s.Graph = g
+			s.Graph.Pivot(s.InitialVertex)
+			opts.OnNearMiss(s)
+		}
+	}
+	return count
+}
+
+// Keeps a solution at the given distance from the target, returns the pivoted solution and whether it's the closest
+// to the target so far.
+func (t *targetSolutions) record(g Graph, s Solution, distance float64) (Solution, bool) {
+	s.Graph = g
+	s.Graph.Pivot(s.InitialVertex)
+	s.Target = t.target
+	s.Distance = distance
+	improved := t.found == 0 || distance < t.bestDistance
+	if improved {
+		t.bestValue = s.Value
+		t.bestDistance = distance
+	}
+	t.best.add(s, t.top)
+	t.found++
+	return s, improved
+}
+
+// Max-heap of solutions, the solution furthest from the target is at the top.
+type solutions []Solution
+
+// Adds a solution, keeping at most k solutions. A solution which ties with the furthest one replaces it if it comes
+// first in the source.
+func (s *solutions) add(sol Solution, k int) {
+	if k < 1 {
+		k = 1
+	}
+	if len(*s) < k {
+		heap.Push(s, sol)
+		return
+	}
+	if sol.Distance < (*s)[0].Distance || (sol.Distance == (*s)[0].Distance && sol.before((*s)[0])) {
+		(*s)[0] = sol
+		heap.Fix(s, 0)
+	}
+}
+
+// Returns the solutions, closest to the target first. Solutions at the same distance are in the order of the source.
+func (s solutions) sorted() []Solution {
+	r := make([]Solution, len(s))
+	copy(r, s)
+	sort.Slice(r, func(i, j int) bool {
+		if r[i].Distance != r[j].Distance {
+			return r[i].Distance < r[j].Distance
+		}
+		return r[i].before(r[j])
+	})
+	return r
+}
+
+func (s solutions) Len() int      { return len(s) }
+func (s solutions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s solutions) Less(i, j int) bool {
+	if s[i].Distance != s[j].Distance {
+		return s[i].Distance > s[j].Distance
+	}
+	return s[j].before(s[i])
+}
+
+func (s *solutions) Push(x interface{}) {
+	*s = append(*s, x.(Solution))
+}
+
+func (s *solutions) Pop() interface{} {
+	old := *s
+	n := len(old)
+	x := old[n-1]
+	*s = old[:n-1]
+	return x
+}
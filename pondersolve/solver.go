@@ -0,0 +1,274 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Solver processes the graphs of a source one at a time, keeping the solutions closest to each target, e.g. to drive
+// the search from a user interface. Solve runs a Solver until the source is exhausted. The callbacks of SolveOptions
+// are called from Next, except OnFinished.
+type Solver struct {
+	source    Source
+	opts      SolveOptions
+	estimator Estimator
+	targets   []*targetSolutions
+
+	// Canonical forms of the graphs processed so far. Skipping isomorphic graphs is safe since every initial vertex
+	// is tried: a relabeled copy of a graph yields the same probabilities, in a different order.
+	seen map[Graph]struct{}
+	// Graphs processed so far, keyed by their compact form. This takes about 25MB per million distinct graphs.
+	seenExact map[[9]byte]struct{}
+
+	summary      Summary
+	startTime    time.Time
+	lastProgress time.Time
+}
+
+// Step describes a graph processed by Solver.Next.
+type Step struct {
+	Number   int    // number of the graph in the source
+	Matrix   string // matrix exactly as returned by the source
+	Skipped  bool   // the graph was malformed, filtered out or a duplicate, and wasn't computed
+	Matches  int    // solutions within tolerance, for every target, day count and initial vertex
+	Improved bool   // one of the solutions is closer to its target than any solution found before
+}
+
+// NewSolver checks the options and returns a Solver reading graphs from source.
+func NewSolver(source Source, opts SolveOptions) (*Solver, error) {
+	if opts.Interval && len(opts.Constraints) > 0 {
+		return nil, ErrIntervalConstraints
+	}
+	if len(opts.Constraints) > 0 {
+		// every constraint comes out of the same computation, up to the largest number of days
+		opts.Targets = []float64{opts.Constraints[0].Target}
+		opts.MinDays, opts.MaxDays = opts.Constraints[0].Days, opts.Constraints[0].Days
+		for _, c := range opts.Constraints {
+			if c.Days == 0 {
+				return nil, fmt.Errorf("%w: constraint for 0 days", ErrInvalidDays)
+			}
+			if c.Days < opts.MinDays {
+				opts.MinDays = c.Days
+			}
+			if c.Days > opts.MaxDays {
+				opts.MaxDays = c.Days
+			}
+		}
+	}
+	if len(opts.Targets) == 0 {
+		return nil, ErrNoTargets
+	}
+	if opts.MaxDays == 0 || opts.MinDays > opts.MaxDays {
+		return nil, fmt.Errorf("%w: [%d, %d]", ErrInvalidDays, opts.MinDays, opts.MaxDays)
+	}
+	o, err := newOptions(opts.Rate, []Option{WithAlgorithm(opts.Algorithm), WithModel(opts.Model)})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Interval {
+		if err := checkIndependent(o.model); err != nil {
+			return nil, err
+		}
+	}
+	s := &Solver{
+		source:    source,
+		opts:      opts,
+		estimator: opts.Estimator,
+		seen:      make(map[Graph]struct{}),
+		seenExact: make(map[[9]byte]struct{}),
+		startTime: time.Now(),
+	}
+	if s.estimator == nil {
+		s.estimator = &progressEstimator{source: source, start: s.startTime}
+	}
+	for _, target := range opts.Targets {
+		s.targets = append(s.targets, &targetSolutions{target: target, tolerance: opts.Tolerance, top: opts.Top})
+	}
+	return s, nil
+}
+
+// ErrResumeMismatch is returned by Solver.Resume when the summary comes from a run with other targets.
+var ErrResumeMismatch = errors.New("summary doesn't match the solver's targets")
+
+// Resume continues a previous run from its summary, e.g. saved to a checkpoint: the counts and the best solutions are
+// restored, and the elapsed time adds up. The source must only return the graphs which the previous run didn't
+// process. The graph of each solution is rebuilt from its Matrix and InitialVertex. Resume must be called before Next.
+func (s *Solver) Resume(previous Summary) error {
+	if len(previous.Results) != len(s.targets) {
+		return fmt.Errorf("%w: %d targets, expecting %d", ErrResumeMismatch, len(previous.Results), len(s.targets))
+	}
+	for i, result := range previous.Results {
+		t := s.targets[i]
+		if result.Target != t.target {
+			return fmt.Errorf("%w: target %g, expecting %g", ErrResumeMismatch, result.Target, t.target)
+		}
+		for _, sol := range result.Best {
+			g, err := ParseGraph(sol.Matrix)
+			if err != nil {
+				return fmt.Errorf("%w: solution from graph %d: %s", ErrResumeMismatch, sol.Number, err)
+			}
+			t.record(g, sol, sol.Distance)
+		}
+	}
+	s.summary = previous
+	s.summary.Results = nil
+	s.summary.Elapsed = 0
+	s.summary.Interrupted = false
+	s.startTime = s.startTime.Add(-previous.Elapsed)
+	return nil
+}
+
+// Next reads and processes the next graph of the source. ok is false once the source is exhausted, or when ctx is
+// cancelled: the graph in flight is then abandoned and the summary is marked as interrupted.
+func (s *Solver) Next(ctx context.Context) (step Step, ok bool, err error) {
+	opts := &s.opts
+	if ctx.Err() != nil {
+		s.summary.Interrupted = true
+		return Step{}, false, nil
+	}
+	number, matrix, ok := s.source.Next()
+	if !ok {
+		return Step{}, false, nil
+	}
+	step = Step{Number: number, Matrix: matrix, Skipped: true}
+	s.summary.Processed++
+	graphStartTime := time.Now()
+	g, err := ParseGraph(matrix)
+	if err == nil && !opts.Directed && !g.Symmetric() {
+		err = ErrAsymmetric
+	}
+	rows := int(g.Size())
+	if err != nil {
+		// the size of malformed lines is guessed, the same way as when planning the work
+		rows = GuessSize(matrix)
+		if opts.Strict {
+			return step, false, fmt.Errorf("graph %d: %w", number, err)
+		}
+		if opts.OnMalformed != nil {
+			opts.OnMalformed(number, matrix, err)
+		}
+		s.summary.Malformed++
+		s.estimator.Skipped(rows)
+		return step, true, nil
+	}
+	if opts.Filter != nil && !opts.Filter(g) {
+		s.summary.Filtered++
+		s.estimator.Skipped(rows)
+		return step, true, nil
+	}
+	if opts.DedupeExact {
+		key := g.Compact()
+		if _, ok := s.seenExact[key]; ok {
+			s.summary.Duplicates++
+			s.estimator.Skipped(rows)
+			return step, true, nil
+		}
+		s.seenExact[key] = struct{}{}
+	}
+	if opts.DedupeIsomorphic {
+		key := g.Canonical()
+		if _, ok := s.seen[key]; ok {
+			s.summary.Isomorphic++
+			s.estimator.Skipped(rows)
+			return step, true, nil
+		}
+		s.seen[key] = struct{}{}
+	}
+	step.Skipped = false
+
+	r, err := opts.compute(ctx, g)
+	var intervals [][]Interval
+	if err == nil && opts.Interval {
+		intervals, err = g.ComputeIntervalDays(ctx, opts.MinDays, opts.MaxDays, opts.Rate)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// the graph in flight doesn't count as processed
+		s.summary.Processed--
+		s.summary.Interrupted = true
+		return Step{}, false, nil
+	}
+	if err != nil {
+		return step, false, fmt.Errorf("graph %d: %w", number, err)
+	}
+
+	notify := func(sol Solution, improved bool) {
+		step.Matches++
+		step.Improved = step.Improved || improved
+		if improved && opts.OnImproved != nil {
+			opts.OnImproved(sol)
+		}
+		if opts.OnMatch != nil {
+			opts.OnMatch(sol)
+		}
+	}
+	if opts.NearMiss > 0 {
+		s.summary.NearMisses += opts.nearMisses(g, number, matrix, r)
+	}
+	if len(opts.Constraints) > 0 {
+		s.targets[0].considerConstraints(g, number, matrix, r, opts, notify)
+	} else {
+		for _, t := range s.targets {
+			for d, values := range r {
+				for i, v := range values {
+					sol := Solution{Number: number, Matrix: matrix, InitialVertex: uint8(i), Days: opts.MinDays + uint(d), Value: v}
+					if intervals != nil {
+						switch intervals[d][i].Classify(t.target, t.tolerance) {
+						case Outside:
+							continue
+						case Undecided:
+							s.summary.Undecided++
+							if opts.OnUndecided != nil {
+								sol.Graph = g
+								sol.Graph.Pivot(sol.InitialVertex)
+								sol.Target = t.target
+								sol.Distance = math.Abs(v - t.target)
+								opts.OnUndecided(sol)
+							}
+							continue
+						}
+					}
+					if sol, improved, ok := t.consider(g, sol); ok {
+						notify(sol, improved)
+					}
+				}
+			}
+		}
+	}
+	s.summary.Matches += step.Matches
+
+	s.estimator.Processed(rows, time.Since(graphStartTime))
+	if opts.OnProgress != nil && (opts.ProgressInterval == 0 || time.Since(s.lastProgress) >= opts.ProgressInterval) {
+		s.lastProgress = time.Now()
+		opts.OnProgress(s.summary.Processed, opts.Total, s.targets[0].bestValue, s.estimator.ETA())
+	}
+	return step, true, nil
+}
+
+// Best returns the solution closest to the first target so far: the pivoted graph, the initially infected vertex
+// before pivoting, and the probability. ok is false until a solution is found.
+func (s *Solver) Best() (g Graph, initialVertex uint8, value float64, ok bool) {
+	best := s.targets[0].best.sorted()
+	if len(best) == 0 {
+		return Graph{}, 0, 0, false
+	}
+	return best[0].Graph, best[0].InitialVertex, best[0].Value, true
+}
+
+// Progress returns the number of graphs read from the source so far, and SolveOptions.Total.
+func (s *Solver) Progress() (processed, total int) {
+	return s.summary.Processed, s.opts.Total
+}
+
+// Summary describes the graphs processed so far, with the best solutions for each target.
+func (s *Solver) Summary() Summary {
+	summary := s.summary
+	summary.Results = nil
+	for _, t := range s.targets {
+		summary.Results = append(summary.Results, TargetResult{Target: t.target, Best: t.best.sorted()})
+	}
+	summary.Elapsed = time.Since(s.startTime)
+	return summary
+}
@@ -0,0 +1,352 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Random graphs with up to 6 vertices, with a few duplicates.
+func testGraphs(count int) []Graph {
+	rng := rand.New(rand.NewSource(1))
+	var graphs []Graph
+	for i := 0; i < count; i++ {
+		if i > 0 && rng.Intn(10) == 0 {
+			graphs = append(graphs, graphs[rng.Intn(i)])
+			continue
+		}
+		graphs = append(graphs, RandomGraph(rng, uint8(2+rng.Intn(5)), rng.Float64()))
+	}
+	return graphs
+}
+
+func testSolveOptions() SolveOptions {
+	return SolveOptions{Targets: []float64{0.3, 0.6}, Tolerance: 0.05, Top: 3, MinDays: 2, MaxDays: 4, Rate: 0.3, Total: 200}
+}
+
+func TestSolverMatchesSolve(t *testing.T) {
+	graphs := testGraphs(200)
+	improvements := 0
+	opts := testSolveOptions()
+	opts.OnImproved = func(s Solution) { improvements++ }
+	want, err := Solve(context.Background(), NewSliceSource(graphs), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Matches == 0 || improvements == 0 {
+		t.Fatalf("%d matches and %d improvements, the test graphs are too few", want.Matches, improvements)
+	}
+
+	s, err := NewSolver(NewSliceSource(graphs), testSolveOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, ok := s.Best(); ok {
+		t.Errorf("Best found a solution before the first graph")
+	}
+	steps, improved, matches := 0, 0, 0
+	for {
+		step, ok, err := s.Next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		steps++
+		if step.Number != steps || step.Matrix != graphs[steps-1].Matrix() || step.Skipped {
+			t.Fatalf("step %d: got %+v", steps, step)
+		}
+		if step.Improved {
+			improved++
+		}
+		matches += step.Matches
+		if processed, total := s.Progress(); processed != steps || total != 200 {
+			t.Fatalf("step %d: Progress() = %d, %d", steps, processed, total)
+		}
+	}
+	got := s.Summary()
+	got.Elapsed, want.Elapsed = 0, 0
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Next gives %+v, Solve %+v", got, want)
+	}
+	if steps != 200 || matches != want.Matches {
+		t.Errorf("%d steps with %d matches, want 200 and %d", steps, matches, want.Matches)
+	}
+	// OnImproved is called once per target improved, Step.Improved once per graph
+	if improved == 0 || improved > improvements {
+		t.Errorf("%d steps improved a solution, OnImproved was called %d times", improved, improvements)
+	}
+	g, initialVertex, value, ok := s.Best()
+	best := want.Results[0].Best[0]
+	if !ok || g != best.Graph || initialVertex != best.InitialVertex || value != best.Value {
+		t.Errorf("Best() = %s, %d, %g, %t, want %s, %d, %g", g.Matrix(), initialVertex, value, ok, best.Graph.Matrix(), best.InitialVertex,
+			best.Value)
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"empty file", "", nil},
+		{"newlines", "01,10\n011,101,110\n", []string{"01,10", "011,101,110"}},
+		{"CRLF", "01,10\r\n011,101,110\r\n", []string{"01,10", "011,101,110"}},
+		{"no final newline", "01,10\n011,101,110", []string{"01,10", "011,101,110"}},
+		{"empty line", "01,10\n\n0", []string{"01,10", "", "0"}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i)))
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			s, err := OpenFileSource(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			var got []string
+			var offsets []int64
+			for {
+				offsets = append(offsets, s.Offset())
+				number, line, ok := s.Next()
+				if !ok {
+					break
+				}
+				if number != len(got)+1 {
+					t.Errorf("line %d numbered %d", len(got)+1, number)
+				}
+				got = append(got, line)
+			}
+			if s.Err() != nil || !reflect.DeepEqual(got, tt.want) || s.Progress() != 1 {
+				t.Fatalf("got %q, progress %g, error %v, want %q", got, s.Progress(), s.Err(), tt.want)
+			}
+			// every line can be read again from its offset
+			for k := len(got) - 1; k >= 0; k-- {
+				if err := s.SeekLine(offsets[k], k); err != nil {
+					t.Fatal(err)
+				}
+				if number, line, ok := s.Next(); !ok || number != k+1 || line != got[k] {
+					t.Errorf("after SeekLine(%d, %d): got line %d %q, %t, want line %d %q", offsets[k], k, number, line, ok, k+1, got[k])
+				}
+			}
+		})
+	}
+	if _, err := OpenFileSource(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("OpenFileSource of a missing file: got error %v", err)
+	}
+}
+
+func TestSolveFileSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	graphs := testGraphs(200)
+	var lines []string
+	for _, g := range graphs {
+		lines = append(lines, g.Matrix())
+	}
+	path := filepath.Join(dir, "graphs.txt")
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\r\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want, err := Solve(context.Background(), NewSliceSource(graphs), testSolveOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := OpenFileSource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	got, err := Solve(context.Background(), source, testSolveOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Elapsed, want.Elapsed = 0, 0
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("reading the graphs from a file gives %+v, from a slice %+v", got, want)
+	}
+}
+
+func TestSolveMalformed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "graphs.txt")
+	if err := ioutil.WriteFile(path, []byte("01,10\n01,1\n0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, strict := range []bool{false, true} {
+		source, err := OpenFileSource(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var malformed []int
+		opts := testSolveOptions()
+		opts.Strict = strict
+		opts.OnMalformed = func(number int, matrix string, err error) {
+			malformed = append(malformed, number)
+		}
+		summary, err := Solve(context.Background(), source, opts)
+		source.Close()
+		if strict {
+			if !errors.Is(err, ErrNotSquare) || summary.Processed != 2 || malformed != nil {
+				t.Errorf("strict: got error %v after %d graphs, OnMalformed called for %v", err, summary.Processed, malformed)
+			}
+			continue
+		}
+		if err != nil || summary.Processed != 3 || summary.Malformed != 1 || !reflect.DeepEqual(malformed, []int{2}) {
+			t.Errorf("got error %v after %d graphs, %d malformed, OnMalformed called for %v", err, summary.Processed, summary.Malformed, malformed)
+		}
+	}
+}
+
+func TestSolveAsymmetric(t *testing.T) {
+	dir, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "graphs.txt")
+	if err := ioutil.WriteFile(path, []byte("01,10\n01,00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, directed := range []bool{false, true} {
+		source, err := OpenFileSource(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var malformed []error
+		opts := testSolveOptions()
+		opts.Directed = directed
+		opts.OnMalformed = func(number int, matrix string, err error) {
+			malformed = append(malformed, err)
+		}
+		summary, err := Solve(context.Background(), source, opts)
+		source.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if directed && (summary.Malformed != 0 || malformed != nil) {
+			t.Errorf("directed: %d malformed, OnMalformed called with %v", summary.Malformed, malformed)
+		}
+		if !directed && (summary.Malformed != 1 || len(malformed) != 1 || !errors.Is(malformed[0], ErrAsymmetric)) {
+			t.Errorf("%d malformed, OnMalformed called with %v, want %v", summary.Malformed, malformed, ErrAsymmetric)
+		}
+	}
+}
+
+func TestSolverResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	graphs := testGraphs(200)
+	var lines []string
+	for _, g := range graphs {
+		lines = append(lines, g.Matrix())
+	}
+	lines[50] = "01,1" // malformed
+	path := filepath.Join(dir, "graphs.txt")
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(source Source, previous *Summary) Summary {
+		s, err := NewSolver(source, testSolveOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if previous != nil {
+			if err := s.Resume(*previous); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for {
+			_, ok, err := s.Next(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				return s.Summary()
+			}
+		}
+	}
+
+	source, err := OpenFileSource(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	want := run(source, nil)
+	want.Elapsed = 0
+	for _, interrupted := range []int{0, 1, 50, 51, 137, 200} {
+		// the first run stops after some graphs, the second one continues from its summary and position
+		if err := source.SeekLine(0, 0); err != nil {
+			t.Fatal(err)
+		}
+		first, err := NewSolver(source, testSolveOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < interrupted; i++ {
+			if _, _, err := first.Next(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		previous := first.Summary()
+		if err := source.SeekLine(source.Offset(), interrupted); err != nil {
+			t.Fatal(err)
+		}
+		got := run(source, &previous)
+		if got.Elapsed < previous.Elapsed {
+			t.Errorf("interrupted after %d graphs: %s elapsed, %s before resuming", interrupted, got.Elapsed, previous.Elapsed)
+		}
+		got.Elapsed = 0
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("interrupted after %d graphs: got %+v, want %+v", interrupted, got, want)
+		}
+	}
+}
+
+func TestSolverResumeMismatch(t *testing.T) {
+	previous, err := Solve(context.Background(), NewSliceSource(testGraphs(20)), testSolveOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := testSolveOptions()
+	opts.Targets = opts.Targets[:1]
+	s, err := NewSolver(NewSliceSource(nil), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Resume(previous); !errors.Is(err, ErrResumeMismatch) {
+		t.Errorf("resuming with fewer targets: got error %v, want %v", err, ErrResumeMismatch)
+	}
+	opts.Targets = []float64{0.3, 0.7}
+	if s, err = NewSolver(NewSliceSource(nil), opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Resume(previous); !errors.Is(err, ErrResumeMismatch) {
+		t.Errorf("resuming with other targets: got error %v, want %v", err, ErrResumeMismatch)
+	}
+}
@@ -0,0 +1,67 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/teivah/bitvector"
+)
+
+// ErrTargetUnreachable is returned by SolveRate when no rate gives the target probability.
+var ErrTargetUnreachable = errors.New("no rate reaches the target")
+
+// RateTolerance is the precision of the rate returned by SolveRate.
+const RateTolerance = 1e-12
+
+// SolveRate returns the smallest rate at which every vertex is infected within the given number of days with
+// probability at least target, when vertex initial is infected on day 0. The probability grows with the rate, so the
+// rate is found by bisection, within RateTolerance. The returned error wraps ErrTargetUnreachable when the target is
+// above the probability at rate 1, e.g. when the graph is disconnected.
+func (g *Graph) SolveRate(ctx context.Context, days uint, target float64, initial uint8, opts ...Option) (float64, error) {
+	o, err := newOptions(0, opts)
+	if err != nil {
+		return 0, err
+	}
+	if initial >= g.size {
+		return 0, fmt.Errorf("%w: initial vertex %d, graph has %d vertices", ErrVertexOutOfRange, initial, g.size)
+	}
+	if !(target >= 0 && target <= 1) {
+		return 0, fmt.Errorf("invalid target %g, expecting a probability in [0, 1]", target)
+	}
+	masks := g.neighborMasks()
+	var state bitvector.Len8
+	state = state.Set(initial, true)
+	probability := func(rate float64) (float64, error) {
+		r, err := g.computeStatesDays(ctx, masks, o, days, days, rate, []bitvector.Len8{state})
+		if err != nil {
+			return 0, err
+		}
+		return r[0][0], nil
+	}
+
+	// the ends are checked first: low stays below the target and high reaches it
+	low, high := 0.0, 1.0
+	p, err := probability(low)
+	if err != nil || p >= target {
+		return low, err
+	}
+	if p, err = probability(high); err != nil {
+		return 0, err
+	}
+	if p < target {
+		return 0, fmt.Errorf("%w: %g after %d days, the probability is at most %g", ErrTargetUnreachable, target, days, p)
+	}
+	for high-low > RateTolerance {
+		mid := (low + high) / 2
+		if p, err = probability(mid); err != nil {
+			return 0, err
+		}
+		if p < target {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return high, nil
+}
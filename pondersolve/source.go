@@ -0,0 +1,112 @@
+package pondersolve
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// SliceSource is a Source returning graphs from a slice, numbered from 1.
+type SliceSource struct {
+	graphs []Graph
+	next   int
+}
+
+// NewSliceSource returns a Source for the given graphs.
+func NewSliceSource(graphs []Graph) *SliceSource {
+	return &SliceSource{graphs: graphs}
+}
+
+// Next implements Source.
+func (s *SliceSource) Next() (int, string, bool) {
+	if s.next == len(s.graphs) {
+		return 0, "", false
+	}
+	s.next++
+	return s.next, s.graphs[s.next-1].Matrix(), true
+}
+
+// Progress implements Source.
+func (s *SliceSource) Progress() float64 {
+	if len(s.graphs) == 0 {
+		return 1
+	}
+	return float64(s.next) / float64(len(s.graphs))
+}
+
+// FileSource is a Source reading one matrix per line from a file, numbered by line starting at 1. Lines end with "\n"
+// or "\r\n", the last one can have neither. Its progress is the fraction of the file's bytes read so far.
+type FileSource struct {
+	file       *os.File
+	reader     *bufio.Reader
+	size       int64
+	read       int64
+	lineNumber int
+	err        error
+}
+
+// OpenFileSource opens a database file. The caller must Close it.
+func OpenFileSource(path string) (*FileSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileSource{file: file, reader: bufio.NewReader(file), size: info.Size()}, nil
+}
+
+// Next implements Source. It returns false on read errors, see Err.
+func (s *FileSource) Next() (int, string, bool) {
+	line, err := s.reader.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return 0, "", false
+	}
+	// the last line doesn't always end with a newline
+	if err != nil && err != io.EOF {
+		s.err = err
+		return 0, "", false
+	}
+	s.read += int64(len(line))
+	s.lineNumber++
+	return s.lineNumber, strings.TrimRight(line, "\r\n"), true
+}
+
+// Progress implements Source.
+func (s *FileSource) Progress() float64 {
+	if s.size == 0 {
+		return 1
+	}
+	return float64(s.read) / float64(s.size)
+}
+
+// Err returns the error which stopped Next early, if any.
+func (s *FileSource) Err() error {
+	return s.err
+}
+
+// Offset returns the number of bytes up to the end of the last line returned by Next, which is where the next line
+// starts. It can be given back to SeekLine.
+func (s *FileSource) Offset() int64 {
+	return s.read
+}
+
+// SeekLine moves to offset, which must be the start of a line: the next call to Next reads from there and numbers that
+// line lineNumber+1.
+func (s *FileSource) SeekLine(offset int64, lineNumber int) error {
+	if _, err := s.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	s.reader.Reset(s.file)
+	s.read, s.lineNumber, s.err = offset, lineNumber, nil
+	return nil
+}
+
+// Close closes the file.
+func (s *FileSource) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,99 @@
+package pondersolve
+
+// Structural properties of undirected graphs. Graphs are tiny, so every property is computed with a breadth-first
+// search from each vertex.
+
+// Returns the number of edges between v and every vertex, -1 for the vertices which can't be reached from v.
+func (g *Graph) distances(v uint8) []int {
+	r := make([]int, g.size)
+	for i := range r {
+		r[i] = -1
+	}
+	r[v] = 0
+	queue := []uint8{v}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, n := range g.Neighbors(current) {
+			if r[n] == -1 {
+				r[n] = r[current] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+	return r
+}
+
+// Components returns the connected components, each in increasing order. Components are sorted by their smallest
+// vertex.
+func (g *Graph) Components() [][]uint8 {
+	var r [][]uint8
+	assigned := make([]bool, g.size)
+	for v := uint8(0); v < g.size; v++ {
+		if assigned[v] {
+			continue
+		}
+		var component []uint8
+		for i, d := range g.distances(v) {
+			if d >= 0 {
+				component = append(component, uint8(i))
+				assigned[i] = true
+			}
+		}
+		r = append(r, component)
+	}
+	return r
+}
+
+// Eccentricities returns, for each vertex, the largest distance to another vertex. The eccentricity is -1 when some
+// vertices can't be reached.
+func (g *Graph) Eccentricities() []int {
+	r := make([]int, g.size)
+	for v := uint8(0); v < g.size; v++ {
+		for _, d := range g.distances(v) {
+			if d == -1 {
+				r[v] = -1
+				break
+			}
+			if d > r[v] {
+				r[v] = d
+			}
+		}
+	}
+	return r
+}
+
+// Diameter returns the largest distance between two vertices, -1 when the graph isn't connected.
+func (g *Graph) Diameter() int {
+	r := 0
+	for _, e := range g.Eccentricities() {
+		if e == -1 {
+			return -1
+		}
+		if e > r {
+			r = e
+		}
+	}
+	return r
+}
+
+// IsTree checks whether the graph is connected and has no cycles.
+func (g *Graph) IsTree() bool {
+	return g.size > 0 && g.Connected() && g.EdgeCount() == int(g.size)-1
+}
+
+// Bipartite checks whether the vertices can be split into two sets, with every edge going from one set to the other.
+func (g *Graph) Bipartite() bool {
+	for v := uint8(0); v < g.size; v++ {
+		d := g.distances(v)
+		for i := uint8(0); i < g.size; i++ {
+			for j := uint8(0); j < g.size; j++ {
+				// an edge between two vertices at the same distance from v closes an odd cycle
+				if d[i] >= 0 && d[i] == d[j] && g.HasEdge(i, j) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,130 @@
+package pondersolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/teivah/bitvector"
+)
+
+// ErrInvalidSchedule is returned by ComputeSchedule when the graphs of the schedule can't be used together.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ComputeSchedule is like Compute, for contacts which change from day to day: the infection spreads along the edges of
+// schedule[k % len(schedule)] between day k and day k+1. Every graph of the schedule must have the same number of
+// vertices. With a single graph, the result is the same as Compute's.
+func ComputeSchedule(ctx context.Context, schedule []Graph, days uint, rate float64, opts ...Option) ([]float64, error) {
+	o, err := newOptions(rate, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedule) == 0 {
+		return nil, fmt.Errorf("%w: no graphs", ErrInvalidSchedule)
+	}
+	g := &schedule[0]
+	masks := make([]*neighborMasks, len(schedule))
+	for k := range schedule {
+		if schedule[k].size != g.size {
+			return nil, fmt.Errorf("%w: graph %d has %d vertices, graph 0 has %d", ErrInvalidSchedule, k, schedule[k].size, g.size)
+		}
+		masks[k] = schedule[k].neighborMasks()
+	}
+	initial := g.initialStates(o.firstResultOnly)
+
+	var r []float64
+	switch o.algorithm {
+	case DP, Lumped, MatrixPower:
+		// the graphs of a schedule don't share their automorphisms or transition matrix, they are computed like DP
+		r, err = g.scheduleTable(ctx, masks, days, rate, o.model, initial)
+	default:
+		r, err = g.scheduleRecursive(ctx, masks, days, rate, o.model, initial, o.algorithm == Memoized)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Same as dpTable, with the transitions of the graph scheduled on each day. The table is filled backwards from the
+// last day: probs[state] is the probability of infecting every vertex by the end, starting from state on the current
+// day.
+func (g *Graph) scheduleTable(ctx context.Context, masks []*neighborMasks, days uint, rate float64, model TransmissionModel, initial []bitvector.Len8) ([]float64, error) {
+	lastState := (1 << g.size) - 1
+	m := make([][][]stateProbability, len(masks))
+	var probs [256]float64
+	probs[lastState] = 1.0
+	for day := int(days) - 1; day >= 0; day-- {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		k := day % len(masks)
+		if m[k] == nil {
+			m[k] = make([][]stateProbability, lastState+1)
+			for state := 0; state <= lastState; state++ {
+				m[k][state] = g.enumerateNextStates(masks[k], bitvector.Len8(state), rate, model, 0)
+			}
+		}
+		var current [256]float64
+		for state := 0; state <= lastState; state++ {
+			p := 0.0
+			for _, nextState := range m[k][state] {
+				p += nextState.probability * probs[nextState.state]
+			}
+			current[state] = p
+		}
+		probs = current
+	}
+	var r []float64
+	for _, state := range initial {
+		r = append(r, probs[state])
+	}
+	return r, nil
+}
+
+// Same as _computeRecursive, with the graph scheduled on each day. With memoize, each (day, state) pair is only
+// computed once, like computeMemoized.
+func (g *Graph) scheduleRecursive(ctx context.Context, masks []*neighborMasks, days uint, rate float64, model TransmissionModel, initial []bitvector.Len8, memoize bool) ([]float64, error) {
+	type key struct {
+		day   uint
+		state bitvector.Len8
+	}
+	memo := make(map[key]float64)
+	var compute func(day uint, state bitvector.Len8) (float64, error)
+	compute = func(day uint, state bitvector.Len8) (float64, error) {
+		if state.Count() == g.size {
+			return 1.0, nil
+		}
+		if day == days {
+			return 0.0, nil
+		}
+		if p, ok := memo[key{day, state}]; ok {
+			return p, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		r := 0.0
+		for _, nextState := range g.enumerateNextStates(masks[day%uint(len(masks))], state, rate, model, 0) {
+			p, err := compute(day+1, nextState.state)
+			if err != nil {
+				return 0, err
+			}
+			r += p * nextState.probability
+		}
+		if memoize {
+			memo[key{day, state}] = r
+		}
+		return r, nil
+	}
+
+	var r []float64
+	for _, state := range initial {
+		p, err := compute(0, state)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, p)
+	}
+	return r, nil
+}
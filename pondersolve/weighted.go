@@ -0,0 +1,135 @@
+package pondersolve
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/teivah/bitvector"
+)
+
+// EdgeRates holds a daily transmission probability for each edge: rates[i][j] is the probability that vertex i infects
+// vertex j along the edge from i to j, see HasEdge.
+type EdgeRates [MaxSize][MaxSize]float64
+
+// UniformRates returns the rates of Compute: every edge of g has the same rate.
+func UniformRates(g *Graph, rate float64) EdgeRates {
+	var rates EdgeRates
+	for i := uint8(0); i < g.size; i++ {
+		for j := uint8(0); j < g.size; j++ {
+			if g.HasEdge(i, j) {
+				rates[i][j] = rate
+			}
+		}
+	}
+	return rates
+}
+
+// ParseWeightedMatrix parses an adjacency matrix whose cells are the rates of the edges, with rows separated by
+// semicolons and cells by commas, e.g. "0,0.1,0.3;0.1,0,0;0.3,0,0". Cells with a rate of 0 have no edge. The returned
+// error wraps ErrTooLarge, ErrNotSquare, ErrBadCharacter or ErrInvalidRate.
+func ParseWeightedMatrix(matrix string) (Graph, EdgeRates, error) {
+	rows := strings.Split(matrix, ";")
+	g, err := newGraph(len(rows))
+	if err != nil {
+		return Graph{}, EdgeRates{}, err
+	}
+	var rates EdgeRates
+	for i, row := range rows {
+		cells := strings.Split(row, ",")
+		if len(cells) != len(rows) {
+			return Graph{}, EdgeRates{}, fmt.Errorf("%w: row %d has length %d but expecting %d", ErrNotSquare, i, len(cells), len(rows))
+		}
+		for j, cell := range cells {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(cell), 64)
+			if err != nil {
+				return Graph{}, EdgeRates{}, fmt.Errorf("%w: %q", ErrBadCharacter, cell)
+			}
+			if !(rate >= 0 && rate <= 1) {
+				return Graph{}, EdgeRates{}, fmt.Errorf("%w: %g in row %d", ErrInvalidRate, rate, i)
+			}
+			if rate > 0 {
+				g.addEdge(uint8(i), uint8(j))
+				rates[i][j] = rate
+			}
+		}
+	}
+	return *g, rates, nil
+}
+
+// ComputeEdgeRates is like Compute, with its own rate for each edge instead of a single rate: a vertex v escapes the
+// infection on a given day with the product of 1-rates[u][v] over the infected vertices u which have an edge to v.
+// Rates of missing edges are ignored. Only the Independent model is supported, and every algorithm is computed like DP.
+// With UniformRates, the result is the same as Compute's.
+func (g *Graph) ComputeEdgeRates(ctx context.Context, days uint, rates *EdgeRates, opts ...Option) ([]float64, error) {
+	// the rates are checked below
+	o, err := newOptions(0, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIndependent(o.model); err != nil {
+		return nil, err
+	}
+	for i := uint8(0); i < g.size; i++ {
+		for j := uint8(0); j < g.size; j++ {
+			if rate := rates[i][j]; g.HasEdge(i, j) && !(rate >= 0 && rate <= 1) {
+				return nil, fmt.Errorf("%w: %g for the edge from %d to %d", ErrInvalidRate, rate, i, j)
+			}
+		}
+	}
+
+	masks := g.neighborMasks()
+	lastState := (1 << g.size) - 1
+	m := make([][]stateProbability, lastState+1)
+	for state := 0; state <= lastState; state++ {
+		m[state] = g.edgeRatesNextStates(masks, rates, bitvector.Len8(state))
+	}
+	// same as scheduleTable, filled backwards from the last day
+	var probs [256]float64
+	probs[lastState] = 1.0
+	for day := uint(0); day < days; day++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var current [256]float64
+		for state := 0; state <= lastState; state++ {
+			p := 0.0
+			for _, nextState := range m[state] {
+				p += nextState.probability * probs[nextState.state]
+			}
+			current[state] = p
+		}
+		probs = current
+	}
+	return g.initialStateProbabilities(probs, o.firstResultOnly), nil
+}
+
+// Same as enumerateNextStates, with the rate of each edge.
+func (g *Graph) edgeRatesNextStates(masks *neighborMasks, rates *EdgeRates, state bitvector.Len8) []stateProbability {
+	r := []stateProbability{{state: state, probability: 1.0}}
+	for v := uint8(0); v < g.size; v++ {
+		if state.Get(v) {
+			continue
+		}
+		escaped := 1.0
+		for u := uint8(0); u < g.size; u++ {
+			if masks[v].Get(u) && state.Get(u) {
+				escaped *= 1.0 - rates[u][v]
+			}
+		}
+		if escaped == 1.0 {
+			continue
+		}
+		// outcomes which can't happen are left out, like in enumerateNextStates
+		var expanded []stateProbability
+		for _, s := range r {
+			if escaped != 0 {
+				expanded = append(expanded, stateProbability{state: s.state, probability: s.probability * escaped})
+			}
+			expanded = append(expanded, stateProbability{state: s.state.Set(v, true), probability: s.probability * (1.0 - escaped)})
+		}
+		r = expanded
+	}
+	return r
+}
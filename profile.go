@@ -0,0 +1,101 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	_ "net/http/pprof" // registers the /debug/pprof handlers, served by --pprof-listen
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+)
+
+// Profiling flags, accepted by every command.
+type profileFlags struct {
+	CPUProfile  string `name:"cpuprofile" type:"path" help:"write a CPU profile to this file. compute and solve only profile the computation, not reading the input and printing the results"`
+	MemProfile  string `name:"memprofile" type:"path" help:"write a heap profile to this file once the command is done"`
+	PprofListen string `help:"serve net/http/pprof on this address for live inspection, e.g. \":6060\""`
+}
+
+// Serves net/http/pprof in the background, when requested.
+func (p profileFlags) serve() {
+	if p.PprofListen == "" {
+		return
+	}
+	go func() {
+		log.Print(http.ListenAndServe(p.PprofListen, nil))
+	}()
+}
+
+var (
+	cpuProfile  *os.File // --cpuprofile, nil when it isn't requested
+	cpuRunning  bool
+	memProfile  *os.File // --memprofile, nil when it isn't requested
+	stopProfile sync.Once
+)
+
+// Creates the profile files before running the command, so that a bad path is reported at once rather than after a
+// long computation.
+func (p profileFlags) create() {
+	var err error
+	if p.CPUProfile != "" {
+		if cpuProfile, err = os.Create(p.CPUProfile); err != nil {
+			fatalf("invalid --cpuprofile: %s", err)
+		}
+	}
+	if p.MemProfile != "" {
+		if memProfile, err = os.Create(p.MemProfile); err != nil {
+			fatalf("invalid --memprofile: %s", err)
+		}
+	}
+}
+
+// Starts the CPU profile just before the computation. compute and solve start it themselves, after reading their input,
+// and stop it once the computation is done. main starts it for the other commands, which print as they compute.
+func startCPUProfile() {
+	if cpuProfile == nil || cpuRunning {
+		return
+	}
+	if err := pprof.StartCPUProfile(cpuProfile); err != nil {
+		log.Panic(err)
+	}
+	cpuRunning = true
+}
+
+// Stops the CPU profile right after the computation, before printing the results.
+func stopCPUProfile() {
+	if !cpuRunning {
+		return
+	}
+	pprof.StopCPUProfile()
+	cpuRunning = false
+}
+
+// Stops the CPU profile if it's still running and writes the heap profile. It must be called before exiting, see
+// exit. Calls after the first one do nothing.
+func stopProfiling() {
+	stopProfile.Do(func() {
+		stopCPUProfile()
+		if cpuProfile != nil {
+			if err := cpuProfile.Close(); err != nil {
+				log.Print(err)
+			}
+		}
+		if memProfile != nil {
+			// get up-to-date statistics
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(memProfile); err != nil {
+				log.Print(err)
+			}
+			if err := memProfile.Close(); err != nil {
+				log.Print(err)
+			}
+		}
+	})
+}
+
+// Exits with the given status, writing the profiles first: os.Exit doesn't run main's deferred calls.
+func exit(code int) {
+	stopProfiling()
+	os.Exit(code)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Writes independent Erdős–Rényi G(n, p) random graphs, in the format used by solve.
+func randomGraphs() {
+	n, p := args.RandomGraphs.N, args.RandomGraphs.P
+	file, err := os.Create(args.RandomGraphs.Out)
+	if err != nil {
+		fatalf("invalid --out: %s", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+
+	rng := rand.New(rand.NewSource(args.RandomGraphs.Seed))
+	histogram := make([]int, int(n)*int(n-1)/2+1)
+	rejected := 0
+	for written := 0; written < args.RandomGraphs.Count; {
+		g := pondersolve.RandomGraph(rng, n, p)
+		if args.RandomGraphs.ConnectedOnly && !g.Connected() {
+			rejected++
+			continue
+		}
+		if _, err := fmt.Fprintln(w, g.Matrix()); err != nil {
+			log.Panic(err)
+		}
+		histogram[g.EdgeCount()]++
+		written++
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+
+	fmt.Printf("%d graphs written to %s\n", args.RandomGraphs.Count, args.RandomGraphs.Out)
+	if args.RandomGraphs.ConnectedOnly {
+		fmt.Printf("%d disconnected graphs rejected\n", rejected)
+	}
+	fmt.Println("edge count distribution:")
+	for edges, count := range histogram {
+		if count > 0 {
+			fmt.Printf("%2d edges: %d (%.2f%%)\n", edges, count, float64(count)*100/float64(args.RandomGraphs.Count))
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Upper bound on the number of rates in a sweep, which catches steps which are too small by mistake.
+const maxSweepRates = 100000
+
+// Parses a range of rates, "start:end:step", e.g. "0.05:0.20:0.01". end is included if it's a multiple of step away
+// from start.
+func parseRateSweep(spec string) ([]float64, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid rate sweep %q, expecting start:end:step", spec)
+	}
+	var values [3]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(v) {
+			return nil, fmt.Errorf("invalid rate sweep %q: %q isn't a number", spec, part)
+		}
+		values[i] = v
+	}
+	start, end, step := values[0], values[1], values[2]
+	if start < 0 || end > 1 {
+		return nil, fmt.Errorf("invalid rate sweep %q: rates must be between 0 and 1", spec)
+	}
+	if start > end {
+		return nil, fmt.Errorf("invalid rate sweep %q: start %g is larger than end %g", spec, start, end)
+	}
+	if !(step > 0) {
+		return nil, fmt.Errorf("invalid rate sweep %q: step must be positive", spec)
+	}
+	// the small epsilon keeps end in the range despite rounding errors, e.g. (0.2-0.05)/0.01 = 14.999999999999998
+	count := math.Floor((end-start)/step+1e-9) + 1
+	if count > maxSweepRates {
+		return nil, fmt.Errorf("invalid rate sweep %q: more than %d rates", spec, maxSweepRates)
+	}
+	rates := make([]float64, int(count))
+	for k := range rates {
+		// computing each rate from start doesn't accumulate rounding errors, rounding hides the remaining ones
+		rates[k] = math.Min(math.Round((start+float64(k)*step)*1e12)/1e12, 1)
+	}
+	return rates, nil
+}
+
+// Prints the probability for each rate of a sweep, as a table or as CSV in args.Compute.CSVOut.
+func printRateSweep(rates []float64, r [][]float64) {
+	if args.Compute.CSVOut == "" {
+		fmt.Printf("probability of all vertices infected after %d days:\n", args.Compute.Days)
+		fmt.Printf("%-10s %s\n", "rate", "probability")
+		for k, rate := range rates {
+			fmt.Printf("%-10g %g%%\n", rate, r[k][0]*100.0)
+		}
+		return
+	}
+
+	file, err := os.Create(args.Compute.CSVOut)
+	if err != nil {
+		fatalf("invalid --csv-out: %s", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, "rate,days,probability")
+	for k, rate := range rates {
+		fmt.Fprintf(w, "%g,%d,%g\n", rate, args.Compute.Days, r[k][0])
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("%d rates written to %s\n", len(rates), args.Compute.CSVOut)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Returns the effective reproduction number for each day t from 1: the expected number of vertices infected on day t,
+// divided by the expected number of vertices infected at the start of day t. Infected vertices stay infected, so the
+// denominator is at least 1 and R_t goes to 0 as the epidemic runs out of vertices to infect. r[t-1] is day t.
+func reproductionNumbers(distributions [][]float64) (infected, newlyInfected, r []float64) {
+	previous, _ := pondersolve.InfectedMoments(distributions[0])
+	for d := 1; d < len(distributions); d++ {
+		mean, _ := pondersolve.InfectedMoments(distributions[d])
+		infected = append(infected, previous)
+		newlyInfected = append(newlyInfected, mean-previous)
+		r = append(r, (mean-previous)/previous)
+		previous = mean
+	}
+	return infected, newlyInfected, r
+}
+
+// Prints the effective reproduction number for each day, see reproductionNumbers.
+func printReproductionNumbers(distributions [][]float64) {
+	fmt.Printf("effective reproduction number, starting from vertex %d:\n", args.Compute.InitialVertex)
+	fmt.Printf("%-5s %-24s %-24s %s\n", "day", "infected at start", "newly infected", "R_t")
+	infected, newlyInfected, r := reproductionNumbers(distributions)
+	for d := range r {
+		fmt.Printf("%-5d %-24g %-24g %g\n", d+1, infected[d], newlyInfected[d], r[d])
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+func TestReproductionNumbers(t *testing.T) {
+	tests := []struct {
+		matrix        string
+		days          uint
+		rate          float64
+		infected      []float64
+		newlyInfected []float64
+		r             []float64
+	}{
+		// K2: the second vertex is infected on day t with probability rate * (1-rate)^(t-1)
+		{"01,10", 3, 0.5, []float64{1, 1.5, 1.75}, []float64{0.5, 0.25, 0.125}, []float64{0.5, 0.25 / 1.5, 0.125 / 1.75}},
+		// at rate 1, the path 0-1-2 is fully infected on day 2 and nobody is newly infected on day 3
+		{"010,101,010", 3, 1, []float64{1, 2, 3}, []float64{1, 1, 0}, []float64{1, 0.5, 0}},
+		// nobody is ever newly infected
+		{"00,00", 2, 0.5, []float64{1, 1}, []float64{0, 0}, []float64{0, 0}},
+		{"0", 2, 0.5, []float64{1, 1}, []float64{0, 0}, []float64{0, 0}},
+	}
+	for _, tt := range tests {
+		g, err := pondersolve.ParseMatrix(tt.matrix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		distributions, err := g.StateDistributions(context.Background(), tt.days, tt.rate, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		infected, newlyInfected, r := reproductionNumbers(distributions)
+		if len(r) != int(tt.days) || len(infected) != int(tt.days) || len(newlyInfected) != int(tt.days) {
+			t.Fatalf("%s, %d days: got %d days", tt.matrix, tt.days, len(r))
+		}
+		for d := range r {
+			if math.Abs(infected[d]-tt.infected[d]) > 1e-12 || math.Abs(newlyInfected[d]-tt.newlyInfected[d]) > 1e-12 ||
+				math.Abs(r[d]-tt.r[d]) > 1e-12 || math.IsNaN(r[d]) {
+				t.Errorf("%s at rate %g, day %d: got %g infected, %g newly infected, R_t %g, want %g, %g and %g", tt.matrix, tt.rate, d+1,
+					infected[d], newlyInfected[d], r[d], tt.infected[d], tt.newlyInfected[d], tt.r[d])
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math/rand"
+)
+
+// A line of the graphs database, selected for sampling.
+type sampledLine struct {
+	number   int // line number
+	vertices int // see pondersolve.GuessSize
+}
+
+// Uniform random sample of a fixed size, picked while streaming over the lines (reservoir sampling).
+type reservoir struct {
+	size  int
+	seen  int
+	lines []sampledLine
+	rand  *rand.Rand
+}
+
+// Returns a reservoir keeping size lines, or nil when no sampling is required.
+func newReservoir(size int, seed int64) *reservoir {
+	if size <= 0 {
+		return nil
+	}
+	return &reservoir{size: size, rand: rand.New(rand.NewSource(seed))}
+}
+
+func (r *reservoir) add(line sampledLine) {
+	r.seen++
+	if len(r.lines) < r.size {
+		r.lines = append(r.lines, line)
+		return
+	}
+	// replace a random line, so that every line seen so far has the same probability of being kept
+	if i := r.rand.Intn(r.seen); i < r.size {
+		r.lines[i] = line
+	}
+}
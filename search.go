@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Local search for a graph whose probability is close to the target, an alternative to scanning a database.
+type searcher struct {
+	rng         *rand.Rand
+	size        uint8
+	target      float64
+	tolerance   float64
+	days        uint
+	rate        float64
+	evaluations int
+	best        pondersolve.Solution
+	found       bool
+}
+
+// Computes the probability of infecting g for every initial vertex and keeps the one closest to the target. The
+// global best is updated (and printed) when it improves.
+func (s *searcher) evaluate(g pondersolve.Graph) pondersolve.Solution {
+	sol, _ := s.evaluateAll(g)
+	return sol
+}
+
+// Same as evaluate, but also returns the probabilities for every initial vertex.
+func (s *searcher) evaluateAll(g pondersolve.Graph) (pondersolve.Solution, []float64) {
+	s.evaluations++
+	r, err := g.Compute(context.Background(), s.days, s.rate)
+	if err != nil {
+		log.Panic(err)
+	}
+	sol := pondersolve.Solution{Matrix: g.Matrix(), Distance: math.Inf(1)}
+	for i, v := range r {
+		if distance := math.Abs(v - s.target); distance < sol.Distance {
+			sol.InitialVertex, sol.Value, sol.Distance = uint8(i), v, distance
+		}
+	}
+	sol.Graph = g
+	sol.Graph.Pivot(sol.InitialVertex)
+	if !s.found || sol.Distance < s.best.Distance {
+		fmt.Printf("Improved solution! v=%g (evaluation %d)\n", sol.Value, s.evaluations)
+		fmt.Printf("graph: %s\ninitial vertex: %d\n", sol.Matrix, sol.InitialVertex)
+		fmt.Println(sol.Graph)
+		s.best = sol
+		s.found = true
+	}
+	return sol, r
+}
+
+// Returns a random connected graph.
+func (s *searcher) randomConnectedGraph() pondersolve.Graph {
+	for {
+		if g := pondersolve.RandomGraph(s.rng, s.size, 0.5); g.Connected() {
+			return g
+		}
+	}
+}
+
+// Returns the starting graph for a search: the provided graph, or a random connected graph.
+func (s *searcher) start() pondersolve.Graph {
+	if args.Search.Graph == "" {
+		return s.randomConnectedGraph()
+	}
+	g, err := pondersolve.ParseMatrix(args.Search.Graph)
+	if err != nil {
+		log.Panic(err)
+	}
+	return g
+}
+
+// Repeatedly moves to the single edge toggle which gets closest to the target, restarting from a random graph when
+// no toggle improves the current graph.
+func (s *searcher) hillClimb() {
+	g := s.start()
+	for restart := 0; restart <= args.Search.Restarts; restart++ {
+		if restart > 0 {
+			g = s.randomConnectedGraph()
+		}
+		current := s.evaluate(g)
+		for step := 0; step < args.Search.MaxSteps && current.Distance >= s.tolerance; step++ {
+			bestNeighbor, bestDistance := g, current.Distance
+			for i := uint8(0); i < g.Size(); i++ {
+				for j := i + 1; j < g.Size(); j++ {
+					neighbor := g
+					neighbor.ToggleEdge(i, j)
+					if sol := s.evaluate(neighbor); sol.Distance < bestDistance {
+						bestNeighbor, bestDistance = neighbor, sol.Distance
+					}
+				}
+			}
+			if bestDistance == current.Distance {
+				// local optimum
+				break
+			}
+			g, current.Distance = bestNeighbor, bestDistance
+		}
+		if s.best.Distance < s.tolerance {
+			break
+		}
+	}
+}
+
+// Toggles a random edge at each step, accepting moves which get further from the target with probability exp(-Δ/T).
+// The temperature T starts at t0 and is multiplied by the cooling factor after each step. Moves which disconnect the
+// graph are allowed, their probability is 0.
+func (s *searcher) anneal() {
+	g := s.start()
+	current := s.evaluate(g)
+	temperature := args.Search.T0
+	for step := 0; step < args.Search.Steps && s.best.Distance >= s.tolerance; step++ {
+		i := uint8(s.rng.Intn(int(g.Size())))
+		j := uint8(s.rng.Intn(int(g.Size()) - 1))
+		if j >= i {
+			j++
+		}
+		neighbor := g
+		neighbor.ToggleEdge(i, j)
+		next := s.evaluate(neighbor)
+		delta := next.Distance - current.Distance
+		if delta <= 0 || (temperature > 0 && s.rng.Float64() < math.Exp(-delta/temperature)) {
+			g, current = neighbor, next
+		}
+		temperature *= args.Search.Cooling
+	}
+}
+
+// States of the graphs in the lattice search.
+const (
+	latticeUnvisited = iota
+	latticeVisited
+	latticeAbove // the graph and all its supergraphs are above target+tolerance
+	latticeBelow // the graph and all its subgraphs are below target-tolerance
+)
+
+// Explores every graph with a given number of vertices, adding one edge at a time. The probability of infecting every
+// vertex never decreases when an edge is added, so once a graph is above target+tolerance for every initial vertex,
+// its supergraphs don't need to be evaluated. Symmetrically, the subgraphs of a graph below target-tolerance are
+// skipped.
+func (s *searcher) lattice() {
+	matches := s.latticeMatches()
+	fmt.Printf("%d graphs within tolerance\n", len(matches))
+	for _, m := range matches {
+		fmt.Printf("%s v=%g initial vertex=%d\n", m.Matrix, m.Value, m.InitialVertex)
+	}
+	total := 1 << (uint(s.size) * uint(s.size-1) / 2)
+	fmt.Printf("%d of %d graphs evaluated, %d pruned\n", s.evaluations, total, total-s.evaluations)
+}
+
+// Returns the graphs within tolerance found by the lattice search.
+func (s *searcher) latticeMatches() []pondersolve.Solution {
+	edges := uint(s.size) * uint(s.size-1) / 2
+	// state of each graph, keyed by the bit pattern of its upper triangle (see pondersolve.FromUpperTriangle)
+	states := make([]uint8, 1<<edges)
+	var matches []pondersolve.Solution
+	var visit func(pattern uint64)
+	visit = func(pattern uint64) {
+		states[pattern] = latticeVisited
+		for bit := uint(0); bit < edges; bit++ {
+			if pattern&(1<<bit) != 0 && states[pattern&^(1<<bit)] == latticeAbove {
+				states[pattern] = latticeAbove
+				return
+			}
+			if pattern&(1<<bit) == 0 && states[pattern|1<<bit] == latticeBelow {
+				states[pattern] = latticeBelow
+				break
+			}
+		}
+		if states[pattern] == latticeVisited {
+			g := pondersolve.FromUpperTriangle(s.size, pattern)
+			sol, r := s.evaluateAll(g)
+			min, max := r[0], r[0]
+			for _, v := range r {
+				min, max = math.Min(min, v), math.Max(max, v)
+			}
+			if sol.Distance < s.tolerance {
+				matches = append(matches, sol)
+			}
+			if min > s.target+s.tolerance {
+				states[pattern] = latticeAbove
+				return
+			}
+			if max < s.target-s.tolerance {
+				states[pattern] = latticeBelow
+			}
+		}
+		for bit := uint(0); bit < edges; bit++ {
+			if next := pattern | 1<<bit; states[next] == latticeUnvisited {
+				visit(next)
+			}
+		}
+	}
+	visit(0)
+	return matches
+}
+
+// Enumerates the graphs with a given number of vertices by increasing number of edges, and stops after the first
+// number of edges for which some graphs are within tolerance. As in the lattice search, the supergraphs of a graph
+// above target+tolerance are skipped. Only one graph of each isomorphism class is evaluated and reported.
+func (s *searcher) minEdges() {
+	edges := uint(s.size) * uint(s.size-1) / 2
+	// above[pattern] is set when the graph and all its supergraphs are above target+tolerance, 32MB for 8 vertices
+	above := make([]uint64, (uint64(1)<<edges+63)/64)
+	isAbove := func(pattern uint64) bool { return above[pattern/64]&(1<<(pattern%64)) != 0 }
+	for count := uint(0); count <= edges; count++ {
+		var matches []pondersolve.Solution
+		// results of the graphs evaluated at this level, by canonical form
+		classes := make(map[uint64]bool)
+		candidates, pruned := 0, 0
+		for pattern := uint64(1)<<count - 1; pattern < 1<<edges; pattern = nextCombination(pattern) {
+			candidates++
+			skip := false
+			for bit := uint(0); bit < edges && !skip; bit++ {
+				skip = pattern&(1<<bit) != 0 && isAbove(pattern&^(1<<bit))
+			}
+			if !skip {
+				g := pondersolve.FromUpperTriangle(s.size, pattern)
+				key := g.CanonicalKey()
+				var seen bool
+				if skip, seen = classes[key]; !seen {
+					sol, r := s.evaluateAll(g)
+					if sol.Distance < s.tolerance {
+						matches = append(matches, sol)
+					}
+					min := r[0]
+					for _, v := range r {
+						min = math.Min(min, v)
+					}
+					skip = min > s.target+s.tolerance
+					classes[key] = skip
+				}
+			}
+			if skip {
+				above[pattern/64] |= 1 << (pattern % 64)
+				pruned++
+			}
+		}
+		fmt.Printf("%d edges: %d candidates, %d isomorphism classes evaluated, %d above the target\n", count, candidates, len(classes), pruned)
+		if len(matches) > 0 {
+			fmt.Printf("%d graphs with %d edges within tolerance, up to isomorphism\n", len(matches), count)
+			for _, m := range matches {
+				fmt.Printf("%s v=%g initial vertex=%d\n", m.Matrix, m.Value, m.InitialVertex)
+			}
+			return
+		}
+	}
+	fmt.Println("no graphs within tolerance")
+}
+
+func search() {
+	s := &searcher{
+		rng:       rand.New(rand.NewSource(args.Search.Seed)),
+		size:      args.Search.N,
+		target:    args.Search.Target,
+		tolerance: args.Search.Tolerance,
+		days:      args.Search.Days,
+		rate:      args.Search.Rate,
+	}
+	switch args.Search.Strategy {
+	case "hillclimb":
+		s.hillClimb()
+	case "anneal":
+		s.anneal()
+	case "lattice":
+		s.lattice()
+	case "min-edges":
+		s.minEdges()
+	default:
+		log.Panicf("unknown strategy: %s", args.Search.Strategy)
+	}
+
+	fmt.Println("best solution")
+	if s.found {
+		fmt.Printf("v=%g, distance=%g, within tolerance: %t\n", s.best.Value, s.best.Distance, s.best.Distance < s.tolerance)
+		fmt.Printf("graph: %s\ninitial vertex: %d\n", s.best.Matrix, s.best.InitialVertex)
+	}
+	fmt.Println(s.best.Graph)
+	fmt.Printf("%d graph evaluations\n", s.evaluations)
+}
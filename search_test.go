@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+func TestLatticeMatchesExhaustiveSearch(t *testing.T) {
+	tests := []struct {
+		target    float64
+		tolerance float64
+		days      uint
+	}{
+		{0.5, 0.05, 5},
+		{0.2, 0.01, 8},
+		{0.9, 0.05, 10},
+	}
+	const size = 5
+	for _, tt := range tests {
+		s := &searcher{size: size, target: tt.target, tolerance: tt.tolerance, days: tt.days, rate: 0.3}
+		var got []string
+		for _, m := range s.latticeMatches() {
+			got = append(got, m.Matrix)
+		}
+		sort.Strings(got)
+
+		var want []string
+		for pattern := uint64(0); pattern < 1<<(size*(size-1)/2); pattern++ {
+			g := pondersolve.FromUpperTriangle(size, pattern)
+			r, err := g.Compute(context.Background(), tt.days, 0.3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range r {
+				if math.Abs(v-tt.target) < tt.tolerance {
+					want = append(want, g.Matrix())
+					break
+				}
+			}
+		}
+		sort.Strings(want)
+
+		if len(want) == 0 {
+			t.Fatalf("target %g: no graph within tolerance, the test doesn't check anything", tt.target)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("target %g: lattice search found %d graphs, exhaustive search %d", tt.target, len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("target %g: lattice search found %s, exhaustive search %s", tt.target, got[i], want[i])
+			}
+		}
+		if total := 1 << (size * (size - 1) / 2); s.evaluations >= total {
+			t.Errorf("target %g: %d of %d graphs evaluated, nothing was pruned", tt.target, s.evaluations, total)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Tries every set of --k initially infected vertices and reports the sets which maximize (or minimize, with
+// --minimize) the probability that every vertex gets infected. A single dp table holds the probability for every
+// initial set.
+func optimizeSeeds() {
+	opts := &args.OptimizeSeeds
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	n := g.Size()
+	if opts.K < 1 || opts.K > int(n) {
+		log.Printf("invalid number of initial vertices %d, expecting 1 to %d for a graph with %d vertices", opts.K, n, n)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+	probs, err := g.ComputeStates(ctx, opts.Days, opts.Rate)
+	switch {
+	case errors.Is(err, context.Canceled):
+		log.Print("computation interrupted")
+		exit(exitInterrupted)
+	case err != nil:
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+
+	type seeds struct {
+		state   int
+		degrees int // sum of the degrees of the initial vertices
+	}
+	var sets []seeds
+	for pattern := uint64(1)<<uint(opts.K) - 1; pattern < 1<<n; pattern = nextCombination(pattern) {
+		s := seeds{state: int(pattern)}
+		for v := uint8(0); v < n; v++ {
+			if pattern&(1<<v) != 0 {
+				s.degrees += g.Degree(v)
+			}
+		}
+		sets = append(sets, s)
+	}
+	// sets are enumerated in increasing order, which breaks ties
+	sort.SliceStable(sets, func(i, j int) bool {
+		if opts.Minimize {
+			return probs[sets[i].state] < probs[sets[j].state]
+		}
+		return probs[sets[i].state] > probs[sets[j].state]
+	})
+	maxDegrees := 0
+	for _, s := range sets {
+		if s.degrees > maxDegrees {
+			maxDegrees = s.degrees
+		}
+	}
+
+	objective := "most"
+	if opts.Minimize {
+		objective = "least"
+	}
+	fmt.Printf("%d sets of %d initial vertices, %s likely to infect every vertex within %d days:\n", len(sets), opts.K, objective, opts.Days)
+	fmt.Printf("%-10s %-24s %s\n", "vertices", "probability", "total degree")
+	for i, s := range sets {
+		if i == opts.Top {
+			break
+		}
+		fmt.Printf("%-10s %-24g %d\n", formatState(s.state, n), probs[s.state], s.degrees)
+	}
+	if sets[0].degrees == maxDegrees {
+		fmt.Printf("the best set has the largest total degree, %d\n", maxDegrees)
+	} else {
+		fmt.Printf("the best set has a total degree of %d, the largest total degree is %d\n", sets[0].degrees, maxDegrees)
+	}
+}
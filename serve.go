@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Maximum size of a request body, a graph with 8 vertices and a few parameters fit in much less.
+const serveMaxBody = 64 << 10
+
+// Body of POST /compute. Only graph and days are required.
+type computeRequest struct {
+	Graph     *pondersolve.Graph `json:"graph"`
+	Days      *uint              `json:"days"`
+	Rate      *float64           `json:"rate"`
+	Algorithm string             `json:"algorithm"`
+	Initial   *uint8             `json:"initial"` // initially infected vertex, every vertex when missing
+}
+
+type computeResponse struct {
+	Probability   *float64  `json:"probability,omitempty"`
+	Probabilities []float64 `json:"probabilities,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Serves compute over HTTP.
+func serve() {
+	server := &http.Server{Addr: args.Serve.Listen, Handler: serveMux()}
+
+	ctx, stop := interruptibleContext()
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// give requests in flight a chance to complete
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), args.Serve.Timeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Print(err)
+		}
+	}()
+
+	log.Printf("listening on %s", args.Serve.Listen)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Panic(err)
+	}
+}
+
+// Routes the endpoints served by serve.
+func serveMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "expecting GET"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/compute", handleCompute)
+	return mux
+}
+
+func handleCompute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "expecting POST"})
+		return
+	}
+	var req computeRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, serveMaxBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request: %s", err)})
+		return
+	}
+	switch {
+	case req.Graph == nil:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "missing graph"})
+		return
+	case req.Graph.Size() == 0:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "empty graph, expecting at least 1 vertex"})
+		return
+	case req.Days == nil:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "missing days"})
+		return
+	case int(req.Graph.Size()) > args.Serve.MaxVertices:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("graph has %d vertices, at most %d are allowed", req.Graph.Size(), args.Serve.MaxVertices)})
+		return
+	case req.Initial != nil && *req.Initial >= req.Graph.Size():
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("initial vertex %d isn't in the graph", *req.Initial)})
+		return
+	}
+	rate := 0.10
+	if req.Rate != nil {
+		rate = *req.Rate
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), args.Serve.Timeout)
+	defer cancel()
+	probabilities, err := req.Graph.Compute(ctx, *req.Days, rate, pondersolve.WithAlgorithm(pondersolve.Algorithm(req.Algorithm)))
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: fmt.Sprintf("computation took longer than %s", args.Serve.Timeout)})
+		return
+	case errors.Is(err, context.Canceled):
+		// the client went away, nobody is reading the response
+		return
+	case err != nil:
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	if req.Initial != nil {
+		writeJSON(w, http.StatusOK, computeResponse{Probability: &probabilities[*req.Initial]})
+		return
+	}
+	writeJSON(w, http.StatusOK, computeResponse{Probabilities: probabilities})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Print(err)
+	}
+}
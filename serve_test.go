@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The graph from the puzzle statement, and the probability of infecting it from vertex 0 in 30 days at rate 0.1.
+const (
+	testPuzzleMatrix      = "00001100,00001011,00000110,00000010,11000101,10101001,01110001,01001110"
+	testPuzzleProbability = 0.6999898686018191
+)
+
+func TestServe(t *testing.T) {
+	args.Serve.Timeout = 10 * time.Second
+	args.Serve.MaxVertices = 8
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		want   string // expected response body, not checked when empty
+	}{
+		{"health check", "GET", "/healthz", "", http.StatusOK, `{"status":"ok"}`},
+		{"health check with POST", "POST", "/healthz", "", http.StatusMethodNotAllowed, ""},
+		{"single vertex", "POST", "/compute", `{"graph":"` + testPuzzleMatrix + `","days":30,"initial":0}`, http.StatusOK, `{"probability":0.6999898686018191}`},
+		{"every vertex", "POST", "/compute", `{"graph":"010,101,010","days":0}`, http.StatusOK, `{"probabilities":[0,0,0]}`},
+		{"single vertex graph", "POST", "/compute", `{"graph":"0","days":1,"rate":0.5,"algorithm":"memoized"}`, http.StatusOK, `{"probabilities":[1]}`},
+		{"GET", "GET", "/compute", "", http.StatusMethodNotAllowed, ""},
+		{"malformed JSON", "POST", "/compute", `{"graph":`, http.StatusBadRequest, ""},
+		{"unknown field", "POST", "/compute", `{"graph":"01,10","days":3,"vertex":0}`, http.StatusBadRequest, ""},
+		{"malformed matrix", "POST", "/compute", `{"graph":"01,1","days":3}`, http.StatusBadRequest, ""},
+		{"bad character", "POST", "/compute", `{"graph":"02,10","days":3}`, http.StatusBadRequest, ""},
+		{"missing graph", "POST", "/compute", `{"days":3}`, http.StatusBadRequest, `{"error":"missing graph"}`},
+		{"empty graph", "POST", "/compute", `{"graph":"","days":3}`, http.StatusBadRequest, `{"error":"empty graph, expecting at least 1 vertex"}`},
+		{"missing days", "POST", "/compute", `{"graph":"01,10"}`, http.StatusBadRequest, `{"error":"missing days"}`},
+		{"negative days", "POST", "/compute", `{"graph":"01,10","days":-1}`, http.StatusBadRequest, ""},
+		{"initial vertex out of range", "POST", "/compute", `{"graph":"01,10","days":3,"initial":2}`, http.StatusBadRequest, ""},
+		{"invalid rate", "POST", "/compute", `{"graph":"01,10","days":3,"rate":1.5}`, http.StatusBadRequest, ""},
+		{"unknown algorithm", "POST", "/compute", `{"graph":"01,10","days":3,"algorithm":"magic"}`, http.StatusBadRequest, ""},
+		{"body too large", "POST", "/compute", `{"graph":"01,10","days":3,"algorithm":"` + strings.Repeat("a", serveMaxBody) + `"}`, http.StatusBadRequest, ""},
+	}
+	mux := serveMux()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.want != "" && strings.TrimSpace(w.Body.String()) != tt.want {
+				t.Errorf("got %s, want %s", w.Body, tt.want)
+			}
+			if w.Code != http.StatusOK {
+				var e errorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.Error == "" {
+					t.Errorf("got %s, want an error message", w.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestServeMaxVertices(t *testing.T) {
+	args.Serve.Timeout = 10 * time.Second
+	args.Serve.MaxVertices = 4
+	defer func() { args.Serve.MaxVertices = 8 }()
+	w := httptest.NewRecorder()
+	serveMux().ServeHTTP(w, httptest.NewRequest("POST", "/compute", strings.NewReader(`{"graph":"`+testPuzzleMatrix+`","days":30}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("got status %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+	}
+}
+
+func TestServeTimeout(t *testing.T) {
+	args.Serve.Timeout = 20 * time.Millisecond
+	args.Serve.MaxVertices = 8
+	start := time.Now()
+	w := httptest.NewRecorder()
+	serveMux().ServeHTTP(w, httptest.NewRequest("POST", "/compute", strings.NewReader(`{"graph":"`+testPuzzleMatrix+`","days":1000000}`)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d: %s", w.Code, http.StatusServiceUnavailable, w.Body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the request took %s with a %s timeout", elapsed, args.Serve.Timeout)
+	}
+}
+
+func TestServeClientGone(t *testing.T) {
+	args.Serve.Timeout = 10 * time.Second
+	args.Serve.MaxVertices = 8
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("POST", "/compute", strings.NewReader(`{"graph":"`+testPuzzleMatrix+`","days":1000000}`)).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		serveMux().ServeHTTP(w, r)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+		if w.Body.Len() != 0 {
+			t.Errorf("wrote %s to a client which went away", w.Body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("still computing 5s after the client went away")
+	}
+}
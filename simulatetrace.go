@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// A vertex getting infected during a simulated run.
+type traceEvent struct {
+	Day     uint  `json:"day"`
+	Vertex  int   `json:"vertex"`
+	Exposed []int `json:"exposed"`          // infected neighbors on the previous day, empty for the initial vertex
+	Source  *int  `json:"source,omitempty"` // neighbor the infection is attributed to, nil for the initial vertex
+}
+
+// A simulated run, with its infections in the order they happened.
+type traceRun struct {
+	Run      int          `json:"run"`
+	Events   []traceEvent `json:"events"`
+	Infected int          `json:"infected"`
+	Complete bool         `json:"complete"`
+	Day      uint         `json:"day,omitempty"` // day on which the last vertex was infected, when the run is complete
+}
+
+// Simulates runs of the infection and prints their infections, as text or JSON. The runs only depend on --seed.
+func simulateTrace() {
+	opts := &args.SimulateTrace
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(opts.Model)
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	var runs []traceRun
+	for k := 1; k <= opts.Runs; k++ {
+		infections, err := g.Simulate(rng, opts.Days, opts.Rate, opts.InitialVertex, pondersolve.WithModel(model))
+		if err != nil {
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		run := traceRun{Run: k, Infected: len(infections), Complete: len(infections) == int(g.Size())}
+		for _, infection := range infections {
+			event := traceEvent{Day: infection.Day, Vertex: int(infection.Vertex), Exposed: []int{}}
+			for _, v := range infection.Exposed {
+				event.Exposed = append(event.Exposed, int(v))
+			}
+			if infection.Day > 0 {
+				source := int(infection.Source)
+				event.Source = &source
+			}
+			run.Events = append(run.Events, event)
+		}
+		if run.Complete {
+			run.Day = infections[len(infections)-1].Day
+		}
+		runs = append(runs, run)
+	}
+
+	if opts.JSON {
+		b, err := json.MarshalIndent(runs, "", "  ")
+		if err != nil {
+			log.Panic(err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+	for _, run := range runs {
+		fmt.Printf("run %d:\n", run.Run)
+		for _, event := range run.Events {
+			if event.Source == nil {
+				fmt.Printf("day %d: vertex %d infected (initially infected)\n", event.Day, event.Vertex)
+				continue
+			}
+			exposed := make([]string, len(event.Exposed))
+			for i, v := range event.Exposed {
+				exposed[i] = fmt.Sprint(v)
+			}
+			fmt.Printf("day %d: vertex %d infected (exposed via neighbors {%s}, attributed to %d)\n",
+				event.Day, event.Vertex, strings.Join(exposed, ", "), *event.Source)
+		}
+		if run.Complete {
+			fmt.Printf("run %d: %d of %d vertices infected, complete on day %d\n", run.Run, run.Infected, g.Size(), run.Day)
+		} else {
+			fmt.Printf("run %d: %d of %d vertices infected, incomplete after %d days\n", run.Run, run.Infected, g.Size(), opts.Days)
+		}
+	}
+}
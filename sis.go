@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Computes the SIS model with --recovery instead of compute's probability: whether the infection died out or persists
+// after --days.
+func computeSIS(ctx context.Context, g pondersolve.Graph) {
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(args.Compute.Model)
+	distribution, err := g.SISDistribution(ctx, args.Compute.Days, args.Compute.Rate, args.Compute.Recovery, args.Compute.InitialVertex,
+		pondersolve.WithModel(model))
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("computation took longer than %s", args.Compute.MaxDuration)
+		exit(exitInterrupted)
+	case errors.Is(err, context.Canceled):
+		log.Print("computation interrupted")
+		exit(exitInterrupted)
+	case err != nil:
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	mean, _ := pondersolve.InfectedMoments(distribution)
+	fmt.Printf("SIS model with recovery %g, starting from vertex %d:\n", args.Compute.Recovery, args.Compute.InitialVertex)
+	fmt.Printf("probability that the infection died out within %d days: %g%%\n", args.Compute.Days, distribution[0]*100.0)
+	fmt.Printf("probability that the infection persists after %d days: %g%%\n", args.Compute.Days, (1-distribution[0])*100.0)
+	fmt.Printf("probability of all vertices infected after %d days: %g%%\n", args.Compute.Days, distribution[len(distribution)-1]*100.0)
+	fmt.Printf("mean number of infected vertices after %d days: %g\n", args.Compute.Days, mean)
+}
@@ -1,15 +1,18 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/alecthomas/kong"
-	"github.com/teivah/bitvector"
-	"io"
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
 	"log"
 	"math"
 	"os"
+	"os/signal"
+	"runtime"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -17,296 +20,1252 @@ import (
 // See https://quaxio.com/ponder_this_april_2020_writeup/ for writeup.
 
 var args struct {
+	profileFlags
+
 	Compute struct {
-		Algorithm string `help:"\"recursive\" or \"dp\""`
-		Graph string `required help:"comma separated rows, e.g. \"011,100,010\""`
+		Algorithm string `default:"auto" enum:"auto,recursive,memoized,dp,lumped,matrix-power,monte-carlo" help:"\"auto\", \"recursive\", \"memoized\", \"dp\", \"lumped\" (dp over the states up to the symmetries of the graph), \"matrix-power\" (repeated squaring of the transition matrix, for thousands of days) or \"monte-carlo\" (estimate from --samples simulated runs). auto picks dp, or memoized for tiny problems"`
+		Samples int `default:"100000" help:"number of runs simulated by the monte-carlo algorithm"`
+		Seed int64 `default:"1" help:"random seed of the monte-carlo algorithm, the same seed gives the same estimate"`
+		Model string `default:"independent" help:"how infected neighbors combine: \"independent\" (each passes the infection on with probability --rate), \"linear\" (min(1, rate * infected neighbors)) or \"threshold:t\" (infected once at least t neighbors are infected, ignoring --rate)"`
+		Graph string `help:"comma separated rows, e.g. \"011,100,010\", or a weighted matrix with the rate of each edge instead of --rate, with semicolon separated rows, e.g. \"0,0.1,0.3;0.1,0,0;0.3,0,0\""`
+		Directed bool `help:"read the graphs as directed: the cell in row i and column j only lets the infection pass from vertex i to vertex j. Asymmetric matrices are rejected otherwise"`
+		GraphsFile string `type:"path" help:"compute every graph of this file instead of --graph, one adjacency matrix, graph6 or sparse6 per line"`
+		GraphSchedule string `help:"graphs used on successive days instead of --graph, separated by \"|\" and repeated when there are more days, e.g. \"011,101,110|010,100,000\""`
+		GraphScheduleFile string `type:"path" help:"file with one graph of the schedule per line, see --graph-schedule"`
+		SchedulePattern string `help:"order in which the graphs of --graph-schedule-file are used, e.g. \"0,0,0,0,0,1,1\". Each graph is used once, in order, by default"`
+		AllVertices bool `help:"print the probability for every initial vertex of the graphs in --graphs-file"`
+		JSON bool `help:"print the results as JSON: the probability of every initial vertex for --graph, or the results for --graphs-file"`
 		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
-		Days uint `required help:"number of days to compute"`
-	} `cmd help:"Compute probability for a given graph."`
+		Recovery float64 `help:"daily probability for an infected vertex to become susceptible again (SIS model). When set, prints the probabilities that the infection died out or persists after --days instead"`
+		Incubation uint `help:"number of days newly infected vertices stay exposed before they can infect their neighbors (SEI model). When set, prints the probabilities that every vertex is infected and infectious after --days instead"`
+		Days uint `required:"" help:"number of days to compute"`
+		Target float64 `default:"-1" help:"exit with status 0 if the probability is within tolerance of the target, 1 otherwise. Disabled by default"`
+		Tolerance float64 `default:"0.00005" help:"maximum distance between the probability and the target"`
+		MaxDuration time.Duration `help:"give up if the computation takes longer than this, e.g. \"10s\". No limit by default"`
+		RateSweep string `help:"compute every rate in start:end:step instead of --rate, e.g. \"0.05:0.20:0.01\""`
+		CSVOut string `name:"csv-out" help:"write the rate sweep, the results for --graphs-file, --correlations or --trajectory as CSV to this file instead of printing a table"`
+		Threads int `help:"number of rates computed concurrently by --rate-sweep, or of goroutines used by the recursive algorithm. Defaults to the number of CPUs"`
+		Polynomial bool `help:"print the probability as an exact polynomial in the rate, evaluated at --rate"`
+		LimitAnalysis bool `help:"also describe the probability as the number of days grows: its limit, how fast it converges and when it exceeds 1-1e-6"`
+		Interval bool `help:"also print an interval guaranteed to contain the exact probability despite rounding errors, and classify it against --target"`
+		Sensitivity bool `help:"also print the derivative of the probability with respect to the rate"`
+		Variance bool `help:"also print the mean, variance and standard deviation of the number of infected vertices after --days"`
+		FirstPassage bool `help:"also print the probability that every vertex gets infected on exactly each day up to --days"`
+		Trajectory bool `help:"also print the probability that every vertex is infected after each day up to --days, as CSV in --csv-out if set"`
+		TrajectoryMean bool `help:"add the expected number of infected vertices after each day to --trajectory"`
+		Rt bool `name:"rt" help:"also print the effective reproduction number R_t for each day up to --days"`
+		FinalState string `help:"also print the probability that exactly these vertices are infected after --days, e.g. \"10110000\""`
+		TopStates int `help:"also print this many of the most probable states after --days"`
+		Correlations bool `help:"also print the covariance and correlation of the infection of every pair of vertices after --days, as CSV in --csv-out if set"`
+		Entropy bool `help:"also print the entropy of the distribution of states for each day up to --days"`
+		InfectionTimes bool `help:"also print quantiles of the day on which each vertex gets infected, within --days"`
+		DumpDistributions string `type:"path" help:"also write the distribution of states on each day up to --days to this directory, one file per day"`
+		Before string `help:"also print the probability that the first of two vertices is infected before the second, e.g. \"3,6\""`
+		InitialVertex uint8 `help:"initially infected vertex, used by --variance, --first-passage, --trajectory, --rt, --final-state, --top-states, --correlations, --entropy, --infection-times, --dump-distributions, --before and --limit-analysis"`
+		Initial string `help:"initially infected vertices, e.g. \"0,3,5\", instead of a single one: prints the probability when they are all infected on day 0. See --initial-dist for a distribution over the initial vertex"`
+		Permute string `help:"relabel the vertices before computing, vertex i becomes the i-th label, e.g. \"3,0,1,2\". --initial-vertex and --final-state use the original labels"`
+		Restrict string `help:"only keep these vertices, e.g. \"0,1,3,4\": compute on the subgraph they induce, relabeled 0, 1, 2... in this order. Applied first, the other flags use the new labels"`
+		Complement bool `help:"replace the graph with its complement before computing, applied before --permute"`
+		InitialDist string `help:"probability of each vertex to be initially infected, e.g. \"0.5,0.25,0.25\", or \"uniform\". Prints the average probability along with each vertex's contribution"`
+		MaxDegree int `default:"1000" help:"largest polynomial degree allowed by --polynomial, which grows with the number of days and edges"`
+		cacheFlags
+	} `cmd:"" help:"Compute probability for a given graph."`
 
 	Solve struct {
-		Algorithm string `help:"\"recursive\" or \"dp\""`
-		Graphs string `required type:"path" help:"pre-computed list of graphs to solve with"`
-		Target float64 `default:"0.70" help:"target probability to solve for"`
+		Algorithm string `default:"auto" enum:"auto,recursive,memoized,dp,lumped,matrix-power" help:"\"auto\", \"recursive\", \"memoized\", \"dp\", \"lumped\" (dp over the states up to the symmetries of the graph) or \"matrix-power\" (repeated squaring of the transition matrix, for thousands of days). auto picks dp, or memoized for tiny problems"`
+		Model string `default:"independent" help:"how infected neighbors combine: \"independent\" (each passes the infection on with probability --rate), \"linear\" (min(1, rate * infected neighbors)) or \"threshold:t\" (infected once at least t neighbors are infected, ignoring --rate)"`
+		Graphs string `type:"path" help:"pre-computed list of graphs to solve with, one adjacency matrix, graph6 or sparse6 per line"`
+		GenerateSize uint8 `help:"enumerate every graph with this many vertices instead of using --graphs"`
+		ConnectedOnly bool `help:"only enumerate connected graphs, used with --generate-size"`
+		Target []float64 `default:"0.70" help:"comma separated target probabilities to solve for"`
+		Tolerance float64 `default:"0.00005" help:"maximum distance between a solution and its target"`
 		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
-		Days uint `required help:"number of days to solve for"`
-	} `cmd help:"Search for a solution."`
-}
+		Days uint `help:"number of days to solve for"`
+		DaysMin uint `help:"smallest number of days to solve for, used with --days-max"`
+		DaysMax uint `help:"largest number of days to solve for, used with --days-min"`
+		Constraint []string `sep:";" help:"solve for several day counts at once instead of --target and --days, e.g. \"days=20,target=0.50\". Repeat the flag for each constraint, a graph qualifies when it meets all of them. Each constraint can override --tolerance, e.g. \"days=30,target=0.70,tolerance=0.001\""`
+		Top int `default:"1" help:"number of closest solutions to report"`
+		Matches string `type:"path" help:"file to append every solution within tolerance to"`
+		DOT string `name:"dot" type:"path" help:"write the best solution for the first target to this file as a Graphviz graph, with the initially infected vertex filled in"`
+		Interval bool `help:"only keep solutions whose guaranteed interval is within tolerance, reporting the ones which straddle the tolerance separately. Takes about twice as long"`
+		NearMiss float64 `help:"distance to a target within which graphs are appended to --near-miss-out, with their closest initial vertex. Wider than --tolerance, e.g. 0.002"`
+		NearMissOut string `type:"path" help:"file to append the graphs within --near-miss of a target to"`
+		NearMissLimit int `default:"10000" help:"maximum number of graphs appended to --near-miss-out, 0 for no limit"`
+		DedupeIsomorphic bool `help:"skip graphs which are isomorphic to a graph already processed"`
+		DedupeExact bool `help:"skip graphs which are identical to a graph already processed"`
+		Order string `default:"file" enum:"file,heuristic,random" help:"order in which the lines of --graphs are processed: \"file\", \"heuristic\" (lines predicted to be closest to the targets first, useful when stopping early) or \"random\" (shuffled with --seed). The results of a full run don't depend on the order"`
+		OrderCalibrate bool `help:"with --order heuristic, fit the predictions to the exact probabilities of 64 lines spread across --graphs before ordering. Ranks the lines better, but computes those lines twice"`
+		Sample int `help:"only process a uniform random sample of this many graphs"`
+		Seed int64 `default:"1" help:"seed used for sampling"`
+		Shard int `default:"0" help:"only process lines whose index modulo --num-shards is this shard"`
+		NumShards int `default:"1" help:"number of shards the database is split into"`
+		Strict bool `help:"stop on the first malformed line instead of skipping it"`
+		Directed bool `help:"read the graphs as directed, see compute --directed. Asymmetric matrices are skipped as malformed otherwise"`
+		MinVertices int `default:"0" help:"skip graphs with fewer vertices"`
+		MaxVertices int `default:"8" help:"skip graphs with more vertices"`
+		MinEdges int `default:"0" help:"skip graphs with fewer edges"`
+		MaxEdges int `default:"28" help:"skip graphs with more edges"`
+		JSON bool `help:"print the best solutions and a summary of the run as JSON once it's over, instead of the progress and results"`
+		MaxDuration time.Duration `help:"stop after this long, e.g. \"2h\", printing the best solution found so far. No limit by default"`
+		ProgressInterval time.Duration `help:"minimum time between progress lines, e.g. \"1s\". Progress is printed after every graph by default"`
+		Checkpoint string `type:"path" help:"file to save the position in --graphs and the best solutions so far to, when stopping and every --checkpoint-interval"`
+		CheckpointInterval time.Duration `default:"1m" help:"minimum time between two writes of --checkpoint"`
+		Resume bool `help:"continue the run saved to --checkpoint, which must have the same flags. The graphs processed after the last write are processed again, and can be appended to --matches and --near-miss-out twice"`
+		cacheFlags
+	} `cmd:"" help:"Search for a solution."`
 
-type graph struct {
-	size     uint8 // number of vertices
-	vertices bitvector.Len64
-}
+	Generate struct {
+		N uint8 `required:"" help:"number of vertices"`
+		ConnectedOnly bool `default:"true" help:"only output connected graphs, use --connected-only=false to output every graph"`
+		Edges int `default:"-1" help:"only output graphs with exactly this many edges"`
+		Canonical bool `help:"only output one graph per isomorphism class"`
+		Out string `required:"" type:"path" help:"file to write the graphs to"`
+	} `cmd:"" help:"Generate a database of graphs."`
+
+	RandomGraphs struct {
+		N uint8 `required:"" help:"number of vertices"`
+		P float64 `required:"" help:"probability of each edge"`
+		Count int `default:"1000" help:"number of graphs to generate"`
+		Seed int64 `default:"1" help:"random seed"`
+		ConnectedOnly bool `help:"reject graphs which aren't connected"`
+		Out string `required:"" type:"path" help:"file to write the graphs to"`
+	} `cmd:"" help:"Generate a database of random graphs."`
+
+	Search struct {
+		Strategy string `default:"hillclimb" enum:"hillclimb,anneal,lattice,min-edges" help:"search strategy: \"hillclimb\", \"anneal\", \"lattice\" or \"min-edges\""`
+		N uint8 `default:"8" help:"number of vertices of the random starting graphs, or of the graphs in the lattice and min-edges searches"`
+		Graph string `help:"starting graph, a random connected graph is used by default"`
+		Restarts int `default:"10" help:"number of restarts from a random graph when stuck, for hillclimb"`
+		MaxSteps int `default:"1000" help:"maximum number of moves before restarting, for hillclimb"`
+		T0 float64 `default:"0.01" help:"initial temperature, for anneal"`
+		Cooling float64 `default:"0.999" help:"factor applied to the temperature after each step, for anneal"`
+		Steps int `default:"10000" help:"number of steps, for anneal"`
+		Seed int64 `default:"1" help:"random seed"`
+		Target float64 `default:"0.70" help:"target probability to search for"`
+		Tolerance float64 `default:"0.00005" help:"maximum distance between a solution and the target"`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		Days uint `required:"" help:"number of days to search for"`
+	} `cmd:"" help:"Search for a solution using local search."`
+
+	Isomorphic struct {
+		A string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		B string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+	} `cmd:"" help:"Check whether two graphs are identical up to a relabeling of their vertices. Exits with status 0 if they are, 1 otherwise."`
+
+	Diff struct {
+		A string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		B string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Days uint `required:"" help:"number of days to compute"`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		InitialVertex uint8 `help:"initially infected vertex of both graphs"`
+		Align bool `help:"relabel --a through the canonical forms before listing the edges which differ, so that isomorphic graphs have none"`
+	} `cmd:"" help:"Compare two graphs: their structure, the edges which differ and their probabilities. Exits with status 0 if they are identical, 5 if they are isomorphic, 6 otherwise."`
+
+	Canonicalize struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+	} `cmd:"" help:"Print the canonical form of a graph: the lexicographically smallest adjacency matrix over every relabeling of its vertices."`
+
+	Verify struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Days uint `required:"" help:"number of days to compute"`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		Target float64 `default:"0.70" help:"target probability"`
+		Tolerance float64 `default:"0.00005" help:"maximum distance between the probability and the target"`
+	} `cmd:"" help:"Check a claimed solution with two algorithms. Exits with status 0 if an initial vertex is within tolerance of the target, 1 otherwise."`
+
+	Compare struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Days uint `required:"" help:"number of days to compute"`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		MaxDivergence float64 `default:"1e-9" help:"maximum difference between algorithms"`
+		Timeout time.Duration `default:"10s" help:"skip algorithms which take longer than this"`
+	} `cmd:"" help:"Compute probabilities with every algorithm. Exits with status 0 if they agree, 1 otherwise."`
+
+	Stats struct {
+		Graphs string `required:"" type:"path" help:"list of graphs to describe"`
+		JSON bool `help:"print the statistics as JSON"`
+		Directed bool `help:"read the graphs as directed, see compute --directed. Asymmetric matrices are counted as malformed otherwise"`
+	} `cmd:"" help:"Describe the content of a database of graphs."`
+
+	Convert struct {
+		From string `default:"matrix" enum:"matrix,edge-list,graph6,sparse6,json,dot" help:"format of the input: matrix, edge-list, graph6, sparse6, json or dot"`
+		To string `required:"" enum:"matrix,edge-list,graph6,sparse6,json,dot" help:"format of the output: matrix, edge-list, graph6, sparse6, json or dot"`
+		In string `required:"" type:"path" help:"graphs to convert, one per line"`
+		Out string `required:"" type:"path" help:"file to write the converted graphs to"`
+		Canonicalize bool `help:"replace each graph with its canonical form"`
+		Permute string `help:"relabel the vertices of each graph, vertex i becomes the i-th label, e.g. \"3,0,1,2\""`
+		Complement bool `help:"replace each graph with its complement, applied before --permute and --canonicalize"`
+		Directed bool `help:"read the graphs as directed, see compute --directed. Asymmetric matrices are skipped otherwise"`
+	} `cmd:"" help:"Convert a list of graphs between formats."`
+
+	Dedup struct {
+		In string `required:"" type:"path" help:"graphs to deduplicate, one adjacency matrix, graph6 or sparse6 per line"`
+		Out string `required:"" type:"path" help:"file to write one graph per isomorphism class to"`
+		Canonicalize bool `help:"write the canonical form of each graph as an adjacency matrix, instead of its first line"`
+		Directed bool `help:"read the graphs as directed, see compute --directed. Asymmetric matrices are skipped otherwise"`
+	} `cmd:"" help:"Remove the graphs which are isomorphic to an earlier graph from a list of graphs."`
+
+	OptimizeVaccination struct {
+		Algorithm string `default:"auto" enum:"auto,recursive,memoized,dp,lumped,matrix-power" help:"\"auto\", \"recursive\", \"memoized\", \"dp\", \"lumped\" (dp over the states up to the symmetries of the graph) or \"matrix-power\" (repeated squaring of the transition matrix, for thousands of days). auto picks dp, or memoized for tiny problems"`
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		Days uint `required:"" help:"number of days to compute"`
+		Budget int `required:"" help:"number of vertices to immunize"`
+		InitialVertex uint8 `help:"initially infected vertex, which can't be immunized"`
+		AllInitial bool `help:"minimize the worst case over every initial vertex instead of using --initial-vertex"`
+	} `cmd:"" help:"Find the vertices to immunize which minimize the probability of infecting all the other vertices."`
+
+	OptimizeSeeds struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		Days uint `required:"" help:"number of days to compute"`
+		K int `name:"k" required:"" help:"number of initially infected vertices"`
+		Minimize bool `help:"look for the sets least likely to infect every vertex"`
+		Top int `default:"5" help:"number of sets to report"`
+	} `cmd:"" help:"Find the initially infected vertices which maximize the probability of infecting every vertex."`
+
+	OptimizeCuts struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		Days uint `required:"" help:"number of days to compute"`
+		Below float64 `required:"" help:"probability to get below, starting from vertex 0"`
+		MaxRemovals int `default:"3" help:"largest number of edges to remove"`
+	} `cmd:"" help:"Find the fewest edges to remove to bring the probability below a threshold. Exits with status 1 if there are none."`
+
+	Whatif struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		Days uint `required:"" help:"number of days to compute"`
+		AddEdges bool `help:"try adding each missing edge"`
+		RemoveEdges bool `help:"try removing each existing edge"`
+		Target float64 `default:"0.70" help:"probability to compare the results with"`
+		Tolerance float64 `default:"0.00005" help:"maximum distance between a result and the target"`
+	} `cmd:"" help:"Compute the probability after adding or removing each edge."`
+
+	Crosscheck struct {
+		Iterations int `default:"500" help:"number of random graphs to check"`
+		MaxSize uint8 `default:"6" help:"largest number of vertices of the random graphs"`
+		MaxDays uint `default:"12" help:"largest number of days"`
+		Seed int64 `default:"1" help:"random seed"`
+		MaxDivergence float64 `default:"1e-9" help:"maximum difference between algorithms"`
+		RecursiveMaxDays uint `default:"8" help:"skip the recursive algorithm above this many days, its running time is exponential in the number of days"`
+		Timeout time.Duration `default:"2s" help:"skip algorithms which take longer than this on a graph"`
+		KeepGoing bool `help:"report every violation instead of stopping at the first one"`
+	} `cmd:"" help:"Check the algorithms against each other on random graphs. Exits with status 0 if they agree, 1 otherwise."`
+
+	Analyze struct {
+		Graph string `required:"" help:"graph to analyze, e.g. \"011,100,100\""`
+		Format string `default:"matrix" enum:"matrix,edge-list,graph6,sparse6,json,dot" help:"format of --graph: matrix, edge-list, graph6, sparse6, json or dot"`
+		JSON bool `help:"print the properties as JSON"`
+	} `cmd:"" help:"Describe the structure of a graph."`
+
+	Likelihood struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Trajectory string `required:"" help:"comma separated states, one per day starting with day 0, e.g. \"100,110,111\""`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+	} `cmd:"" help:"Compute the probability of an observed sequence of infected vertices."`
 
-type stateProbability struct {
-	state       bitvector.Len8
-	probability float64
+	EstimateRate struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Trajectories string `required:"" type:"path" help:"observed trajectories, one per line, see likelihood's --trajectory"`
+		Grid int `default:"99" help:"number of rates in (0, 1) at which the log-likelihood is evaluated before refining the maximum"`
+		CSVOut string `name:"csv-out" help:"write the log-likelihood curve as CSV to this file instead of printing a table"`
+	} `cmd:"" help:"Estimate the rate which maximizes the likelihood of observed trajectories."`
+
+	SolveRate struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Days uint `required:"" help:"number of days to compute"`
+		Target float64 `required:"" help:"probability of infecting every vertex to reach"`
+		InitialVertex uint8 `help:"initially infected vertex"`
+		Algorithm string `default:"auto" enum:"auto,recursive,memoized,dp,lumped,matrix-power" help:"algorithm used for each rate, see compute's --algorithm"`
+		Model string `default:"independent" help:"how infected neighbors combine: \"independent\" (each passes the infection on with probability --rate), \"linear\" (min(1, rate * infected neighbors)) or \"threshold:t\" (infected once at least t neighbors are infected, ignoring --rate)"`
+		MaxDuration time.Duration `help:"give up if the search takes longer than this, e.g. \"10s\". No limit by default"`
+	} `cmd:"" help:"Find the smallest rate at which a graph reaches a target probability, by bisection. Exits with status 1 if even a rate of 1 doesn't reach it."`
+
+	SolveDays struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		Target float64 `required:"" help:"probability of infecting every vertex to reach"`
+		InitialVertex uint8 `help:"initially infected vertex"`
+		MaxDays uint `default:"100000" help:"give up after this many days"`
+		MaxDuration time.Duration `help:"give up if the search takes longer than this, e.g. \"10s\". No limit by default"`
+	} `cmd:"" help:"Find the smallest number of days after which a graph reaches a target probability, one day at a time. Exits with status 1 if it doesn't within --max-days."`
+
+	ExportTransitions struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		Out string `required:"" type:"path" help:"file to write the matrix to"`
+		Sparse bool `help:"write the non-zero entries as JSON instead of a dense CSV matrix"`
+	} `cmd:"" help:"Write the one-day transition matrix between states, bit i of a state is set when vertex i is infected."`
+
+	SimulateTrace struct {
+		Graph string `required:"" help:"comma separated rows, e.g. \"011,100,010\""`
+		Rate float64 `default:"0.10" help:"daily probability for infection to pass between edges"`
+		Days uint `required:"" help:"number of days to simulate"`
+		Runs int `default:"1" help:"number of runs to simulate"`
+		Seed int64 `default:"1" help:"random seed, the same seed gives the same runs"`
+		InitialVertex uint8 `help:"initially infected vertex"`
+		Model string `default:"independent" help:"how infected neighbors combine: \"independent\" (each passes the infection on with probability --rate), \"linear\" (min(1, rate * infected neighbors)) or \"threshold:t\" (infected once at least t neighbors are infected, ignoring --rate)"`
+		JSON bool `help:"print the runs as JSON"`
+	} `cmd:"" help:"Simulate runs of the infection and print the day each vertex gets infected, along with the neighbors it was exposed to."`
+
+	Serve struct {
+		Listen string `default:":8080" help:"address to listen on"`
+		Timeout time.Duration `default:"10s" help:"maximum time spent on a request"`
+		MaxVertices int `default:"8" help:"reject graphs with more vertices"`
+	} `cmd:"" help:"Serve compute over HTTP: POST /compute with a JSON body {graph, days, rate, algorithm, initial}."`
 }
 
+const (
+	exitOutsideTolerance = 1   // compute's result isn't within tolerance of the target
+	exitUnreachable      = 1   // solve-rate found no rate reaching the target, or solve-days no number of days
+	exitNotIsomorphic    = 1   // isomorphic was given graphs which aren't relabelings of each other
+	exitNotVerified      = 1   // verify found no initial vertex within tolerance, or the algorithms disagree
+	exitDiverged         = 1   // compare or crosscheck found algorithms which disagree by more than --max-divergence
+	exitInvalidInput     = 2   // invalid command line, or compute was given an invalid graph when checking a target
+	exitInterrupted      = 3   // the computation was interrupted, or solve stopped before processing all the graphs
+	exitTimeLimit        = 4   // solve ran out of --max-duration before processing all the graphs
+	exitIsomorphic       = 5   // diff was given graphs which are relabelings of each other, but not identical
+	exitDifferent        = 6   // diff was given graphs which aren't relabelings of each other
+	exitForceQuit        = 130 // the computation was interrupted a second time
+)
+
 func main() {
-	ctx := kong.Parse(&args)
+	ctx := kong.Parse(&args, kong.Exit(func(code int) {
+		// kong exits with status 1 on usage errors, which is reserved for compute results outside tolerance
+		if code == 1 {
+			code = exitInvalidInput
+		}
+		os.Exit(code)
+	}))
+	if err := validateArgs(ctx.Command()); err != nil {
+		ctx.Fatalf("%s", err)
+	}
+	args.serve()
+	args.create()
+	defer stopProfiling()
+	if ctx.Command() != "compute" && ctx.Command() != "solve" {
+		startCPUProfile()
+	}
 	switch ctx.Command() {
 	case "compute":
-		// Parse graph
-		g := parseMatrix(args.Compute.Graph)
-		r := compute(g, args.Compute.Algorithm, args.Compute.Days, args.Compute.Rate, true)
-		fmt.Printf("probability of all vertices infected after %d days: %g%%\n", args.Compute.Days, r[0] * 100.0)
+		compute()
 	case "solve":
 		solve()
+	case "generate":
+		generate()
+	case "random-graphs":
+		randomGraphs()
+	case "search":
+		search()
+	case "isomorphic":
+		isomorphic()
+	case "diff":
+		diff()
+	case "canonicalize":
+		canonicalize()
+	case "verify":
+		verify()
+	case "compare":
+		compare()
+	case "stats":
+		stats()
+	case "convert":
+		convert()
+	case "dedup":
+		dedup()
+	case "optimize-vaccination":
+		optimizeVaccination()
+	case "optimize-seeds":
+		optimizeSeeds()
+	case "optimize-cuts":
+		optimizeCuts()
+	case "whatif":
+		whatif()
+	case "crosscheck":
+		crosscheck()
+	case "analyze":
+		analyze()
+	case "likelihood":
+		likelihood()
+	case "estimate-rate":
+		estimateRate()
+	case "solve-rate":
+		solveRate()
+	case "solve-days":
+		solveDays()
+	case "export-transitions":
+		exportTransitions()
+	case "simulate-trace":
+		simulateTrace()
+	case "serve":
+		serve()
 	default:
 		panic(ctx.Command())
 	}
 }
 
-// Parses an adjacency matrix into a graph
-func parseMatrix(matrix string) graph {
-	rows := strings.Split(matrix, ",")
-	// check that we have at most 8 rows/cols
-	if len(rows) > 8 {
-		log.Panicf("matrix size is too large: %d > 8", len(rows))
+// Compute probability for a single graph, optionally checking it against a target.
+func compute() {
+	if args.Compute.GraphsFile != "" {
+		startCPUProfile()
+		computeBatch()
+		return
+	}
+	if args.Compute.GraphSchedule != "" || args.Compute.GraphScheduleFile != "" {
+		startCPUProfile()
+		computeSchedule()
+		return
+	}
+	if isWeightedMatrix(args.Compute.Graph) {
+		startCPUProfile()
+		computeEdgeRates()
+		return
+	}
+	checkTarget := args.Compute.Target >= 0
+	fail := func(err error) {
+		log.Print(err)
+		exit(exitInvalidInput)
 	}
 
-	g := graph{size: uint8(len(rows))}
+	var rates []float64
+	if args.Compute.RateSweep != "" {
+		var err error
+		if rates, err = parseRateSweep(args.Compute.RateSweep); err != nil {
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		if checkTarget || computeAnalyses() {
+			log.Print("--rate-sweep only prints probabilities, it can't be used with --target or the other analyses")
+			exit(exitInvalidInput)
+		}
+	}
 
-	// check that we have a square matrix + convert string to bits
-	for i, row := range rows {
-		if len(row) != len(rows) {
-			log.Panicf("row %d has length %d but expecting %d", i, len(row), len(rows))
+	// Parse graph
+	g, err := pondersolve.ParseMatrix(args.Compute.Graph)
+	if err != nil {
+		fail(err)
+	}
+	if args.Compute.Restrict != "" {
+		// validated by validateArgs
+		keep, _ := parseVertexSet(args.Compute.Restrict, g.Size())
+		g = g.InducedSubgraph(keep)
+		var mapping []string
+		for i, v := range keep {
+			mapping = append(mapping, fmt.Sprintf("%d->%d", v, i))
+		}
+		if !args.Compute.JSON {
+			fmt.Printf("restricted graph: %s\n", g.Matrix())
+			fmt.Printf("vertex mapping: %s\n", strings.Join(mapping, " "))
+		}
+	}
+	if args.Compute.InitialVertex >= g.Size() {
+		log.Printf("invalid initial vertex %d, graph has %d vertices", args.Compute.InitialVertex, g.Size())
+		exit(exitInvalidInput)
+	}
+	finalState := -1
+	if args.Compute.FinalState != "" {
+		if finalState, err = parseState(args.Compute.FinalState, g.Size()); err != nil {
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+	}
+	var initial []uint8
+	if args.Compute.Initial != "" {
+		if initial, err = parseVertexSet(args.Compute.Initial, g.Size()); err != nil {
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+	}
+	var order *infectionOrder
+	if args.Compute.Before != "" {
+		order = &infectionOrder{}
+		if order.a, order.b, err = parseVertexPair(args.Compute.Before, g.Size()); err != nil {
+			log.Print(err)
+			exit(exitInvalidInput)
 		}
-		for j, char := range row {
-			switch char {
-			case '0':
-			case '1': g.addEdge(uint8(i), uint8(j))
-			default:
-				log.Panicf("unknown character in matrix: '%c'", char)
+	}
+	var weights []float64
+	if args.Compute.InitialDist != "" {
+		// in the original labels, like --initial-vertex
+		if weights, err = parseInitialDistribution(args.Compute.InitialDist, g.Size()); err != nil {
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+	}
+	if args.Compute.Complement {
+		g = g.Complement()
+		if !args.Compute.JSON {
+			fmt.Printf("complemented graph: %s\n", g.Matrix())
+		}
+	}
+	if args.Compute.Permute != "" {
+		// validated by validateArgs
+		perm, _ := parsePermutation(args.Compute.Permute)
+		if g, err = g.Permute(perm); err != nil {
+			fail(err)
+		}
+		args.Compute.InitialVertex = perm[args.Compute.InitialVertex]
+		if weights != nil {
+			permuted := make([]float64, len(weights))
+			for i, w := range weights {
+				permuted[perm[i]] = w
 			}
+			weights = permuted
+		}
+		if order != nil {
+			order.a, order.b = perm[order.a], perm[order.b]
+		}
+		for i, v := range initial {
+			initial[i] = perm[v]
+		}
+		if finalState >= 0 {
+			finalState = permuteState(finalState, perm)
+			args.Compute.FinalState = formatState(finalState, g.Size())
+		}
+		if !args.Compute.JSON {
+			fmt.Printf("permuted graph: %s\n", g.Matrix())
 		}
 	}
-
-	return g
+	ctx, stop := interruptibleContext()
+	defer stop()
+	if args.Compute.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.Compute.MaxDuration)
+		defer cancel()
+	}
+	if args.Compute.Algorithm == monteCarloAlgorithm {
+		startCPUProfile()
+		computeMonteCarlo(ctx, g)
+		return
+	}
+	if args.Compute.Recovery > 0 {
+		startCPUProfile()
+		computeSIS(ctx, g)
+		return
+	}
+	if args.Compute.Incubation > 0 {
+		startCPUProfile()
+		computeIncubation(ctx, g)
+		return
+	}
+	if initial != nil {
+		startCPUProfile()
+		computeFrom(ctx, g, initial)
+		return
+	}
+	algorithm, err := selectAlgorithm(args.Compute.Algorithm, g.Size(), args.Compute.Days)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	threads := args.Compute.Threads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(args.Compute.Model)
+	opts := []pondersolve.Option{pondersolve.WithAlgorithm(algorithm), pondersolve.WithThreads(threads), pondersolve.WithModel(model)}
+	cache := args.Compute.open(model)
+	if cache != nil {
+		defer cache.Close()
+	}
+	startCPUProfile()
+	startTime := time.Now()
+	var r []float64
+	var sweep [][]float64
+	var polynomials []pondersolve.Polynomial
+	var derivatives []float64
+	var intervals []pondersolve.Interval
+	var distribution []float64
+	var cumulative []float64
+	var distributions [][]float64
+	if rates != nil {
+		sweep, err = g.ComputeRates(ctx, args.Compute.Days, rates, append(opts, pondersolve.FirstResultOnly())...)
+	} else {
+		// every initial vertex comes out of the same dp table
+		r, err = cache.compute(ctx, g, args.Compute.Days, args.Compute.Rate, weights == nil && !args.Compute.JSON, opts...)
+		if err == nil && args.Compute.Interval {
+			intervals, err = g.ComputeInterval(ctx, args.Compute.Days, args.Compute.Rate, pondersolve.FirstResultOnly())
+		}
+		if err == nil && args.Compute.Sensitivity {
+			_, derivatives, err = g.ComputeSensitivity(ctx, args.Compute.Days, args.Compute.Rate, pondersolve.FirstResultOnly())
+		}
+		// the initial vertex never recovers, so final states without it are unreachable and don't need the distribution
+		reachable := finalState >= 0 && finalState&(1<<args.Compute.InitialVertex) != 0
+		if err == nil && (args.Compute.Variance || reachable || args.Compute.TopStates > 0 || args.Compute.Correlations) {
+			distribution, err = g.StateDistribution(ctx, args.Compute.Days, args.Compute.Rate, args.Compute.InitialVertex)
+		}
+		if err == nil && (args.Compute.FirstPassage || args.Compute.Trajectory) {
+			var byDay [][]float64
+			byDay, err = g.ComputeDays(ctx, 0, args.Compute.Days, args.Compute.Rate, opts...)
+			for _, values := range byDay {
+				cumulative = append(cumulative, values[args.Compute.InitialVertex])
+			}
+		}
+		if err == nil && (args.Compute.Rt || args.Compute.Entropy || args.Compute.InfectionTimes || args.Compute.TrajectoryMean) {
+			distributions, err = g.StateDistributions(ctx, args.Compute.Days, args.Compute.Rate, args.Compute.InitialVertex)
+		}
+		if err == nil && args.Compute.DumpDistributions != "" {
+			err = dumpDistributions(ctx, g, args.Compute.Days, args.Compute.Rate, args.Compute.InitialVertex, args.Compute.DumpDistributions)
+		}
+		if err == nil && order != nil {
+			order.aFirst, order.sameDay, order.bFirst, order.neither, err = g.InfectionOrder(ctx, args.Compute.Days, args.Compute.Rate,
+				args.Compute.InitialVertex, order.a, order.b)
+		}
+		if err == nil && args.Compute.Polynomial {
+			polynomials, err = g.ComputePolynomial(ctx, args.Compute.Days, args.Compute.MaxDegree, pondersolve.FirstResultOnly())
+		}
+	}
+	stopCPUProfile()
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("computation took longer than %s", args.Compute.MaxDuration)
+		exit(exitInterrupted)
+	case errors.Is(err, context.Canceled):
+		log.Print("computation interrupted")
+		exit(exitInterrupted)
+	case errors.Is(err, pondersolve.ErrDegreeTooLarge):
+		log.Printf("%s, use fewer days or raise --max-degree", err)
+		exit(exitInvalidInput)
+	case err != nil:
+		fail(err)
+	}
+	if rates != nil {
+		printRateSweep(rates, sweep)
+		return
+	}
+	if args.Compute.JSON {
+		printComputeJSON(g, algorithm, r, time.Since(startTime))
+		return
+	}
+	value := r[0]
+	if weights != nil {
+		value = printInitialDistribution(weights, r)
+	} else {
+		fmt.Printf("probability of all vertices infected after %d days: %g%%\n", args.Compute.Days, r[0]*100.0)
+	}
+	if intervals != nil {
+		printInterval(intervals[0], checkTarget)
+	}
+	if derivatives != nil {
+		fmt.Printf("dP/dr at rate %g: %g\n", args.Compute.Rate, derivatives[0])
+	}
+	if args.Compute.Variance {
+		mean, variance := pondersolve.InfectedMoments(distribution)
+		fmt.Printf("infected vertices after %d days, starting from vertex %d: mean %g, variance %g, standard deviation %g\n",
+			args.Compute.Days, args.Compute.InitialVertex, mean, variance, math.Sqrt(variance))
+	}
+	if args.Compute.FirstPassage {
+		printFirstPassage(cumulative)
+	}
+	if args.Compute.Trajectory {
+		var means [][]float64
+		if args.Compute.TrajectoryMean {
+			means = distributions
+		}
+		printTrajectory(cumulative, means)
+	}
+	if args.Compute.Rt {
+		printReproductionNumbers(distributions)
+	}
+	if args.Compute.Entropy {
+		fmt.Printf("entropy of the states, starting from vertex %d:\n", args.Compute.InitialVertex)
+		for d, distribution := range distributions {
+			fmt.Printf("day %d: %g bits\n", d, pondersolve.Entropy(distribution))
+		}
+	}
+	if finalState >= 0 {
+		finalStateProbability := 0.0
+		if distribution != nil {
+			finalStateProbability = distribution[finalState]
+		}
+		fmt.Printf("probability of exactly %s infected after %d days, starting from vertex %d: %g%%\n",
+			args.Compute.FinalState, args.Compute.Days, args.Compute.InitialVertex, finalStateProbability*100.0)
+		if finalState&(1<<args.Compute.InitialVertex) == 0 {
+			fmt.Printf("vertex %d is infected from the start and stays infected, states without it are unreachable\n", args.Compute.InitialVertex)
+		}
+	}
+	if args.Compute.TopStates > 0 {
+		printTopStates(distribution, g.Size())
+	}
+	if args.Compute.Correlations {
+		printCorrelations(pondersolve.InfectionCovariances(distribution, g.Size()))
+	}
+	if args.Compute.InfectionTimes {
+		printInfectionTimes(distributions, g.Size())
+	}
+	if args.Compute.DumpDistributions != "" {
+		fmt.Printf("distributions for days 0 to %d written to %s\n", args.Compute.Days, args.Compute.DumpDistributions)
+	}
+	if order != nil {
+		order.print()
+	}
+	if polynomials != nil {
+		printPolynomial(polynomials[0], r[0])
+	}
+	if args.Compute.LimitAnalysis {
+		if err := printLimitAnalysis(ctx, g, args.Compute.Rate, args.Compute.InitialVertex); err != nil {
+			log.Print(err)
+			exit(exitInterrupted)
+		}
+	}
+	if args.Compute.CacheStats {
+		cache.printStats()
+	}
+	if checkTarget {
+		checkComputeTarget(value)
+	}
 }
 
-func (g *graph) addEdge(vertex1, vertex2 uint8) {
-	g.vertices = g.vertices.Set(vertex1*8+vertex2, true)
+// Compares compute's result with --target, exiting with exitOutsideTolerance if it isn't within tolerance.
+func checkComputeTarget(value float64) {
+	delta := value - args.Compute.Target
+	within := math.Abs(delta) < args.Compute.Tolerance
+	fmt.Printf("delta from target: %+g, within tolerance: %t\n", delta, within)
+	if !within {
+		exit(exitOutsideTolerance)
+	}
 }
 
-func (g *graph) hasEdge(vertex1, vertex2 uint8) bool {
-	return g.vertices.Get(vertex1*8 + vertex2)
+// Returns whether compute was asked for more than the probabilities.
+func computeAnalyses() bool {
+	c := &args.Compute
+	return c.Polynomial || c.Interval || c.LimitAnalysis || c.Sensitivity || c.Variance || c.FirstPassage || c.Trajectory || c.Rt || c.FinalState != "" || c.TopStates > 0 || c.Correlations ||
+		c.Entropy || c.InitialDist != "" || c.Before != "" || c.InfectionTimes || c.DumpDistributions != ""
 }
 
-// Compute probability for all vertices to be infected.
-func compute(g graph, algorithm string, days uint, rate float64, firstResultOnly bool) []float64 {
-	// Compute probability
-	switch algorithm {
-	case "recursive":
-		return g.computeRecursive(days, rate, firstResultOnly)
-	case "dp":
-		return g.computeDP(days, rate, firstResultOnly)
-	default:
-		panic(fmt.Sprintf("unknown algorithm: %s", algorithm))
-	}
+// Returns true if compute was asked for analyses which only support the independent model. --first-passage,
+// --trajectory and --initial-dist are computed like the probability itself, with any model.
+func independentAnalyses() bool {
+	c := &args.Compute
+	return c.Polynomial || c.Interval || c.LimitAnalysis || c.Sensitivity || c.Variance || c.TrajectoryMean || c.Rt || c.FinalState != "" || c.TopStates > 0 || c.Correlations ||
+		c.Entropy || c.Before != "" || c.InfectionTimes || c.DumpDistributions != ""
 }
 
-// Use a recursive function (note: this is going to be slow)
-func (g *graph) computeRecursive(days uint, rate float64, firstResultOnly bool) []float64 {
-	var r []float64
-	for i:=uint8(0); i<g.size; i++ {
-		// initial state is one vertex is infected on day 0.
-		var state bitvector.Len8
-		state = state.Set(i, true)
-		r = append(r, g._computeRecursive(days, rate, state))
-		if firstResultOnly {
-			break
+// Returns a context which is cancelled by the first SIGINT/SIGTERM, the second one exits immediately. The returned
+// function stops listening for signals.
+func interruptibleContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-signals; !ok {
+			return
 		}
+		cancel()
+		if _, ok := <-signals; ok {
+			os.Exit(exitForceQuit)
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		close(signals)
+		cancel()
 	}
-	return r
 }
 
-func (g *graph) _computeRecursive(days uint, rate float64, state bitvector.Len8) float64 {
-	if state.Count() == g.size {
-		// all vertices were infected, stop further processing
-		return 1.0
+// Iterate through graphs and find which ones are valid solutions
+func solve() {
+
+	var source pondersolve.Source
+	var total int
+	var database *fileSource
+	var lines []orderedLine // lines to order, see --order
+	eta := &etaEstimator{minSize: args.Solve.MinVertices, maxSize: args.Solve.MaxVertices}
+	var resumed *checkpoint
+	if args.Solve.Resume {
+		c, err := readCheckpoint(args.Solve.Checkpoint)
+		if err != nil {
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		resumed = &c
 	}
-	if days == 0 {
-		// some vertices were not infected, but we reached the end of our iterations
-		return 0.0
+	if args.Solve.Graphs != "" {
+		// Use a database of graphs to reduce search space. Count the lines in our shard, and graphs of each size for
+		// the eta.
+		sample := newReservoir(args.Solve.Sample, args.Solve.Seed)
+		fileSource := openFileSource(args.Solve.Graphs, func(lineNumber int, offset int64, line string) {
+			if !inShard(lineNumber) {
+				return
+			}
+			if args.Solve.Order != "file" {
+				lines = append(lines, orderedLine{number: lineNumber, offset: offset})
+			}
+			total++
+			vertices := pondersolve.GuessSize(line)
+			if sample != nil {
+				// lines processed before resuming are sampled too, so that the sample stays the same
+				sample.add(sampledLine{number: lineNumber, vertices: vertices})
+			} else if resumed == nil || lineNumber > resumed.Line {
+				eta.add(vertices)
+			}
+		})
+		defer fileSource.Close()
+		fileSource.sharded = true
+		if sample != nil {
+			total = len(sample.lines)
+			fileSource.sampled = make(map[int]bool, total)
+			for _, l := range sample.lines {
+				fileSource.sampled[l.number] = true
+				if resumed == nil || l.number > resumed.Line {
+					eta.add(l.vertices)
+				}
+			}
+		}
+		if resumed != nil {
+			fileSource.seek(resumed.Offset, resumed.Line)
+		}
+		database = fileSource
+		source = fileSource
+	} else {
+		// Enumerate graphs on the fly
+		size := int(args.Solve.GenerateSize)
+		enumeration := newEnumerationSource(args.Solve.GenerateSize, args.Solve.ConnectedOnly, func() {
+			eta.Skipped(size)
+		})
+		total = enumeration.shardCount()
+		eta.addMany(size, total)
+		source = enumeration
 	}
 
-	// enumerate combinations of edges which can change state
-	r := 0.0
-	nextStates := g.enumerateNextStates(state, rate, 0)
-	for _, nextState := range nextStates {
-		r += g._computeRecursive(days-1, rate, nextState.state) * nextState.probability
+	// open the matches file in append mode, so that we don't clobber results from previous runs
+	var matches *os.File
+	if args.Solve.Matches != "" {
+		var err error
+		matches, err = os.OpenFile(args.Solve.Matches, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			fatalf("invalid --matches: %s", err)
+		}
+		defer matches.Close()
+	}
+	var nearMisses *os.File
+	if args.Solve.NearMissOut != "" {
+		var err error
+		nearMisses, err = os.OpenFile(args.Solve.NearMissOut, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			fatalf("invalid --near-miss-out: %s", err)
+		}
+		defer nearMisses.Close()
 	}
-	return r
-}
 
-// For a given state, returns all possible next states and their probability of happening
-func (g *graph) enumerateNextStates(state bitvector.Len8, rate float64, index uint8) []stateProbability {
-	if index == g.size {
-		return []stateProbability{{state: state, probability: 1.0}}
+	// either solve for a single day count or for every day count in [days-min, days-max]
+	minDays, maxDays := args.Solve.Days, args.Solve.Days
+	if args.Solve.DaysMin != 0 || args.Solve.DaysMax != 0 {
+		minDays, maxDays = args.Solve.DaysMin, args.Solve.DaysMax
+	}
+	// or for every constraint, reported as a single target, with the same bounds as pondersolve.NewSolver
+	targets := args.Solve.Target
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(args.Solve.Model)
+	constraints, _ := parseConstraints(args.Solve.Constraint, args.Solve.Tolerance)
+	if constraints != nil {
+		targets = []float64{constraints[0].Target}
+		minDays, maxDays = constraints[0].Days, constraints[0].Days
+		for _, c := range constraints {
+			if c.Days < minDays {
+				minDays = c.Days
+			}
+			if c.Days > maxDays {
+				maxDays = c.Days
+			}
+		}
 	}
-	// if index is infected, there's nothing to do for this vertex
-	if state.Get(index) {
-		return g.enumerateNextStates(state, rate, index+1)
+	// databases can hold graphs of any size, the algorithm is picked for the largest ones
+	size := uint8(pondersolve.MaxSize)
+	if args.Solve.GenerateSize > 0 {
+		size = args.Solve.GenerateSize
 	}
-	// count how many neighbors this vertex has
-	infected := 0
-	for i:=uint8(0); i<g.size; i++ {
-		if g.hasEdge(index, i) && state.Get(i) {
-			infected++
+	algorithm, err := selectAlgorithm(args.Solve.Algorithm, size, maxDays)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	if lines != nil {
+		if database.sampled != nil {
+			sampled := lines[:0]
+			for _, l := range lines {
+				if database.sampled[l.number] {
+					sampled = append(sampled, l)
+				}
+			}
+			lines = sampled
 		}
+		goals := orderGoals{targets: targets, minDays: minDays, maxDays: maxDays, rate: args.Solve.Rate, model: model, constraints: constraints}
+		database.ordered = orderLines(database, lines, args.Solve.Order, args.Solve.Seed, args.Solve.OrderCalibrate, goals)
 	}
-	if infected == 0 {
-		// there are no infected neighbors
-		return g.enumerateNextStates(state, rate, index+1)
+
+	// a nil *resultCache isn't a nil pondersolve.Cache
+	cache := args.Solve.open(model)
+	var solveCache pondersolve.Cache
+	if cache != nil {
+		defer cache.Close()
+		solveCache = cache
 	}
 
-	// The probability of not being infected is (1-rate)^infected.
-	// The probability of getting infected is 1 - (1-rate)^infected.
-	p := math.Pow(1.0 - rate, float64(infected))
-	r := g.enumerateNextStates(state, rate, index+1)
-	var r2 []stateProbability
-	for _, s := range r {
-		r2 = append(r2, stateProbability{state: s.state, probability: s.probability * p})
-		r2 = append(r2, stateProbability{state: s.state.Set(index, true), probability: s.probability * (1.0 - p)})
+	r := &reporter{
+		source:      source,
+		targets:     targets,
+		constraints: constraints,
+		bestValues:  make(map[float64]float64),
+		showTarget:  len(targets) > 1,
+		showDays:    minDays != maxDays && constraints == nil,
+		generated:   args.Solve.GenerateSize != 0,
+		startTime:   time.Now(),
+	}
+	var malformed malformedLines
+	// --json only prints the summary
+	onProgress, onUndecided := r.progress, r.undecided
+	if args.Solve.JSON {
+		onProgress, onUndecided = nil, nil
 	}
-	return r2
-}
 
-// Iterate through graphs and find which ones are valid solutions
-func solve() {
-	// Use a database of graphs to reduce search space
-	file, err := os.Open(args.Solve.Graphs)
-	defer file.Close()
+	// The first SIGINT/SIGTERM stops the search, abandoning the graph in flight, the second one exits immediately.
+	ctx, stop := interruptibleContext()
+	defer stop()
+	if args.Solve.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.Solve.MaxDuration)
+		defer cancel()
+	}
+
+	// SIGUSR1 prints the status without stopping the search
+	status := newSolveStatus(source, eta, total, targets)
+	stopStatus := notifyStatus(status, os.Stderr)
+	defer stopStatus()
+
+	solver, err := pondersolve.NewSolver(status, pondersolve.SolveOptions{
+		Targets:          targets,
+		Tolerance:        args.Solve.Tolerance,
+		Top:              args.Solve.Top,
+		MinDays:          minDays,
+		MaxDays:          maxDays,
+		Rate:             args.Solve.Rate,
+		Algorithm:        algorithm,
+		Model:            model,
+		Constraints:      constraints,
+		Filter:           matchesFilters,
+		DedupeExact:      args.Solve.DedupeExact,
+		DedupeIsomorphic: args.Solve.DedupeIsomorphic,
+		Strict:           args.Solve.Strict,
+		Directed:         args.Solve.Directed,
+		Cache:            solveCache,
+		Interval:         args.Solve.Interval,
+		NearMiss:         args.Solve.NearMiss,
+		Total:            total,
+		Estimator:        status,
+		ProgressInterval: args.Solve.ProgressInterval,
+		OnProgress:       onProgress,
+		OnImproved: func(s pondersolve.Solution) {
+			status.improved(s)
+			if !args.Solve.JSON {
+				r.improved(s)
+			}
+		},
+		OnMatch: func(s pondersolve.Solution) {
+			if matches == nil {
+				return
+			}
+			var err error
+			if len(constraints) > 1 {
+				_, err = fmt.Fprintf(matches, "%s %g values=%s line=%d original=%s initial=%d\n", s.Graph.Matrix(), s.Value, formatValues(s.Values), s.Number, s.Matrix, s.InitialVertex)
+			} else if r.showDays {
+				_, err = fmt.Fprintf(matches, "%s %g days=%d line=%d original=%s initial=%d\n", s.Graph.Matrix(), s.Value, s.Days, s.Number, s.Matrix, s.InitialVertex)
+			} else {
+				_, err = fmt.Fprintf(matches, "%s %g line=%d original=%s initial=%d\n", s.Graph.Matrix(), s.Value, s.Number, s.Matrix, s.InitialVertex)
+			}
+			if err != nil {
+				log.Panic(err)
+			}
+		},
+		OnUndecided: onUndecided,
+		OnNearMiss: func(s pondersolve.Solution) {
+			if args.Solve.NearMissLimit > 0 && r.nearMisses >= args.Solve.NearMissLimit {
+				return
+			}
+			r.nearMisses++
+			var err error
+			if r.showDays || len(targets) > 1 {
+				_, err = fmt.Fprintf(nearMisses, "%s %g distance=%g target=%g days=%d line=%d original=%s initial=%d\n", s.Graph.Matrix(), s.Value, s.Distance, s.Target, s.Days, s.Number, s.Matrix, s.InitialVertex)
+			} else {
+				_, err = fmt.Fprintf(nearMisses, "%s %g distance=%g line=%d original=%s initial=%d\n", s.Graph.Matrix(), s.Value, s.Distance, s.Number, s.Matrix, s.InitialVertex)
+			}
+			if err != nil {
+				log.Panic(err)
+			}
+		},
+		OnMalformed: func(number int, matrix string, err error) {
+			log.Printf("line %d: %s, skipping", number, err)
+			malformed.add(err)
+		},
+	})
 	if err != nil {
 		log.Panic(err)
 	}
-	// count number of lines
-	fileScanner := bufio.NewScanner(file)
-	lineCount := 0
-	for fileScanner.Scan() {
-		lineCount++
+	if resumed != nil {
+		previous := resumed.Summary.summary()
+		if err := solver.Resume(previous); err != nil {
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		for _, result := range previous.Results {
+			if len(result.Best) > 0 {
+				status.improved(result.Best[0])
+				r.bestValues[result.Target] = result.Best[0].Value
+				r.bestDistance = result.Best[0].Distance
+			}
+		}
 	}
-	if _, err = file.Seek(0, 0); err != nil {
-		log.Panic(err)
+	// the graph in flight when stopping isn't part of the checkpoint, so the position is only updated once it's done
+	var offset int64
+	var line int
+	if database != nil {
+		offset, line = database.checkpoint()
 	}
-
-	// read each graph
-	reader := bufio.NewReader(file)
-	startTime := time.Now()
-	linesProcessed := 0
-	bestValue := float64(0)
-	var bestGraph graph
+	lastCheckpoint := time.Now()
+	startCPUProfile()
 	for {
-		line, err := reader.ReadString('\n')
-		if err == io.EOF {
-			break
-		}
+		_, ok, err := solver.Next(ctx)
 		if err != nil {
+			// --strict stops on the first malformed line, which is an error in the input rather than a bug
+			if args.Solve.Strict {
+				fatalf("%s", err)
+			}
 			log.Panic(err)
 		}
-		line = strings.TrimSuffix(line, "\n")
-		g := parseMatrix(line)
-
-		r := compute(g, args.Solve.Algorithm, args.Solve.Days, args.Solve.Rate, false)
-		for i, v := range r {
-			if math.Abs(v-args.Solve.Target) < math.Abs(bestValue-args.Solve.Target) && math.Abs(v-args.Solve.Target) < 0.00005 {
-				fmt.Printf("Improved solution! v=%g\n", v)
-				bestValue = v
-				bestGraph = graph{size: g.size, vertices: g.vertices}
-				bestGraph.pivot(uint8(i))
-				fmt.Println(bestGraph)
+		if !ok {
+			break
+		}
+		if args.Solve.Checkpoint != "" {
+			offset, line = database.checkpoint()
+			if time.Since(lastCheckpoint) >= args.Solve.CheckpointInterval {
+				saveCheckpoint(solver.Summary(), offset, line, minDays, maxDays, algorithm)
+				lastCheckpoint = time.Now()
 			}
 		}
-		linesProcessed++
-		timeLeft := float64(time.Now().Sub(startTime).Milliseconds()) / float64(linesProcessed) * float64(lineCount - linesProcessed)
-		fmt.Printf("best: %g, eta: %s\n", bestValue, time.Duration(timeLeft)*time.Millisecond)
 	}
-	fmt.Println("best solution")
-	fmt.Println(bestGraph)
+	stopCPUProfile()
+	summary := solver.Summary()
+	if args.Solve.Checkpoint != "" {
+		saveCheckpoint(summary, offset, line, minDays, maxDays, algorithm)
+	}
+	if args.Solve.JSON {
+		printSolveJSON(summary, minDays, maxDays, algorithm)
+	} else {
+		r.finished(summary, malformed, total, errors.Is(ctx.Err(), context.DeadlineExceeded))
+	}
+	if args.Solve.DOT != "" {
+		writeSolutionDOT(summary)
+	}
+	if args.Solve.CacheStats {
+		cache.printStats()
+	}
+	if summary.Interrupted && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		exit(exitTimeLimit)
+	}
+	if summary.Interrupted {
+		exit(exitInterrupted)
+	}
 }
 
-// Transform g.vertices so that infected vertex becomes the first vertex.
-func (g *graph) pivot(infected uint8) {
-	// swap 0 and infected
-	original := graph{size: g.size, vertices: g.vertices}
-	g.vertices = 0
-	for i := uint8(0); i < g.size; i++ {
-		for j := uint8(0); j < g.size; j++ {
-			if original.hasEdge(i, j) {
-				mapper := func(x uint8) uint8 {
-					if x == infected {
-						return 0
-					} else if x < infected {
-						return x + 1
-					} else {
-						return x
-					}
-				}
-				g.addEdge(mapper(i), mapper(j))
-			}
-		}
-	}
+// Checks whether a line (starting at 1) belongs to the shard being processed.
+func inShard(lineNumber int) bool {
+	return (lineNumber-1)%args.Solve.NumShards == args.Solve.Shard
 }
 
-func (g graph) String() string {
-	var r strings.Builder
-	for i := byte(0); i < g.size; i++ {
-		for j := byte(0); j < g.size; j++ {
-			if g.hasEdge(i, j) {
-				fmt.Fprintf(&r, "1")
-			} else {
-				fmt.Fprintf(&r, "0")
-			}
-		}
-		fmt.Fprintln(&r,"")
-	}
-	return r.String()
+// Checks the vertex and edge count filters.
+func matchesFilters(g pondersolve.Graph) bool {
+	vertices, edges := int(g.Size()), g.EdgeCount()
+	return vertices >= args.Solve.MinVertices && vertices <= args.Solve.MaxVertices &&
+		edges >= args.Solve.MinEdges && edges <= args.Solve.MaxEdges
 }
 
-// Compute using dynamic programming.
-func (g *graph) computeDP(days uint, rate float64, firstResultOnly bool) []float64 {
-	lastState := (1 << g.size)-1
+// Prints solve's progress and results, as the callbacks of pondersolve.Solve.
+type reporter struct {
+	source       pondersolve.Source
+	targets      []float64
+	constraints  []pondersolve.Constraint // replace the targets when solving with --constraint
+	bestValues   map[float64]float64      // value closest to each target so far, targets without a solution yet are missing
+	bestDistance float64                  // distance of the best solution to the constraints so far, see bestValues
+	nearMisses   int                      // graphs appended to --near-miss-out
+	showTarget   bool                     // several targets are being solved for
+	showDays     bool                     // a range of days is being solved for
+	generated    bool                     // graphs are enumerated rather than read from a database
+	startTime    time.Time
+}
 
-	// Build a table with 256 * (days+1) entries. We could actually make this smaller (lastState * days-1) but
-	// the size we picked is a little more convenient.
-	probs := make([][256]float64, days + 1)
+func (r *reporter) progress(processed, total int, best float64, eta time.Duration) {
+	elapsed := time.Since(r.startTime).Round(time.Millisecond)
+	progress := fmt.Sprintf("progress: %.2f%%, elapsed: %s, eta: %s", r.source.Progress()*100, elapsed, eta)
+	// until a solution is found, the best values are printed as "-" rather than 0, which would look like a hit
+	_, found := r.bestValues[r.targets[0]]
+	if len(r.constraints) > 1 {
+		fmt.Printf("best distance: %s, %s\n", formatBest(r.bestDistance, found), progress)
+		return
+	}
+	if len(r.targets) == 1 {
+		fmt.Printf("best: %s, %s\n", formatBest(best, found), progress)
+		return
+	}
+	var distances strings.Builder
+	for _, target := range r.targets {
+		value, found := r.bestValues[target]
+		fmt.Fprintf(&distances, "target %g: %s, ", target, formatBest(math.Abs(value-target), found))
+	}
+	fmt.Printf("best distance: %s%s\n", distances.String(), progress)
+}
 
-	// fill the base case
-	for state:=0; state<lastState; state++ {
-		probs[0][state] = 0.0
+func (r *reporter) improved(s pondersolve.Solution) {
+	if len(r.constraints) > 1 {
+		fmt.Printf("Improved solution! distance=%g, %s\n", s.Distance, formatConstraintValues(r.constraints, s.Values))
+	} else if r.showTarget {
+		fmt.Printf("Improved solution for target %g! v=%g\n", s.Target, s.Value)
+	} else {
+		fmt.Printf("Improved solution! v=%g\n", s.Value)
 	}
-	probs[0][lastState] = 1.0
+	r.bestValues[s.Target] = s.Value
+	r.bestDistance = s.Distance
+	fmt.Print(r.describe(s))
+	fmt.Println(s.Graph)
+}
 
-	// compute the mapping of state => nextStates
-	m := make(map[int][]stateProbability)
-	for state:=0; state<=lastState; state++ {
-		m[state] = g.enumerateNextStates(bitvector.Len8(state), rate, 0)
+// Formats a best value or distance of the progress line, "-" when no solution was found yet.
+func formatBest(v float64, found bool) string {
+	if !found {
+		return "-"
 	}
+	return fmt.Sprint(v)
+}
 
-	// fill probs table
-	for i := uint(1); i<=days; i++ {
-		// each state depends on probabilities available in m and probs table
-		for state:=0; state<=lastState; state++ {
-			p := 0.0
-			for _, nextState := range m[state] {
-				p += nextState.probability * probs[i - 1][nextState.state]
+// Reports a candidate whose interval straddles the tolerance, it's neither a solution nor ruled out.
+func (r *reporter) undecided(s pondersolve.Solution) {
+	fmt.Printf("Undecided candidate for target %g: v=%g, too close to the tolerance for the interval to decide\n", s.Target, s.Value)
+	fmt.Print(r.describe(s))
+}
+
+// Prints the results once every graph was processed, or solve was stopped early because it was interrupted or ran
+// out of time.
+func (r *reporter) finished(summary pondersolve.Summary, malformed malformedLines, total int, outOfTime bool) {
+	if summary.Filtered > 0 && summary.Filtered+summary.Malformed == summary.Processed {
+		fmt.Println("0 graphs matched filters")
+	} else {
+		for _, result := range summary.Results {
+			if len(summary.Results) > 1 {
+				fmt.Printf("target %g\n", result.Target)
 			}
-			probs[i][state] = p
+			r.print(result)
+		}
+	}
+	if args.Solve.NumShards > 1 {
+		for _, result := range summary.Results {
+			fmt.Println(shardResult(result, args.Solve.Shard, args.Solve.NumShards))
 		}
 	}
+	if args.Solve.Matches != "" {
+		fmt.Printf("%d matches appended to %s\n", summary.Matches, args.Solve.Matches)
+	}
+	if args.Solve.Interval {
+		fmt.Printf("%d undecided candidates\n", summary.Undecided)
+	}
+	if args.Solve.NearMiss > 0 {
+		fmt.Printf("%d near misses within %g, %d appended to %s\n", summary.NearMisses, args.Solve.NearMiss, r.nearMisses, args.Solve.NearMissOut)
+	}
+	if args.Solve.DedupeExact {
+		fmt.Printf("%d duplicate graphs skipped\n", summary.Duplicates)
+	}
+	if args.Solve.DedupeIsomorphic {
+		fmt.Printf("%d isomorphic graphs skipped\n", summary.Isomorphic)
+	}
+	if summary.Filtered > 0 {
+		fmt.Printf("%d graphs filtered out\n", summary.Filtered)
+	}
+	if malformed.total > 0 {
+		fmt.Printf("%d malformed lines skipped: %s\n", malformed.total, malformed)
+	}
+	if summary.Interrupted && outOfTime {
+		fmt.Printf("time budget of %s exhausted after processing %d/%d graphs, %.2f%% of the graphs covered\n",
+			args.Solve.MaxDuration, summary.Processed, total, r.source.Progress()*100)
+	} else if summary.Interrupted {
+		fmt.Printf("interrupted after processing %d/%d graphs in %s\n", summary.Processed, total, summary.Elapsed)
+	}
+}
 
-	// for each possible initial state, perform a single lookup
-	var r []float64
-	for i := uint8(0); i < g.size; i++ {
-		var initialState bitvector.Len8
-		initialState = initialState.Set(i, true)
-		p := probs[days][initialState]
-		r = append(r, p)
-		if firstResultOnly {
-			break
+// Prints the best solutions for a target.
+func (r *reporter) print(result pondersolve.TargetResult) {
+	if args.Solve.Top <= 1 {
+		var bestGraph pondersolve.Graph
+		if len(result.Best) > 0 {
+			bestGraph = result.Best[0].Graph
 		}
+		fmt.Println("best solution")
+		if len(result.Best) > 0 {
+			fmt.Print(r.describe(result.Best[0]))
+		}
+		fmt.Println(bestGraph)
+		return
+	}
+	fmt.Printf("best %d solutions\n", len(result.Best))
+	for i, s := range result.Best {
+		fmt.Printf("#%d: v=%g, distance=%g\n", i+1, s.Value, s.Distance)
+		fmt.Print(r.describe(s))
+		fmt.Println(s.Graph)
+	}
+}
+
+// Describes where a solution comes from: the database line (or enumerated graph) and the initially infected vertex.
+// The pivoted graph is not included, it's the original matrix with vertex 0 and the initial vertex swapped.
+func (r *reporter) describe(s pondersolve.Solution) string {
+	var b strings.Builder
+	if r.generated {
+		fmt.Fprintf(&b, "graph #%d: %s\n", s.Number, s.Matrix)
+	} else {
+		fmt.Fprintf(&b, "line %d: %s\n", s.Number, s.Matrix)
+	}
+	fmt.Fprintf(&b, "initial vertex: %d\n", s.InitialVertex)
+	if len(r.constraints) > 1 {
+		fmt.Fprintf(&b, "values: %s\n", formatConstraintValues(r.constraints, s.Values))
+	}
+	if r.showDays {
+		fmt.Fprintf(&b, "days: %d\n", s.Days)
+	}
+	return b.String()
+}
+
+// Returns the best solution for a target in a machine readable format, used to merge the results of each shard.
+func shardResult(result pondersolve.TargetResult, shard, numShards int) string {
+	if len(result.Best) == 0 {
+		return fmt.Sprintf("shard=%d/%d target=%g none", shard, numShards, result.Target)
 	}
-	return r
+	s := result.Best[0]
+	return fmt.Sprintf("shard=%d/%d target=%g value=%g distance=%g days=%d line=%d initial=%d original=%s pivoted=%s",
+		shard, numShards, result.Target, s.Value, s.Distance, s.Days, s.Number, s.InitialVertex, s.Matrix, s.Graph.Matrix())
+}
+
+// Counts the lines which failed to parse, by reason.
+type malformedLines struct {
+	total        int
+	tooLarge     int
+	notSquare    int
+	badCharacter int
+	asymmetric   int
+}
+
+func (m *malformedLines) add(err error) {
+	m.total++
+	switch {
+	case errors.Is(err, pondersolve.ErrTooLarge):
+		m.tooLarge++
+	case errors.Is(err, pondersolve.ErrNotSquare):
+		m.notSquare++
+	case errors.Is(err, pondersolve.ErrBadCharacter):
+		m.badCharacter++
+	case errors.Is(err, pondersolve.ErrAsymmetric):
+		m.asymmetric++
+	}
+}
+
+func (m malformedLines) String() string {
+	return fmt.Sprintf("%d too large, %d not square, %d with bad characters, %d asymmetric", m.tooLarge, m.notSquare, m.badCharacter,
+		m.asymmetric)
 }
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Finds the smallest number of days after which --graph reaches --target, see Graph.DaysToTarget.
+func solveDays() {
+	opts := &args.SolveDays
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+	if opts.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxDuration)
+		defer cancel()
+	}
+
+	days, p, err := g.DaysToTarget(ctx, opts.Rate, opts.InitialVertex, opts.Target, opts.MaxDays)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("search took longer than %s", opts.MaxDuration)
+		exit(exitInterrupted)
+	case errors.Is(err, context.Canceled):
+		log.Print("search interrupted")
+		exit(exitInterrupted)
+	case errors.Is(err, pondersolve.ErrNeverReached):
+		log.Print(err)
+		exit(exitUnreachable)
+	case err != nil:
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	fmt.Printf("smallest number of days reaching %g%% at rate %g, starting from vertex %d: %d\n", opts.Target*100.0, opts.Rate,
+		opts.InitialVertex, days)
+	fmt.Printf("probability after %d days: %g%%\n", days, p*100.0)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Finds the smallest rate at which --graph reaches --target, see Graph.SolveRate.
+func solveRate() {
+	opts := &args.SolveRate
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	algorithm, err := selectAlgorithm(opts.Algorithm, g.Size(), opts.Days)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+	if opts.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.MaxDuration)
+		defer cancel()
+	}
+
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(opts.Model)
+	computeOpts := []pondersolve.Option{pondersolve.WithAlgorithm(algorithm), pondersolve.WithModel(model)}
+	rate, err := g.SolveRate(ctx, opts.Days, opts.Target, opts.InitialVertex, computeOpts...)
+	var p float64
+	if err == nil {
+		p, err = g.ComputeFrom(ctx, opts.Days, rate, []uint8{opts.InitialVertex}, computeOpts...)
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("search took longer than %s", opts.MaxDuration)
+		exit(exitInterrupted)
+	case errors.Is(err, context.Canceled):
+		log.Print("search interrupted")
+		exit(exitInterrupted)
+	case errors.Is(err, pondersolve.ErrTargetUnreachable):
+		log.Print(err)
+		exit(exitUnreachable)
+	case err != nil:
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	fmt.Printf("smallest rate reaching %g%% after %d days, starting from vertex %d: %.10f\n", opts.Target*100.0, opts.Days,
+		opts.InitialVertex, rate)
+	fmt.Printf("probability at this rate: %g%%\n", p*100.0)
+}
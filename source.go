@@ -0,0 +1,170 @@
+package main
+
+import (
+	"log"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Reads graphs from a database file, one matrix per line, with pondersolve.FileSource. Lines can be left out (shards,
+// sampling) or read in another order.
+type fileSource struct {
+	lines      *pondersolve.FileSource
+	lineNumber int // last line read from start to end
+	lineCount  int
+	sampled    map[int]bool  // lines to process when sampling, nil to process every line
+	sharded    bool          // only return the lines in the shard selected by solve's flags, see inShard
+	ordered    []orderedLine // lines to process, in this order, nil to process the file from start to end
+	position   int           // index of the next line in ordered
+}
+
+// A line of a database file, along with its position in the file.
+type orderedLine struct {
+	number int
+	offset int64
+	score  float64 // lines with lower scores come first, see orderLines
+}
+
+// Opens a database file. Lines are counted and passed to prescan along with their offset in the file, which is used
+// to plan the work (eta, sampling, order). The caller must Close the source.
+func openFileSource(path string, prescan func(lineNumber int, offset int64, line string)) *fileSource {
+	lines, err := pondersolve.OpenFileSource(path)
+	if err != nil {
+		fatalf("%s", err)
+	}
+	s := &fileSource{lines: lines}
+	for {
+		offset := lines.Offset()
+		number, line, ok := lines.Next()
+		if !ok {
+			break
+		}
+		s.lineCount = number
+		prescan(number, offset, line)
+	}
+	if err := lines.Err(); err != nil {
+		log.Panic(err)
+	}
+	s.seek(0, 0)
+	return s
+}
+
+func (s *fileSource) Close() error {
+	return s.lines.Close()
+}
+
+func (s *fileSource) Next() (int, string, bool) {
+	if s.ordered != nil {
+		return s.nextOrdered()
+	}
+	for {
+		number, line, ok := s.lines.Next()
+		if !ok {
+			if err := s.lines.Err(); err != nil {
+				log.Panic(err)
+			}
+			return 0, "", false
+		}
+		s.lineNumber = number
+		if (s.sharded && !inShard(number)) || (s.sampled != nil && !s.sampled[number]) {
+			continue
+		}
+		return number, line, true
+	}
+}
+
+// Continues reading the file from start to end after a line read before, see --resume.
+func (s *fileSource) seek(offset int64, lineNumber int) {
+	if err := s.lines.SeekLine(offset, lineNumber); err != nil {
+		log.Panic(err)
+	}
+	s.lineNumber = lineNumber
+}
+
+// Returns the offset and number of the last line read from start to end, which seek takes back.
+func (s *fileSource) checkpoint() (int64, int) {
+	return s.lines.Offset(), s.lineNumber
+}
+
+// Reads the next line of s.ordered.
+func (s *fileSource) nextOrdered() (int, string, bool) {
+	if s.position == len(s.ordered) {
+		return 0, "", false
+	}
+	l := s.ordered[s.position]
+	s.position++
+	return l.number, s.readLine(l), true
+}
+
+// Reads a line found by the prescan, wherever the file is at.
+func (s *fileSource) readLine(l orderedLine) string {
+	if err := s.lines.SeekLine(l.offset, l.number-1); err != nil {
+		log.Panic(err)
+	}
+	_, line, ok := s.lines.Next()
+	if !ok {
+		if err := s.lines.Err(); err != nil {
+			log.Panic(err)
+		}
+		log.Panicf("line %d: unexpected end of file", l.number)
+	}
+	return line
+}
+
+func (s *fileSource) Progress() float64 {
+	if s.ordered != nil {
+		return float64(s.position) / float64(len(s.ordered))
+	}
+	if s.lineCount == 0 {
+		return 1
+	}
+	return float64(s.lineNumber) / float64(s.lineCount)
+}
+
+// Enumerates every graph with a given number of vertices: each bit pattern of the upper triangle of the adjacency
+// matrix is a graph.
+type enumerationSource struct {
+	size          uint8
+	connectedOnly bool
+	index         uint64 // next bit pattern
+	limit         uint64 // number of bit patterns
+	skipped       func() // called for each graph in the shard which isn't connected
+}
+
+func newEnumerationSource(size uint8, connectedOnly bool, skipped func()) *enumerationSource {
+	if size < 1 || size > 8 {
+		log.Panicf("invalid size: %d, expecting 1 to 8 vertices", size)
+	}
+	edges := uint(size) * uint(size-1) / 2
+	return &enumerationSource{size: size, connectedOnly: connectedOnly, limit: 1 << edges, skipped: skipped}
+}
+
+// Returns the number of bit patterns in the shard being processed.
+func (s *enumerationSource) shardCount() int {
+	count := 0
+	if uint64(args.Solve.Shard) < s.limit {
+		count = int((s.limit-uint64(args.Solve.Shard)-1)/uint64(args.Solve.NumShards)) + 1
+	}
+	return count
+}
+
+func (s *enumerationSource) Next() (int, string, bool) {
+	for ; s.index < s.limit; s.index++ {
+		number := int(s.index) + 1
+		if !inShard(number) {
+			continue
+		}
+		g := pondersolve.FromUpperTriangle(s.size, s.index)
+		if s.connectedOnly && !g.Connected() {
+			s.skipped()
+			continue
+		}
+		s.index++
+		return number, g.Matrix(), true
+	}
+	return 0, "", false
+}
+
+func (s *enumerationSource) Progress() float64 {
+	return float64(s.index) / float64(s.limit)
+}
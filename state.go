@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Parses a state written like a row of the matrix: character i is '1' when vertex i is infected.
+func parseState(text string, size uint8) (int, error) {
+	if len(text) != int(size) {
+		return 0, fmt.Errorf("invalid state %q, expecting %d characters", text, size)
+	}
+	state := 0
+	for i, c := range text {
+		switch c {
+		case '0':
+		case '1':
+			state |= 1 << uint(i)
+		default:
+			return 0, fmt.Errorf("invalid state %q, unknown character '%c'", text, c)
+		}
+	}
+	return state, nil
+}
+
+// Formats a state like a row of the matrix, see parseState.
+func formatState(state int, size uint8) string {
+	var r strings.Builder
+	for i := uint8(0); i < size; i++ {
+		if state&(1<<i) != 0 {
+			r.WriteByte('1')
+		} else {
+			r.WriteByte('0')
+		}
+	}
+	return r.String()
+}
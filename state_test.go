@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestParseState(t *testing.T) {
+	tests := []struct {
+		text  string
+		size  uint8
+		state int
+		ok    bool
+	}{
+		{"10110000", 8, 0x0d, true},
+		{"00000001", 8, 0x80, true},
+		{"0", 1, 0, true},
+		{"111", 3, 7, true},
+		{"1011", 8, 0, false},
+		{"10110000", 4, 0, false},
+		{"10210000", 8, 0, false},
+		{"", 0, 0, true},
+	}
+	for _, tt := range tests {
+		state, err := parseState(tt.text, tt.size)
+		if (err == nil) != tt.ok || (tt.ok && state != tt.state) {
+			t.Errorf("parseState(%q, %d) = %b, %v", tt.text, tt.size, state, err)
+			continue
+		}
+		if tt.ok && formatState(state, tt.size) != tt.text {
+			t.Errorf("formatState(%b, %d) = %q, want %q", state, tt.size, formatState(state, tt.size), tt.text)
+		}
+	}
+}
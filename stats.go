@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Number of malformed line numbers reported by stats.
+const statsMalformedExamples = 10
+
+// Summary of a database of graphs.
+type databaseStats struct {
+	Lines          int         `json:"lines"`
+	Graphs         int         `json:"graphs"`
+	Vertices       map[int]int `json:"vertices"` // number of graphs by vertex count
+	Edges          map[int]int `json:"edges"`    // number of graphs by edge count
+	Connected      int         `json:"connected"`
+	Duplicates     int         `json:"duplicates"`
+	Malformed      int         `json:"malformed"`
+	TooLarge       int         `json:"too_large"`
+	NotSquare      int         `json:"not_square"`
+	BadCharacter   int         `json:"bad_character"`
+	Asymmetric     int         `json:"asymmetric"`
+	MalformedLines []int       `json:"malformed_lines"` // first few malformed line numbers
+}
+
+// Describes the content of a database of graphs.
+func stats() {
+	file, err := os.Open(args.Stats.Graphs)
+	if err != nil {
+		fatalf("%s", err)
+	}
+	defer file.Close()
+
+	s := databaseStats{Vertices: make(map[int]int), Edges: make(map[int]int), MalformedLines: []int{}}
+	var malformed malformedLines
+	// This takes about 25MB per million distinct graphs.
+	seen := make(map[[9]byte]struct{})
+	fileScanner := bufio.NewScanner(file)
+	for fileScanner.Scan() {
+		s.Lines++
+		g, err := pondersolve.ParseGraph(fileScanner.Text())
+		if err == nil {
+			err = checkUndirected(&g, args.Stats.Directed)
+		}
+		if err != nil {
+			malformed.add(err)
+			if len(s.MalformedLines) < statsMalformedExamples {
+				s.MalformedLines = append(s.MalformedLines, s.Lines)
+			}
+			continue
+		}
+		s.Graphs++
+		s.Vertices[int(g.Size())]++
+		s.Edges[g.EdgeCount()]++
+		if g.Connected() {
+			s.Connected++
+		}
+		key := g.Compact()
+		if _, ok := seen[key]; ok {
+			s.Duplicates++
+		}
+		seen[key] = struct{}{}
+	}
+	if err := fileScanner.Err(); err != nil {
+		log.Panic(err)
+	}
+	s.Malformed, s.TooLarge, s.NotSquare, s.BadCharacter, s.Asymmetric = malformed.total, malformed.tooLarge, malformed.notSquare,
+		malformed.badCharacter, malformed.asymmetric
+
+	if args.Stats.JSON {
+		b, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			log.Panic(err)
+		}
+		fmt.Println(string(b))
+		return
+	}
+	fmt.Printf("lines: %d\n", s.Lines)
+	fmt.Printf("graphs: %d\n", s.Graphs)
+	fmt.Println("vertex count distribution:")
+	for vertices := 0; vertices <= pondersolve.MaxSize; vertices++ {
+		if count := s.Vertices[vertices]; count > 0 {
+			fmt.Printf("%2d vertices: %d\n", vertices, count)
+		}
+	}
+	fmt.Println("edge count distribution:")
+	maxEdges := pondersolve.MaxSize * (pondersolve.MaxSize - 1) / 2
+	for edges := 0; edges <= maxEdges; edges++ {
+		if count := s.Edges[edges]; count > 0 {
+			fmt.Printf("%2d edges: %d\n", edges, count)
+		}
+	}
+	fmt.Printf("connected: %d\n", s.Connected)
+	fmt.Printf("exact duplicates: %d\n", s.Duplicates)
+	fmt.Printf("malformed lines: %d", s.Malformed)
+	if s.Malformed > 0 {
+		fmt.Printf(" (%s), first lines: %v", malformed, s.MalformedLines)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Snapshot of a solve run, printed on demand (see notifyStatus). The solve goroutine updates it through the source
+// and estimator it wraps, and through improved. Every access holds mu.
+type solveStatus struct {
+	mu         sync.Mutex
+	source     pondersolve.Source
+	estimator  pondersolve.Estimator
+	startTime  time.Time
+	total      int
+	lineNumber int // number of the last graph read from the source
+	read       int // graphs read from the source
+	targets    []float64
+	best       map[float64]pondersolve.Solution // closest solution to each target so far
+}
+
+func newSolveStatus(source pondersolve.Source, estimator pondersolve.Estimator, total int, targets []float64) *solveStatus {
+	return &solveStatus{
+		source:    source,
+		estimator: estimator,
+		startTime: time.Now(),
+		total:     total,
+		targets:   targets,
+		best:      make(map[float64]pondersolve.Solution),
+	}
+}
+
+func (s *solveStatus) Next() (int, string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	number, matrix, ok := s.source.Next()
+	if ok {
+		s.lineNumber = number
+		s.read++
+	}
+	return number, matrix, ok
+}
+
+func (s *solveStatus) Progress() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.source.Progress()
+}
+
+func (s *solveStatus) Processed(size int, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.estimator.Processed(size, d)
+}
+
+func (s *solveStatus) Skipped(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.estimator.Skipped(size)
+}
+
+func (s *solveStatus) ETA() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.estimator.ETA()
+}
+
+func (s *solveStatus) improved(sol pondersolve.Solution) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.best[sol.Target] = sol
+}
+
+// Prints the current line, throughput, time and best solutions.
+func (s *solveStatus) dump(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elapsed := time.Since(s.startTime)
+	fmt.Fprintf(w, "status: line %d, %d/%d graphs, %.1f graphs/s, elapsed: %s, eta: %s\n", s.lineNumber, s.read,
+		s.total, float64(s.read)/math.Max(elapsed.Seconds(), 1e-9), elapsed.Round(time.Millisecond), s.estimator.ETA())
+	for _, target := range s.targets {
+		best, ok := s.best[target]
+		if !ok {
+			fmt.Fprintf(w, "target %g: no solution yet\n", target)
+			continue
+		}
+		fmt.Fprintf(w, "target %g: v=%g, line %d: %s, initial vertex: %d, pivoted: %s\n", target, best.Value, best.Number,
+			best.Matrix, best.InitialVertex, best.Graph.Matrix())
+	}
+}
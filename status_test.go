@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Solves two copies of the puzzle graph for the targets through a solveStatus, with a tolerance of 0.01, processing the
+// given number of graphs.
+func solvedStatus(t *testing.T, targets []float64, graphs int) *solveStatus {
+	t.Helper()
+	g, err := pondersolve.ParseMatrix(testPuzzleMatrix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := pondersolve.NewSliceSource([]pondersolve.Graph{g, g})
+	status := newSolveStatus(source, &etaEstimator{minSize: 1, maxSize: 8}, 2, targets)
+	solver, err := pondersolve.NewSolver(status, pondersolve.SolveOptions{
+		Targets:    targets,
+		Tolerance:  0.01,
+		Top:        1,
+		MinDays:    30,
+		MaxDays:    30,
+		Rate:       0.1,
+		OnImproved: status.improved,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < graphs; i++ {
+		if _, _, err := solver.Next(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return status
+}
+
+func TestStatusDump(t *testing.T) {
+	tests := []struct {
+		name   string
+		graphs int
+		want   []string
+	}{
+		{"before the first graph", 0, []string{"status: line 0, 0/2 graphs", "target 0.7: no solution yet", "target 0.5: no solution yet"}},
+		{"after the first graph", 1, []string{"status: line 1, 1/2 graphs", "target 0.7: v=0.6999898686018191, line 1: " + testPuzzleMatrix + ", initial vertex: 0",
+			"target 0.5: no solution yet"}},
+		{"after every graph", 2, []string{"status: line 2, 2/2 graphs", "target 0.7: v=0.6999898686018191, line 1: "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			solvedStatus(t, []float64{0.7, 0.5}, tt.graphs).dump(&b)
+			for _, want := range tt.want {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("got %q, want %q in it", b.String(), want)
+				}
+			}
+		})
+	}
+}
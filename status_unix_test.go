@@ -0,0 +1,57 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// A writer which can be read while another goroutine writes to it, closing written after the first write.
+type notifyingBuffer struct {
+	mu      sync.Mutex
+	b       bytes.Buffer
+	once    sync.Once
+	written chan struct{}
+}
+
+func (b *notifyingBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.once.Do(func() { close(b.written) })
+	return b.b.Write(p)
+}
+
+func (b *notifyingBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestNotifyStatus(t *testing.T) {
+	status := solvedStatus(t, []float64{0.7}, 1)
+	w := &notifyingBuffer{written: make(chan struct{})}
+	stop := notifyStatus(status, w)
+	defer stop()
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no status 5s after SIGUSR1")
+	}
+	// the dump is written in several calls
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(w.String(), "target 0.7: v=0.6999898686018191") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := w.String(); !strings.HasPrefix(got, "status: line 1, 1/2 graphs") || !strings.Contains(got, "target 0.7: v=0.6999898686018191") {
+		t.Errorf("SIGUSR1 printed %q", got)
+	}
+}
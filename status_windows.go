@@ -0,0 +1,8 @@
+package main
+
+import "io"
+
+// There is no SIGUSR1 on Windows.
+func notifyStatus(status *solveStatus, w io.Writer) func() {
+	return func() {}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Computes the probability for graphs which change from day to day, see pondersolve.ComputeSchedule.
+func computeSchedule() {
+	schedule, err := readSchedule()
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	size := schedule[0].Size()
+	if args.Compute.InitialVertex >= size {
+		log.Printf("invalid initial vertex %d, graphs have %d vertices", args.Compute.InitialVertex, size)
+		exit(exitInvalidInput)
+	}
+	algorithm, err := selectAlgorithm(args.Compute.Algorithm, size, args.Compute.Days)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+	if args.Compute.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.Compute.MaxDuration)
+		defer cancel()
+	}
+
+	// validated by validateArgs
+	model, _ := pondersolve.ParseModel(args.Compute.Model)
+	r, err := pondersolve.ComputeSchedule(ctx, schedule, args.Compute.Days, args.Compute.Rate, pondersolve.WithAlgorithm(algorithm),
+		pondersolve.WithModel(model))
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("computation took longer than %s", args.Compute.MaxDuration)
+		exit(exitInterrupted)
+	case errors.Is(err, context.Canceled):
+		log.Print("computation interrupted")
+		exit(exitInterrupted)
+	case err != nil:
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	value := r[args.Compute.InitialVertex]
+	fmt.Printf("probability of all vertices infected after %d days, starting from vertex %d, with a schedule of %d graphs: %g%%\n",
+		args.Compute.Days, args.Compute.InitialVertex, len(schedule), value*100.0)
+	if args.Compute.Target >= 0 {
+		checkComputeTarget(value)
+	}
+}
+
+// Returns the graph used on each day of the cycle, from --graph-schedule or --graph-schedule-file and
+// --schedule-pattern.
+func readSchedule() ([]pondersolve.Graph, error) {
+	var matrices []string
+	if args.Compute.GraphSchedule != "" {
+		matrices = strings.Split(args.Compute.GraphSchedule, "|")
+	} else {
+		file, err := os.Open(args.Compute.GraphScheduleFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		fileScanner := bufio.NewScanner(file)
+		for fileScanner.Scan() {
+			if line := strings.TrimSpace(fileScanner.Text()); line != "" {
+				matrices = append(matrices, line)
+			}
+		}
+		if err := fileScanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var graphs []pondersolve.Graph
+	for k, matrix := range matrices {
+		g, err := pondersolve.ParseMatrix(strings.TrimSpace(matrix))
+		if err != nil {
+			return nil, fmt.Errorf("graph %d of the schedule: %s", k, err)
+		}
+		if err := checkUndirected(&g, args.Compute.Directed); err != nil {
+			return nil, fmt.Errorf("graph %d of the schedule: %s", k, err)
+		}
+		graphs = append(graphs, g)
+	}
+	if len(graphs) == 0 {
+		return nil, fmt.Errorf("the schedule doesn't contain any graph")
+	}
+	if args.Compute.SchedulePattern == "" {
+		return graphs, nil
+	}
+
+	var schedule []pondersolve.Graph
+	for _, field := range strings.Split(args.Compute.SchedulePattern, ",") {
+		k, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || k < 0 || k >= len(graphs) {
+			return nil, fmt.Errorf("invalid schedule pattern %q, expecting comma separated indexes of the %d graphs", args.Compute.SchedulePattern, len(graphs))
+		}
+		schedule = append(schedule, graphs[k])
+	}
+	return schedule, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Prints the args.Compute.TopStates most probable states of a distribution returned by StateDistribution. States with
+// the same probability are listed in increasing order of their bitmask.
+func printTopStates(distribution []float64, size uint8) {
+	// unreachable states aren't listed
+	var states []int
+	for state, p := range distribution {
+		if p > 0 {
+			states = append(states, state)
+		}
+	}
+	sort.SliceStable(states, func(i, j int) bool {
+		return distribution[states[i]] > distribution[states[j]]
+	})
+	if len(states) > args.Compute.TopStates {
+		states = states[:args.Compute.TopStates]
+	}
+
+	fmt.Printf("most probable states after %d days, starting from vertex %d:\n", args.Compute.Days, args.Compute.InitialVertex)
+	fmt.Printf("%-10s %-24s %s\n", "state", "probability", "cumulative")
+	cumulative := 0.0
+	for _, state := range states {
+		cumulative += distribution[state]
+		fmt.Printf("%-10s %-24g %g\n", formatState(state, size), distribution[state], cumulative)
+	}
+}
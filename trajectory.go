@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Prints the probability that every vertex is infected after each day, cumulative[d], along with the expected number
+// of infected vertices when distributions isn't nil, as a table or as CSV in args.Compute.CSVOut.
+func printTrajectory(cumulative []float64, distributions [][]float64) {
+	header := []string{"day", "probability"}
+	if distributions != nil {
+		header = append(header, "mean infected")
+	}
+	var rows [][]string
+	for d := 1; d < len(cumulative); d++ {
+		row := []string{fmt.Sprint(d), fmt.Sprint(cumulative[d])}
+		if distributions != nil {
+			mean, _ := pondersolve.InfectedMoments(distributions[d])
+			row = append(row, fmt.Sprint(mean))
+		}
+		rows = append(rows, row)
+	}
+
+	if args.Compute.CSVOut == "" {
+		fmt.Printf("probability of all vertices infected after each day, starting from vertex %d:\n", args.Compute.InitialVertex)
+		format := "%-5s %s\n"
+		if distributions != nil {
+			format = "%-5s %-24s %s\n"
+		}
+		for _, row := range append([][]string{header}, rows...) {
+			values := make([]interface{}, len(row))
+			for i, value := range row {
+				values[i] = value
+			}
+			fmt.Printf(format, values...)
+		}
+		return
+	}
+
+	file, err := os.Create(args.Compute.CSVOut)
+	if err != nil {
+		fatalf("invalid --csv-out: %s", err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	header[len(header)-1] = strings.Replace(header[len(header)-1], " ", "_", -1)
+	for _, row := range append([][]string{header}, rows...) {
+		fmt.Fprintln(w, strings.Join(row, ","))
+	}
+	if err := w.Flush(); err != nil {
+		log.Panic(err)
+	}
+	fmt.Printf("trajectory for days 1 to %d written to %s\n", len(cumulative)-1, args.Compute.CSVOut)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Immune sets whose probabilities are this close are reported as ties.
+const vaccinationTieTolerance = 1e-12
+
+// Tries every set of --budget immune vertices and reports the sets which minimize the probability that all the other
+// vertices get infected. With --all-initial, the worst case over every initial vertex which isn't immune is minimized.
+func optimizeVaccination() {
+	opts := &args.OptimizeVaccination
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	n := g.Size()
+	if opts.Budget < 0 || opts.Budget >= int(n) {
+		log.Printf("invalid budget %d, expecting 0 to %d for a graph with %d vertices", opts.Budget, int(n)-1, n)
+		exit(exitInvalidInput)
+	}
+	if !opts.AllInitial && opts.InitialVertex >= n {
+		log.Printf("invalid initial vertex %d, graph has %d vertices", opts.InitialVertex, n)
+		exit(exitInvalidInput)
+	}
+	algorithm, err := selectAlgorithm(opts.Algorithm, n, opts.Days)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+
+	// probability that every vertex which isn't immune gets infected
+	evaluate := func(immune []uint8) float64 {
+		r, err := g.ComputeImmune(ctx, opts.Days, opts.Rate, immune, pondersolve.WithAlgorithm(algorithm))
+		switch {
+		case errors.Is(err, context.Canceled):
+			log.Print("computation interrupted")
+			exit(exitInterrupted)
+		case err != nil:
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		if !opts.AllInitial {
+			return r[opts.InitialVertex]
+		}
+		// immune vertices have a probability of 0, which doesn't affect the worst case
+		worst := 0.0
+		for _, p := range r {
+			worst = math.Max(worst, p)
+		}
+		return worst
+	}
+
+	baseline := evaluate(nil)
+	best := math.Inf(1)
+	var bestSets [][]uint8
+	evaluated := 0
+	for pattern := uint64(1)<<uint(opts.Budget) - 1; pattern < 1<<n; pattern = nextCombination(pattern) {
+		if !opts.AllInitial && pattern&(1<<opts.InitialVertex) != 0 {
+			continue
+		}
+		var immune []uint8
+		for v := uint8(0); v < n; v++ {
+			if pattern&(1<<v) != 0 {
+				immune = append(immune, v)
+			}
+		}
+		p := evaluate(immune)
+		evaluated++
+		switch {
+		case p < best-vaccinationTieTolerance:
+			best = p
+			bestSets = [][]uint8{immune}
+		case p <= best+vaccinationTieTolerance:
+			bestSets = append(bestSets, immune)
+		}
+	}
+
+	objective := fmt.Sprintf("starting from vertex %d", opts.InitialVertex)
+	if opts.AllInitial {
+		objective = "worst case over the initial vertices"
+	}
+	fmt.Printf("probability of all the other vertices infected after %d days, %s\n", opts.Days, objective)
+	fmt.Printf("without immune vertices: %g%%\n", baseline*100.0)
+	fmt.Printf("best with %d immune vertices: %g%% (%d sets evaluated)\n", opts.Budget, best*100.0, evaluated)
+	for _, immune := range bestSets {
+		fmt.Printf("immune vertices: %v\n", immune)
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Reports an error in the input found once the command runs, e.g. a missing file, the same way kong reports the errors
+// of validateArgs, and exits with exitInvalidInput.
+func fatalf(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, "%s: error: %s\n", filepath.Base(os.Args[0]), fmt.Sprintf(format, a...))
+	exit(exitInvalidInput)
+}
+
+// Checks the flags which kong can't check on its own, before running a command. The returned error fits on a single
+// line.
+func validateArgs(command string) error {
+	switch command {
+	case "compute":
+		c := &args.Compute
+		warnNoDays(c.Days)
+		if err := c.cacheFlags.check(); err != nil {
+			return err
+		}
+		if err := checkModel(c.Model); err != nil {
+			return err
+		}
+		if c.Model != "independent" && independentAnalyses() {
+			return fmt.Errorf("--model %s only applies to the probability, --first-passage, --trajectory and --initial-dist, the other analyses assume the independent model", c.Model)
+		}
+		if c.TrajectoryMean && !c.Trajectory {
+			return fmt.Errorf("--trajectory-mean is only used with --trajectory")
+		}
+		if c.Correlations && c.Trajectory && c.CSVOut != "" {
+			return fmt.Errorf("--csv-out can only hold one of --correlations and --trajectory")
+		}
+		if c.Algorithm == monteCarloAlgorithm {
+			if computeAnalyses() || c.RateSweep != "" || c.GraphsFile != "" || c.GraphSchedule != "" || c.GraphScheduleFile != "" || c.Cache != "" {
+				return fmt.Errorf("--algorithm monte-carlo only estimates the probability for --graph, it can't be used with --rate-sweep, --graphs-file, --graph-schedule, --cache or the other analyses")
+			}
+			if c.Samples < 1 {
+				return fmt.Errorf("invalid --samples %d, expecting at least 1", c.Samples)
+			}
+		}
+		if c.Recovery != 0 {
+			if !(c.Recovery > 0 && c.Recovery <= 1) {
+				return fmt.Errorf("invalid recovery probability: %g, expecting a value in (0, 1]", c.Recovery)
+			}
+			if computeAnalyses() || c.RateSweep != "" || c.GraphsFile != "" || c.GraphSchedule != "" || c.GraphScheduleFile != "" ||
+				c.Algorithm == monteCarloAlgorithm || c.JSON || c.Target >= 0 || c.Cache != "" {
+				return fmt.Errorf("--recovery only prints the SIS probabilities for --graph, it can't be used with --rate-sweep, --graphs-file, --graph-schedule, --algorithm monte-carlo, --json, --target, --cache or the other analyses")
+			}
+		}
+		if c.Incubation != 0 {
+			if c.Incubation > pondersolve.MaxIncubation {
+				return fmt.Errorf("invalid incubation: %d days, expecting at most %d", c.Incubation, pondersolve.MaxIncubation)
+			}
+			if computeAnalyses() || c.RateSweep != "" || c.GraphsFile != "" || c.GraphSchedule != "" || c.GraphScheduleFile != "" ||
+				c.Algorithm == monteCarloAlgorithm || c.JSON || c.Cache != "" || c.Recovery != 0 {
+				return fmt.Errorf("--incubation only prints the SEI probabilities for --graph, it can't be used with --rate-sweep, --graphs-file, --graph-schedule, --algorithm monte-carlo, --json, --cache, --recovery or the other analyses")
+			}
+		}
+		if c.Initial != "" {
+			if computeAnalyses() || c.RateSweep != "" || c.GraphsFile != "" || c.GraphSchedule != "" || c.GraphScheduleFile != "" || isWeightedMatrix(c.Graph) ||
+				c.Algorithm == monteCarloAlgorithm || c.JSON || c.Cache != "" || c.Recovery != 0 || c.Incubation != 0 || c.InitialVertex != 0 {
+				return fmt.Errorf("--initial only prints the probability for --graph, it can't be used with --rate-sweep, --graphs-file, --graph-schedule, a weighted --graph, --algorithm monte-carlo, --json, --cache, --recovery, --incubation, --initial-vertex or the other analyses")
+			}
+		}
+		var target error
+		if c.Target >= 0 {
+			target = checkTarget(c.Target)
+		}
+		var rate error
+		if c.RateSweep == "" {
+			rate = checkRate(c.Rate)
+		}
+		if c.Permute != "" {
+			if _, err := parsePermutation(c.Permute); err != nil {
+				return err
+			}
+		}
+		if c.GraphsFile != "" {
+			if c.Graph != "" || c.GraphSchedule != "" || c.GraphScheduleFile != "" || c.RateSweep != "" || c.Target >= 0 || c.Permute != "" || c.Complement || c.Restrict != "" {
+				return fmt.Errorf("--graphs-file can't be used with --graph, --graph-schedule, --rate-sweep, --target, --permute, --complement or --restrict")
+			}
+			if computeAnalyses() {
+				return fmt.Errorf("--graphs-file only prints probabilities, it can't be used with the other analyses")
+			}
+			return rate
+		}
+		if c.GraphSchedule != "" || c.GraphScheduleFile != "" {
+			if c.Graph != "" || c.RateSweep != "" || c.Permute != "" || c.Complement || c.Restrict != "" || (c.GraphSchedule != "" && c.GraphScheduleFile != "") {
+				return fmt.Errorf("--graph-schedule and --graph-schedule-file can't be used together, or with --graph, --rate-sweep, --permute, --complement or --restrict")
+			}
+			if computeAnalyses() {
+				return fmt.Errorf("--graph-schedule only prints probabilities, it can't be used with the other analyses")
+			}
+			if c.SchedulePattern != "" && c.GraphScheduleFile == "" {
+				return fmt.Errorf("--schedule-pattern is only used with --graph-schedule-file")
+			}
+			return firstError(rate, target, checkTolerance(c.Tolerance))
+		}
+		if c.Graph == "" {
+			return fmt.Errorf("expecting one of --graph, --graphs-file, --graph-schedule or --graph-schedule-file")
+		}
+		if c.AllVertices {
+			return fmt.Errorf("--all-vertices is only used with --graphs-file")
+		}
+		if c.JSON && (computeAnalyses() || c.RateSweep != "" || c.Algorithm == monteCarloAlgorithm || c.CacheStats) {
+			return fmt.Errorf("--json only prints the probabilities, it can't be used with --rate-sweep, --algorithm monte-carlo, --cache-stats or the other analyses")
+		}
+		if isWeightedMatrix(c.Graph) {
+			if computeAnalyses() || c.RateSweep != "" || c.Algorithm == monteCarloAlgorithm || c.Model != "independent" || c.JSON || c.Cache != "" ||
+				c.Recovery != 0 || c.Incubation != 0 || c.Permute != "" || c.Complement || c.Restrict != "" {
+				return fmt.Errorf("a weighted --graph only prints the probability, it can't be used with --rate-sweep, --algorithm monte-carlo, --model, --json, --cache, --recovery, --incubation, --permute, --complement, --restrict or the other analyses")
+			}
+			g, rates, err := pondersolve.ParseWeightedMatrix(c.Graph)
+			if err != nil {
+				return fmt.Errorf("invalid --graph: %s", err)
+			}
+			for i := range rates {
+				for j := range rates {
+					if rates[i][j] != rates[j][i] && !c.Directed {
+						return fmt.Errorf("invalid --graph: %s", errAsymmetric)
+					}
+				}
+			}
+			return firstError(checkUndirected(&g, c.Directed), target, checkTolerance(c.Tolerance))
+		}
+		if g, err := pondersolve.ParseMatrix(c.Graph); err == nil {
+			if err := checkUndirected(&g, c.Directed); err != nil {
+				return fmt.Errorf("invalid --graph: %s", err)
+			}
+			if c.Restrict != "" {
+				if _, err := parseVertexSet(c.Restrict, g.Size()); err != nil {
+					return err
+				}
+			}
+		}
+		return firstError(checkGraph("graph", c.Graph), rate, target, checkTolerance(c.Tolerance))
+	case "solve":
+		s := &args.Solve
+		if err := s.cacheFlags.check(); err != nil {
+			return err
+		}
+		if s.JSON && s.CacheStats {
+			return fmt.Errorf("--cache-stats can't be used with --json")
+		}
+		if err := checkModel(s.Model); err != nil {
+			return err
+		}
+		if s.Interval && s.Model != "independent" {
+			return fmt.Errorf("--interval only supports the independent model")
+		}
+		if s.NumShards < 1 || s.Shard < 0 || s.Shard >= s.NumShards {
+			return fmt.Errorf("invalid shard: expecting 0 <= shard (%d) < num-shards (%d)", s.Shard, s.NumShards)
+		}
+		if (s.Graphs == "") == (s.GenerateSize == 0) {
+			return fmt.Errorf("expecting either --graphs or --generate-size")
+		}
+		if s.Graphs == "" {
+			if err := checkVertices("generate-size", s.GenerateSize); err != nil {
+				return err
+			}
+			if s.Order != "file" {
+				return fmt.Errorf("--order is only used with --graphs")
+			}
+		}
+		if !(s.NearMiss >= 0) || (s.NearMiss > 0) != (s.NearMissOut != "") {
+			return fmt.Errorf("--near-miss and --near-miss-out must be used together, with a positive distance")
+		}
+		if s.Top < 1 {
+			return fmt.Errorf("invalid --top: %d, expecting at least 1 solution to report", s.Top)
+		}
+		if s.NearMissLimit < 0 {
+			return fmt.Errorf("invalid near miss limit: %d, expecting 0 for no limit or a positive number", s.NearMissLimit)
+		}
+		if s.OrderCalibrate && s.Order != "heuristic" {
+			return fmt.Errorf("--order-calibrate is only used with --order heuristic")
+		}
+		if s.Checkpoint != "" {
+			// only the position in the file is saved, not the order of the lines or the graphs already seen
+			if s.Graphs == "" || s.Order != "file" {
+				return fmt.Errorf("--checkpoint is only used with --graphs and --order file")
+			}
+			if s.DedupeExact || s.DedupeIsomorphic {
+				return fmt.Errorf("--checkpoint can't be used with --dedupe-exact or --dedupe-isomorphic")
+			}
+			if s.CheckpointInterval <= 0 {
+				return fmt.Errorf("invalid checkpoint interval: %s, expecting a positive duration", s.CheckpointInterval)
+			}
+		}
+		if s.Resume && s.Checkpoint == "" {
+			return fmt.Errorf("--resume is used with --checkpoint")
+		}
+		if len(s.Constraint) > 0 {
+			if s.Interval {
+				return fmt.Errorf("--interval can't be used with --constraint")
+			}
+			if s.Days != 0 || s.DaysMin != 0 || s.DaysMax != 0 {
+				return fmt.Errorf("--constraint can't be used with --days, --days-min or --days-max")
+			}
+			if _, err := parseConstraints(s.Constraint, s.Tolerance); err != nil {
+				return err
+			}
+			return firstError(checkRate(s.Rate), checkTolerance(s.Tolerance))
+		}
+		minDays, maxDays := s.Days, s.Days
+		if s.DaysMin != 0 || s.DaysMax != 0 {
+			minDays, maxDays = s.DaysMin, s.DaysMax
+		}
+		if maxDays == 0 || minDays > maxDays {
+			return fmt.Errorf("invalid number of days: use --days or --days-min <= --days-max")
+		}
+		for _, target := range s.Target {
+			if err := checkTarget(target); err != nil {
+				return err
+			}
+		}
+		return firstError(checkRate(s.Rate), checkTolerance(s.Tolerance))
+	case "search":
+		s := &args.Search
+		warnNoDays(s.Days)
+		var graph error
+		if s.Graph != "" {
+			graph = checkGraph("graph", s.Graph)
+		} else {
+			graph = checkVertices("n", s.N)
+		}
+		if s.Strategy == "anneal" {
+			// annealing toggles edges between two distinct vertices
+			size := s.N
+			if g, err := pondersolve.ParseMatrix(s.Graph); s.Graph != "" && err == nil {
+				size = g.Size()
+			}
+			if size < 2 {
+				return fmt.Errorf("annealing requires at least 2 vertices")
+			}
+		}
+		if s.Strategy == "lattice" && s.N > 7 {
+			return fmt.Errorf("lattice search supports at most 7 vertices")
+		}
+		return firstError(graph, checkRate(s.Rate), checkTarget(s.Target), checkTolerance(s.Tolerance))
+	case "generate":
+		g := &args.Generate
+		if err := checkVertices("n", g.N); err != nil {
+			return err
+		}
+		if slots := int(g.N) * int(g.N-1) / 2; g.Edges > slots {
+			return fmt.Errorf("invalid number of edges: %d, graphs with %d vertices have at most %d edges", g.Edges, g.N, slots)
+		}
+	case "random-graphs":
+		r := &args.RandomGraphs
+		if err := checkVertices("n", r.N); err != nil {
+			return err
+		}
+		if r.P < 0 || r.P > 1 {
+			return fmt.Errorf("invalid edge probability: %g, expecting a value in [0, 1]", r.P)
+		}
+		if r.Count < 1 {
+			return fmt.Errorf("invalid count: %d, expecting at least 1 graph", r.Count)
+		}
+		if r.ConnectedOnly && r.P == 0 && r.N > 1 {
+			return fmt.Errorf("graphs with %d vertices are never connected when the edge probability is 0", r.N)
+		}
+	case "convert":
+		if args.Convert.Permute == "" {
+			return nil
+		}
+		if args.Convert.Canonicalize {
+			return fmt.Errorf("--permute has no effect with --canonicalize")
+		}
+		_, err := parsePermutation(args.Convert.Permute)
+		return err
+	case "isomorphic":
+		return firstError(checkGraph("a", args.Isomorphic.A), checkGraph("b", args.Isomorphic.B))
+	case "diff":
+		d := &args.Diff
+		warnNoDays(d.Days)
+		if err := firstError(checkGraph("a", d.A), checkGraph("b", d.B), checkRate(d.Rate)); err != nil {
+			return err
+		}
+		a, _ := pondersolve.ParseMatrix(d.A)
+		b, _ := pondersolve.ParseMatrix(d.B)
+		if d.InitialVertex >= a.Size() || d.InitialVertex >= b.Size() {
+			return fmt.Errorf("invalid initial vertex %d, the graphs have %d and %d vertices", d.InitialVertex, a.Size(), b.Size())
+		}
+		if d.Align && a.Size() != b.Size() {
+			return fmt.Errorf("--align requires graphs with the same number of vertices")
+		}
+	case "canonicalize":
+		return checkGraph("graph", args.Canonicalize.Graph)
+	case "verify":
+		v := &args.Verify
+		warnNoDays(v.Days)
+		return firstError(checkGraph("graph", v.Graph), checkRate(v.Rate), checkTarget(v.Target), checkTolerance(v.Tolerance))
+	case "compare":
+		warnNoDays(args.Compare.Days)
+		return firstError(checkGraph("graph", args.Compare.Graph), checkRate(args.Compare.Rate))
+	case "optimize-vaccination":
+		o := &args.OptimizeVaccination
+		warnNoDays(o.Days)
+		return firstError(checkGraph("graph", o.Graph), checkRate(o.Rate))
+	case "optimize-seeds":
+		o := &args.OptimizeSeeds
+		warnNoDays(o.Days)
+		return firstError(checkGraph("graph", o.Graph), checkRate(o.Rate))
+	case "optimize-cuts":
+		o := &args.OptimizeCuts
+		warnNoDays(o.Days)
+		return firstError(checkGraph("graph", o.Graph), checkRate(o.Rate))
+	case "likelihood":
+		return firstError(checkGraph("graph", args.Likelihood.Graph), checkRate(args.Likelihood.Rate))
+	case "estimate-rate":
+		if args.EstimateRate.Grid < 2 {
+			return fmt.Errorf("invalid grid: %d, expecting at least 2 rates", args.EstimateRate.Grid)
+		}
+		return checkGraph("graph", args.EstimateRate.Graph)
+	case "solve-rate":
+		r := &args.SolveRate
+		warnNoDays(r.Days)
+		if g, err := pondersolve.ParseMatrix(r.Graph); err == nil && r.InitialVertex >= g.Size() {
+			return fmt.Errorf("invalid initial vertex %d, graph has %d vertices", r.InitialVertex, g.Size())
+		}
+		return firstError(checkGraph("graph", r.Graph), checkTarget(r.Target), checkModel(r.Model))
+	case "solve-days":
+		d := &args.SolveDays
+		if g, err := pondersolve.ParseMatrix(d.Graph); err == nil && d.InitialVertex >= g.Size() {
+			return fmt.Errorf("invalid initial vertex %d, graph has %d vertices", d.InitialVertex, g.Size())
+		}
+		return firstError(checkGraph("graph", d.Graph), checkRate(d.Rate), checkTarget(d.Target))
+	case "export-transitions":
+		return firstError(checkGraph("graph", args.ExportTransitions.Graph), checkRate(args.ExportTransitions.Rate))
+	case "simulate-trace":
+		t := &args.SimulateTrace
+		if t.Runs < 1 {
+			return fmt.Errorf("invalid number of runs: %d, expecting at least 1", t.Runs)
+		}
+		if g, err := pondersolve.ParseMatrix(t.Graph); err == nil && t.InitialVertex >= g.Size() {
+			return fmt.Errorf("invalid initial vertex %d, graph has %d vertices", t.InitialVertex, g.Size())
+		}
+		return firstError(checkGraph("graph", t.Graph), checkRate(t.Rate), checkModel(t.Model))
+	case "whatif":
+		w := &args.Whatif
+		warnNoDays(w.Days)
+		return firstError(checkGraph("graph", w.Graph), checkRate(w.Rate), checkTarget(w.Target), checkTolerance(w.Tolerance))
+	}
+	return nil
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkGraph(flag, matrix string) error {
+	if _, err := pondersolve.ParseMatrix(matrix); err != nil {
+		return fmt.Errorf("invalid --%s: %s", flag, err)
+	}
+	return nil
+}
+
+var errAsymmetric = fmt.Errorf("%w, use --directed for directed graphs", pondersolve.ErrAsymmetric)
+
+// Checks that the graph is undirected, unless the command's --directed is set.
+func checkUndirected(g *pondersolve.Graph, directed bool) error {
+	if !directed && !g.Symmetric() {
+		return errAsymmetric
+	}
+	return nil
+}
+
+func checkVertices(flag string, n uint8) error {
+	if n < 1 || n > pondersolve.MaxSize {
+		return fmt.Errorf("invalid number of vertices --%s=%d, expecting 1 to %d", flag, n, pondersolve.MaxSize)
+	}
+	return nil
+}
+
+func checkRate(rate float64) error {
+	if !(rate >= 0 && rate <= 1) {
+		return fmt.Errorf("invalid rate %g, expecting a probability in [0, 1]", rate)
+	}
+	return nil
+}
+
+func checkTarget(target float64) error {
+	if !(target > 0 && target < 1) {
+		return fmt.Errorf("invalid target %g, expecting a probability in (0, 1)", target)
+	}
+	return nil
+}
+
+func checkTolerance(tolerance float64) error {
+	if !(tolerance >= 0) {
+		return fmt.Errorf("invalid tolerance %g, expecting a non-negative value", tolerance)
+	}
+	return nil
+}
+
+// A day count of 0 is valid, but the answer is trivial.
+func warnNoDays(days uint) {
+	if days == 0 {
+		log.Print("warning: with --days=0 only the initially infected vertex is infected")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+// Parses the command line into args and checks it with validateArgs, as main does.
+func validateCommandLine(t *testing.T, commandLine ...string) error {
+	t.Helper()
+	ctx, err := kong.Must(&args).Parse(commandLine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return validateArgs(ctx.Command())
+}
+
+// Parses a valid command line into args.
+func parseArgs(t *testing.T, commandLine ...string) {
+	t.Helper()
+	if err := validateCommandLine(t, commandLine...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	// copies base, so that the test cases don't share it
+	with := func(base []string, flags ...string) []string {
+		return append(append([]string{}, base...), flags...)
+	}
+	compute := []string{"compute", "--days", "3"}
+	graph := with(compute, "--graph", "011,101,110")
+	weighted := with(compute, "--graph", "0,0.1;0.1,0")
+	graphsFile := with(compute, "--graphs-file", "graphs.txt")
+	schedule := with(compute, "--graph-schedule", "01,10|01,10")
+	solve := []string{"solve", "--graphs", "graphs.txt", "--days", "3"}
+	constraint := []string{"solve", "--graphs", "graphs.txt", "--constraint", "days=3,target=0.5"}
+	tests := []struct {
+		commandLine []string
+		want        string // part of the error, "" when the command line is valid
+	}{
+		{graph, ""},
+		{with(compute, "--graph", "01,00", "--directed"), ""},
+		{weighted, ""},
+		{graphsFile, ""},
+		{schedule, ""},
+		{with(graph, "--cache-stats"), "--cache-stats requires --cache"},
+		{with(graph, "--model", "sir"), "invalid --model"},
+		{with(graph, "--model", "linear", "--variance"), "--model linear only applies to"},
+		{with(graph, "--trajectory-mean"), "--trajectory-mean is only used with --trajectory"},
+		{with(graph, "--correlations", "--trajectory", "--csv-out", "out.csv"), "--csv-out can only hold one of"},
+		{with(graph, "--algorithm", "monte-carlo", "--variance"), "--algorithm monte-carlo only estimates"},
+		{with(graph, "--algorithm", "monte-carlo", "--samples", "0"), "invalid --samples 0"},
+		{with(graph, "--recovery", "1.5"), "invalid recovery probability"},
+		{with(graph, "--recovery", "0.5", "--json"), "--recovery only prints"},
+		{with(graph, "--incubation", "15"), "invalid incubation"},
+		{with(graph, "--incubation", "2", "--json"), "--incubation only prints"},
+		{with(graph, "--initial", "0,1", "--json"), "--initial only prints"},
+		{with(graph, "--permute", "0,0,1"), "invalid permutation"},
+		{with(graphsFile, "--complement"), "--graphs-file can't be used with"},
+		{with(graphsFile, "--variance"), "--graphs-file only prints"},
+		{with(graphsFile, "--rate", "2"), "invalid rate 2"},
+		{with(schedule, "--graph", "01,10"), "can't be used together, or with --graph"},
+		{with(schedule, "--graph-schedule-file", "schedule.txt"), "can't be used together, or with --graph"},
+		{with(schedule, "--variance"), "--graph-schedule only prints"},
+		{with(schedule, "--schedule-pattern", "0,1"), "--schedule-pattern is only used with --graph-schedule-file"},
+		{with(schedule, "--target", "1"), "invalid target 1"},
+		{with(schedule, "--tolerance=-1"), "invalid tolerance -1"},
+		{compute, "expecting one of --graph"},
+		{with(graph, "--all-vertices"), "--all-vertices is only used with --graphs-file"},
+		{with(graph, "--json", "--cache-stats", "--cache", "cache.txt"), "--json only prints the probabilities"},
+		{with(weighted, "--complement"), "a weighted --graph only prints the probability"},
+		{with(compute, "--graph", "0,0.1;0.1"), "invalid --graph"},
+		{with(compute, "--graph", "0,0.1;0.2,0"), "isn't symmetric"},
+		{with(weighted, "--target", "0"), "invalid target 0"},
+		{with(weighted, "--tolerance=-1"), "invalid tolerance -1"},
+		{with(compute, "--graph", "01,00"), "isn't symmetric"},
+		{with(graph, "--restrict", "0,3"), "invalid vertices"},
+		{with(compute, "--graph", "012"), "invalid --graph"},
+		{with(graph, "--rate=-0.1"), "invalid rate -0.1"},
+		{with(graph, "--target", "1"), "invalid target 1"},
+		{with(graph, "--tolerance=-1"), "invalid tolerance -1"},
+
+		{solve, ""},
+		{constraint, ""},
+		{[]string{"solve", "--generate-size", "4", "--days", "3"}, ""},
+		{with(solve, "--cache-stats"), "--cache-stats requires --cache"},
+		{with(solve, "--cache", "cache.txt", "--cache-stats", "--json"), "--cache-stats can't be used with --json"},
+		{with(solve, "--model", "sir"), "invalid --model"},
+		{with(solve, "--model", "linear", "--interval"), "--interval only supports the independent model"},
+		{with(solve, "--shard", "2", "--num-shards", "2"), "invalid shard"},
+		{with(solve, "--num-shards", "0"), "invalid shard"},
+		{[]string{"solve", "--days", "3"}, "expecting either --graphs or --generate-size"},
+		{with(solve, "--generate-size", "4"), "expecting either --graphs or --generate-size"},
+		{[]string{"solve", "--generate-size", "9", "--days", "3"}, "invalid number of vertices --generate-size=9"},
+		{[]string{"solve", "--generate-size", "4", "--days", "3", "--order", "random"}, "--order is only used with --graphs"},
+		{with(solve, "--near-miss", "0.1"), "--near-miss and --near-miss-out must be used together"},
+		{with(solve, "--near-miss-out", "near.txt"), "--near-miss and --near-miss-out must be used together"},
+		{with(solve, "--top", "0"), "invalid --top: 0"},
+		{with(solve, "--near-miss-limit=-1"), "invalid near miss limit"},
+		{with(solve, "--order-calibrate"), "--order-calibrate is only used with --order heuristic"},
+		{with(solve, "--checkpoint", "checkpoint.json", "--order", "random"), "--checkpoint is only used with --graphs and --order file"},
+		{[]string{"solve", "--generate-size", "4", "--days", "3", "--checkpoint", "checkpoint.json"}, "--checkpoint is only used with --graphs"},
+		{with(solve, "--checkpoint", "checkpoint.json", "--dedupe-exact"), "--checkpoint can't be used with --dedupe-exact"},
+		{with(solve, "--checkpoint", "checkpoint.json", "--checkpoint-interval", "0s"), "invalid checkpoint interval"},
+		{with(solve, "--resume"), "--resume is used with --checkpoint"},
+		{with(constraint, "--interval"), "--interval can't be used with --constraint"},
+		{with(constraint, "--days", "3"), "--constraint can't be used with --days"},
+		{[]string{"solve", "--graphs", "graphs.txt", "--constraint", "days=0,target=0.5"}, "invalid constraint"},
+		{with(constraint, "--rate", "2"), "invalid rate 2"},
+		{with(constraint, "--tolerance=-1"), "invalid tolerance -1"},
+		{[]string{"solve", "--graphs", "graphs.txt"}, "invalid number of days"},
+		{[]string{"solve", "--graphs", "graphs.txt", "--days-min", "4", "--days-max", "3"}, "invalid number of days"},
+		{with(solve, "--target", "0.5,1"), "invalid target 1"},
+		{with(solve, "--rate", "2"), "invalid rate 2"},
+		{with(solve, "--tolerance=-1"), "invalid tolerance -1"},
+
+		{[]string{"search", "--days", "3", "--n", "4"}, ""},
+		{[]string{"search", "--days", "3", "--n", "9"}, "invalid number of vertices --n=9"},
+		{[]string{"search", "--days", "3", "--graph", "012"}, "invalid --graph"},
+		{[]string{"search", "--days", "3", "--n", "1", "--strategy", "anneal"}, "annealing requires at least 2 vertices"},
+		{[]string{"search", "--days", "3", "--graph", "0", "--strategy", "anneal"}, "annealing requires at least 2 vertices"},
+		{[]string{"search", "--days", "3", "--n", "8", "--strategy", "lattice"}, "lattice search supports at most 7 vertices"},
+		{[]string{"search", "--days", "3", "--n", "4", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"search", "--days", "3", "--n", "4", "--target", "1"}, "invalid target 1"},
+		{[]string{"search", "--days", "3", "--n", "4", "--tolerance=-1"}, "invalid tolerance -1"},
+
+		{[]string{"generate", "--n", "4", "--out", "graphs.txt"}, ""},
+		{[]string{"generate", "--n", "0", "--out", "graphs.txt"}, "invalid number of vertices --n=0"},
+		{[]string{"generate", "--n", "4", "--edges", "7", "--out", "graphs.txt"}, "invalid number of edges: 7"},
+
+		{[]string{"random-graphs", "--n", "4", "--p", "0.5", "--out", "graphs.txt"}, ""},
+		{[]string{"random-graphs", "--n", "9", "--p", "0.5", "--out", "graphs.txt"}, "invalid number of vertices --n=9"},
+		{[]string{"random-graphs", "--n", "4", "--p", "1.5", "--out", "graphs.txt"}, "invalid edge probability"},
+		{[]string{"random-graphs", "--n", "4", "--p", "0.5", "--count", "0", "--out", "graphs.txt"}, "invalid count: 0"},
+		{[]string{"random-graphs", "--n", "4", "--p", "0", "--connected-only", "--out", "graphs.txt"}, "never connected"},
+
+		{[]string{"convert", "--to", "graph6", "--in", "in.txt", "--out", "out.txt", "--permute", "1,0"}, ""},
+		{[]string{"convert", "--to", "graph6", "--in", "in.txt", "--out", "out.txt", "--permute", "1,0", "--canonicalize"}, "--permute has no effect with --canonicalize"},
+		{[]string{"convert", "--to", "graph6", "--in", "in.txt", "--out", "out.txt", "--permute", "1,1"}, "invalid permutation"},
+
+		{[]string{"isomorphic", "--a", "01,10", "--b", "01,10"}, ""},
+		{[]string{"isomorphic", "--a", "012", "--b", "01,10"}, "invalid --a"},
+		{[]string{"isomorphic", "--a", "01,10", "--b", "012"}, "invalid --b"},
+
+		{[]string{"diff", "--a", "01,10", "--b", "011,101,110", "--days", "3"}, ""},
+		{[]string{"diff", "--a", "012", "--b", "01,10", "--days", "3"}, "invalid --a"},
+		{[]string{"diff", "--a", "01,10", "--b", "012", "--days", "3"}, "invalid --b"},
+		{[]string{"diff", "--a", "01,10", "--b", "01,10", "--days", "3", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"diff", "--a", "01,10", "--b", "011,101,110", "--days", "3", "--initial-vertex", "2"}, "invalid initial vertex 2"},
+		{[]string{"diff", "--a", "01,10", "--b", "011,101,110", "--days", "3", "--align"}, "--align requires graphs with the same number of vertices"},
+
+		{[]string{"canonicalize", "--graph", "012"}, "invalid --graph"},
+
+		{[]string{"verify", "--graph", "01,10", "--days", "3"}, ""},
+		{[]string{"verify", "--graph", "012", "--days", "3"}, "invalid --graph"},
+		{[]string{"verify", "--graph", "01,10", "--days", "3", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"verify", "--graph", "01,10", "--days", "3", "--target", "1"}, "invalid target 1"},
+		{[]string{"verify", "--graph", "01,10", "--days", "3", "--tolerance=-1"}, "invalid tolerance -1"},
+
+		{[]string{"compare", "--graph", "012", "--days", "3"}, "invalid --graph"},
+		{[]string{"compare", "--graph", "01,10", "--days", "3", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"optimize-vaccination", "--graph", "012", "--days", "3", "--budget", "1"}, "invalid --graph"},
+		{[]string{"optimize-vaccination", "--graph", "01,10", "--days", "3", "--budget", "1", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"optimize-seeds", "--graph", "012", "--days", "3", "--k", "1"}, "invalid --graph"},
+		{[]string{"optimize-seeds", "--graph", "01,10", "--days", "3", "--k", "1", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"optimize-cuts", "--graph", "012", "--days", "3", "--below", "0.5"}, "invalid --graph"},
+		{[]string{"optimize-cuts", "--graph", "01,10", "--days", "3", "--below", "0.5", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"likelihood", "--graph", "012", "--trajectory", "1,3"}, "invalid --graph"},
+		{[]string{"likelihood", "--graph", "01,10", "--trajectory", "1,3", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"estimate-rate", "--graph", "01,10", "--trajectories", "trajectories.txt", "--grid", "1"}, "invalid grid: 1"},
+		{[]string{"estimate-rate", "--graph", "012", "--trajectories", "trajectories.txt"}, "invalid --graph"},
+
+		{[]string{"solve-rate", "--graph", "01,10", "--days", "3", "--target", "0.5"}, ""},
+		{[]string{"solve-rate", "--graph", "01,10", "--days", "3", "--target", "0.5", "--initial-vertex", "2"}, "invalid initial vertex 2"},
+		{[]string{"solve-rate", "--graph", "012", "--days", "3", "--target", "0.5"}, "invalid --graph"},
+		{[]string{"solve-rate", "--graph", "01,10", "--days", "3", "--target", "1"}, "invalid target 1"},
+		{[]string{"solve-rate", "--graph", "01,10", "--days", "3", "--target", "0.5", "--model", "sir"}, "invalid --model"},
+		{[]string{"solve-days", "--graph", "01,10", "--target", "0.5"}, ""},
+		{[]string{"solve-days", "--graph", "01,10", "--target", "0.5", "--initial-vertex", "2"}, "invalid initial vertex 2"},
+		{[]string{"solve-days", "--graph", "012", "--target", "0.5"}, "invalid --graph"},
+		{[]string{"solve-days", "--graph", "01,10", "--target", "0.5", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"solve-days", "--graph", "01,10", "--target", "1"}, "invalid target 1"},
+		{[]string{"export-transitions", "--graph", "012", "--out", "out.txt"}, "invalid --graph"},
+		{[]string{"export-transitions", "--graph", "01,10", "--out", "out.txt", "--rate", "2"}, "invalid rate 2"},
+
+		{[]string{"simulate-trace", "--graph", "01,10", "--days", "3"}, ""},
+		{[]string{"simulate-trace", "--graph", "01,10", "--days", "3", "--runs", "0"}, "invalid number of runs: 0"},
+		{[]string{"simulate-trace", "--graph", "01,10", "--days", "3", "--initial-vertex", "2"}, "invalid initial vertex 2"},
+		{[]string{"simulate-trace", "--graph", "012", "--days", "3"}, "invalid --graph"},
+		{[]string{"simulate-trace", "--graph", "01,10", "--days", "3", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"simulate-trace", "--graph", "01,10", "--days", "3", "--model", "sir"}, "invalid --model"},
+		{[]string{"whatif", "--graph", "012", "--days", "3"}, "invalid --graph"},
+		{[]string{"whatif", "--graph", "01,10", "--days", "3", "--rate", "2"}, "invalid rate 2"},
+		{[]string{"whatif", "--graph", "01,10", "--days", "3", "--target", "1"}, "invalid target 1"},
+		{[]string{"whatif", "--graph", "01,10", "--days", "3", "--tolerance=-1"}, "invalid tolerance -1"},
+	}
+	for _, tt := range tests {
+		err := validateCommandLine(t, tt.commandLine...)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: got error %q, want no error", strings.Join(tt.commandLine, " "), err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: got error %v, want an error containing %q", strings.Join(tt.commandLine, " "), err, tt.want)
+		}
+	}
+}
+
+func TestAsymmetricLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asymmetric")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "graphs.txt"), filepath.Join(dir, "out.txt")
+	if err := ioutil.WriteFile(in, []byte("01,10\n01,00\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	readLines := func() []string {
+		b, err := ioutil.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Fields(string(b))
+	}
+	for _, directed := range []bool{false, true} {
+		var flags []string
+		want := 1
+		if directed {
+			flags, want = []string{"--directed"}, 2
+		}
+
+		parseArgs(t, append([]string{"dedup", "--in", in, "--out", out}, flags...)...)
+		captureStdout(t, dedup)
+		if lines := readLines(); len(lines) != want {
+			t.Errorf("dedup, directed %t: got %v, want %d graphs", directed, lines, want)
+		}
+
+		parseArgs(t, append([]string{"convert", "--to", "matrix", "--in", in, "--out", out}, flags...)...)
+		captureStdout(t, convert)
+		if lines := readLines(); len(lines) != want {
+			t.Errorf("convert, directed %t: got %v, want %d graphs", directed, lines, want)
+		}
+
+		parseArgs(t, append([]string{"stats", "--graphs", in, "--json"}, flags...)...)
+		var s databaseStats
+		if err := json.Unmarshal([]byte(captureStdout(t, stats)), &s); err != nil {
+			t.Fatal(err)
+		}
+		if s.Graphs != want || s.Asymmetric != 2-want || s.Malformed != 2-want {
+			t.Errorf("stats, directed %t: got %d graphs, %d malformed, %d asymmetric, want %d graphs", directed, s.Graphs, s.Malformed,
+				s.Asymmetric, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Maximum difference between the dp and memoized algorithms for verify to accept a solution.
+const verifyMaxDifference = 1e-9
+
+// Checks a claimed solution: computes the probability for every initial vertex with the dp and memoized algorithms,
+// which share nothing but enumerateNextStates.
+func verify() {
+	g, err := pondersolve.ParseMatrix(args.Verify.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+	fail := func(err error) {
+		log.Print(err)
+		if errors.Is(err, context.Canceled) {
+			exit(exitInterrupted)
+		}
+		exit(exitInvalidInput)
+	}
+	dp, err := g.Compute(ctx, args.Verify.Days, args.Verify.Rate, pondersolve.WithAlgorithm(pondersolve.DP))
+	if err != nil {
+		fail(err)
+	}
+	memoized, err := g.Compute(ctx, args.Verify.Days, args.Verify.Rate, pondersolve.WithAlgorithm(pondersolve.Memoized))
+	if err != nil {
+		fail(err)
+	}
+
+	agree := true
+	var matching []int
+	for i := range dp {
+		difference := math.Abs(dp[i] - memoized[i])
+		within := math.Abs(dp[i]-args.Verify.Target) < args.Verify.Tolerance &&
+			math.Abs(memoized[i]-args.Verify.Target) < args.Verify.Tolerance
+		fmt.Printf("initial vertex %d: dp=%g memoized=%g difference=%g within tolerance: %t\n", i, dp[i], memoized[i], difference, within)
+		if difference > verifyMaxDifference {
+			agree = false
+		}
+		if within {
+			matching = append(matching, i)
+		}
+	}
+
+	if !agree {
+		fmt.Printf("algorithms disagree by more than %g\n", verifyMaxDifference)
+		exit(exitNotVerified)
+	}
+	if len(matching) == 0 {
+		fmt.Printf("no initial vertex within %g of %g\n", args.Verify.Tolerance, args.Verify.Target)
+		exit(exitNotVerified)
+	}
+	fmt.Printf("verified, initial vertices within tolerance: %v\n", matching)
+}
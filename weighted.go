@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Returns whether --graph is a weighted matrix, which holds the rate of each edge instead of 0s and 1s.
+func isWeightedMatrix(matrix string) bool {
+	return strings.Contains(matrix, ";")
+}
+
+// Computes the probability for a weighted --graph, with the rate of each edge instead of --rate.
+func computeEdgeRates() {
+	g, rates, err := pondersolve.ParseWeightedMatrix(args.Compute.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	if args.Compute.InitialVertex >= g.Size() {
+		log.Printf("invalid initial vertex %d, graph has %d vertices", args.Compute.InitialVertex, g.Size())
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+	if args.Compute.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.Compute.MaxDuration)
+		defer cancel()
+	}
+
+	r, err := g.ComputeEdgeRates(ctx, args.Compute.Days, &rates)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		log.Printf("computation took longer than %s", args.Compute.MaxDuration)
+		exit(exitInterrupted)
+	case errors.Is(err, context.Canceled):
+		log.Print("computation interrupted")
+		exit(exitInterrupted)
+	case err != nil:
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	value := r[args.Compute.InitialVertex]
+	fmt.Printf("probability of all vertices infected after %d days, starting from vertex %d, with the rates of the weighted graph: %g%%\n",
+		args.Compute.Days, args.Compute.InitialVertex, value*100.0)
+	if args.Compute.Target >= 0 {
+		checkComputeTarget(value)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/alokmenghrajani/ponderthis-april2020/pondersolve"
+)
+
+// Computes the probability of infecting every vertex, starting from vertex 0, after adding each missing edge (or
+// removing each existing edge, with --remove-edges), and lists the results by increasing probability.
+func whatif() {
+	opts := &args.Whatif
+	if opts.AddEdges == opts.RemoveEdges {
+		log.Print("expecting exactly one of --add-edges and --remove-edges")
+		exit(exitInvalidInput)
+	}
+	g, err := pondersolve.ParseMatrix(opts.Graph)
+	if err != nil {
+		log.Print(err)
+		exit(exitInvalidInput)
+	}
+	ctx, stop := interruptibleContext()
+	defer stop()
+	compute := func(g pondersolve.Graph) float64 {
+		r, err := g.Compute(ctx, opts.Days, opts.Rate, pondersolve.FirstResultOnly())
+		switch {
+		case errors.Is(err, context.Canceled):
+			log.Print("computation interrupted")
+			exit(exitInterrupted)
+		case err != nil:
+			log.Print(err)
+			exit(exitInvalidInput)
+		}
+		return r[0]
+	}
+
+	type change struct {
+		edge  [2]uint8
+		value float64
+	}
+	var changes []change
+	for i := uint8(0); i < g.Size(); i++ {
+		for j := i + 1; j < g.Size(); j++ {
+			if g.HasEdge(i, j) != opts.RemoveEdges {
+				continue
+			}
+			// g is a value, each change starts from the parsed graph
+			changed := g
+			changed.ToggleEdge(i, j)
+			changes = append(changes, change{edge: [2]uint8{i, j}, value: compute(changed)})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].value < changes[j].value
+	})
+
+	before := compute(g)
+	action := "adding"
+	if opts.RemoveEdges {
+		action = "removing"
+	}
+	fmt.Printf("probability of all vertices infected after %d days: %g%%\n", opts.Days, before*100.0)
+	fmt.Printf("after %s an edge:\n", action)
+	fmt.Printf("%-6s %-24s %s\n", "edge", "probability", "delta from target")
+	for _, c := range changes {
+		delta := c.value - opts.Target
+		line := fmt.Sprintf("%d-%-4d %-24g %+g", c.edge[0], c.edge[1], c.value, delta)
+		switch {
+		case (c.value >= opts.Target) != (before >= opts.Target):
+			line += " crosses the target"
+		case delta < opts.Tolerance && delta > -opts.Tolerance:
+			line += " within tolerance"
+		}
+		fmt.Println(line)
+	}
+	if len(changes) == 0 {
+		fmt.Printf("no edges to consider\n")
+	}
+}